@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
+)
+
+// 默认配置，可用环境变量覆盖
+const (
+	DefaultIssuer   = "ielts_test_backend"
+	DefaultAudience = "ielts_test_web"
+	DefaultTokenTTL = 15 * time.Minute // access token 有效期，过期后用 refresh token 换取
+)
+
+var (
+	// ErrInvalidToken token 格式、签名、过期时间、签发者或受众不正确
+	ErrInvalidToken = errors.New("invalid or expired token")
+	// ErrUnknownKey token 的 kid 不在当前密钥列表中（密钥已下线）
+	ErrUnknownKey = errors.New("unknown signing key")
+	// ErrNotConfigured 启动时没有调用 Init
+	ErrNotConfigured = errors.New("jwt is not configured")
+	// ErrMissingKeys 没有配置 JWT_KEYS，不使用默认密钥
+	ErrMissingKeys = errors.New("JWT_KEYS must be set")
+)
+
+// Claims JWT 中的声明，sub 为用户 ID，sid 为登录会话 ID
+type Claims struct {
+	Email     string `json:"email"`
+	RoleID    int    `json:"role_id"`
+	SessionID string `json:"sid"`
+	jwt.StandardClaims
+}
+
+// Config JWT 配置
+// JWT_KEYS 为 "kid:secret" 列表（逗号分隔），JWT_CURRENT_KID 为签发新 token 使用的 kid（默认第一个）；
+// 轮换密钥时先加入新密钥并切换 JWT_CURRENT_KID，旧 token 全部过期后再移除旧密钥
+type Config struct {
+	Keys       map[string][]byte
+	CurrentKID string
+	Issuer     string
+	Audience   string
+	TokenTTL   time.Duration
+}
+
+var (
+	config   *Config
+	configMu sync.RWMutex
+)
+
+// LoadConfig 从环境变量读取 JWT 配置
+func LoadConfig() (*Config, error) {
+	cfg := &Config{
+		Keys:     map[string][]byte{},
+		Issuer:   envOr("JWT_ISSUER", DefaultIssuer),
+		Audience: envOr("JWT_AUDIENCE", DefaultAudience),
+		TokenTTL: DefaultTokenTTL,
+	}
+	if ttl := os.Getenv("JWT_TTL"); ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid JWT_TTL %q", ttl)
+		}
+		cfg.TokenTTL = d
+	}
+
+	first := ""
+	for _, entry := range strings.Split(os.Getenv("JWT_KEYS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kid, secret, ok := strings.Cut(entry, ":")
+		if !ok || kid == "" || len(secret) < 32 {
+			return nil, fmt.Errorf("invalid JWT_KEYS entry %q: expected kid:secret with a secret of at least 32 characters", kid)
+		}
+		cfg.Keys[kid] = []byte(secret)
+		if first == "" {
+			first = kid
+		}
+	}
+	if len(cfg.Keys) == 0 {
+		return nil, ErrMissingKeys
+	}
+
+	cfg.CurrentKID = envOr("JWT_CURRENT_KID", first)
+	if _, ok := cfg.Keys[cfg.CurrentKID]; !ok {
+		return nil, fmt.Errorf("JWT_CURRENT_KID %q is not in JWT_KEYS", cfg.CurrentKID)
+	}
+	return cfg, nil
+}
+
+// Init 从环境变量读取并校验 JWT 配置，启动时调用，返回错误时不能启动
+func Init() error {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	SetConfig(cfg)
+	return nil
+}
+
+// CurrentConfig 返回当前 JWT 配置，没有调用 Init 时返回 nil
+func CurrentConfig() *Config {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return config
+}
+
+// SetConfig 替换 JWT 配置（测试中使用）
+func SetConfig(cfg *Config) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	config = cfg
+}
+
+// Sign 使用当前密钥为用户的会话签发 access token，header 中带上 kid
+func Sign(userID, email string, roleID int, sessionID string) (string, *Claims, error) {
+	cfg := CurrentConfig()
+	if cfg == nil {
+		return "", nil, ErrNotConfigured
+	}
+	now := time.Now()
+	claims := &Claims{
+		Email:     email,
+		RoleID:    roleID,
+		SessionID: sessionID,
+		StandardClaims: jwt.StandardClaims{
+			Subject:   userID,
+			Issuer:    cfg.Issuer,
+			Audience:  cfg.Audience,
+			IssuedAt:  now.Unix(),
+			NotBefore: now.Unix(),
+			ExpiresAt: now.Add(cfg.TokenTTL).Unix(),
+			Id:        uuid.NewString(),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = cfg.CurrentKID
+	signed, err := token.SignedString(cfg.Keys[cfg.CurrentKID])
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, claims, nil
+}
+
+// Parse 校验签名（按 kid 选择密钥）、exp、nbf、iss 和 aud，返回声明
+func Parse(tokenString string) (*Claims, error) {
+	cfg := CurrentConfig()
+	if cfg == nil {
+		return nil, ErrNotConfigured
+	}
+	parser := &jwt.Parser{ValidMethods: []string{jwt.SigningMethodHS256.Alg()}}
+
+	claims := &Claims{}
+	_, err := parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := cfg.Keys[kid]
+		if !ok {
+			return nil, ErrUnknownKey
+		}
+		return key, nil
+	})
+	if err != nil {
+		var validationErr *jwt.ValidationError
+		if errors.As(err, &validationErr) && errors.Is(validationErr.Inner, ErrUnknownKey) {
+			return nil, ErrUnknownKey
+		}
+		return nil, ErrInvalidToken
+	}
+
+	// StandardClaims.Valid 不要求 exp 存在，这里全部要求
+	if claims.ExpiresAt == 0 || claims.Subject == "" || claims.SessionID == "" ||
+		!claims.VerifyIssuer(cfg.Issuer, true) || !claims.VerifyAudience(cfg.Audience, true) {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// TTL 返回 token 剩余有效时间
+func (c *Claims) TTL() time.Duration {
+	return time.Until(time.Unix(c.ExpiresAt, 0))
+}
+
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
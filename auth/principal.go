@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// principalKey gin.Context 中保存当前用户的键
+const principalKey = "auth.principal"
+
+// ErrUnauthenticated 请求中没有已认证的用户
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// Principal 当前请求的已认证用户，由 JWT 中间件写入
+type Principal struct {
+	ID        string
+	Email     string
+	RoleID    int
+	SessionID string // 登录会话 ID（sid）
+	TokenID   string // jti
+	Token     string // 原始 token
+	Claims    *Claims
+}
+
+// SetPrincipal 保存当前用户
+func SetPrincipal(c *gin.Context, principal *Principal) {
+	c.Set(principalKey, principal)
+}
+
+// PrincipalFrom 获取当前用户，没有经过 JWT 中间件时返回 ErrUnauthenticated
+func PrincipalFrom(c *gin.Context) (*Principal, error) {
+	value, ok := c.Get(principalKey)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	principal, ok := value.(*Principal)
+	if !ok || principal == nil {
+		return nil, ErrUnauthenticated
+	}
+	return principal, nil
+}
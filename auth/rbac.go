@@ -0,0 +1,47 @@
+package auth
+
+import "github.com/Queen2333/ielts_test_backend/models"
+
+// Permission 权限
+type Permission string
+
+// 权限列表
+const (
+	PermContentRead     Permission = "content:read"      // 查看题库
+	PermContentOwn      Permission = "content:own"       // 管理自己的题目（type 3）
+	PermContentManage   Permission = "content:manage"    // 管理系统和官方题目（type 1/2）
+	PermContentModerate Permission = "content:moderate"  // 查看、删除其他用户的题目（type 3）
+	PermQuestionGen     Permission = "question:generate" // AI 生成题目草稿
+	PermGrade           Permission = "grading:grade"     // 评分（领取、提交评分、参加校准）
+	PermGradingManage   Permission = "grading:manage"    // 管理评分（分配、校准、双评设置）
+	PermSettingsManage  Permission = "settings:manage"   // 系统设置（分数换算表）
+	PermJobsManage      Permission = "jobs:manage"       // 查看所有后台任务和死信
+	PermRecordsReadAll  Permission = "records:read_all"  // 查看所有用户的做题记录和批改
+	PermRecordsManage   Permission = "records:manage"    // 删除其他用户的做题记录
+)
+
+// rolePermissions 角色权限矩阵
+var rolePermissions = map[int][]Permission{
+	models.RoleStudent: {PermContentRead, PermContentOwn},
+	models.RoleTeacher: {PermContentRead, PermContentOwn, PermQuestionGen, PermGrade},
+	models.RoleEditor:  {PermContentRead, PermContentOwn, PermContentManage, PermQuestionGen},
+	models.RoleAdmin: {
+		PermContentRead, PermContentOwn, PermContentManage, PermContentModerate, PermQuestionGen,
+		PermGrade, PermGradingManage, PermSettingsManage, PermJobsManage, PermRecordsReadAll, PermRecordsManage,
+	},
+}
+
+// Can 检查角色是否拥有权限
+func Can(roleID int, perm Permission) bool {
+	for _, p := range rolePermissions[roleID] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// Can 检查当前用户是否拥有权限
+func (p *Principal) Can(perm Permission) bool {
+	return Can(p.RoleID, perm)
+}
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/Queen2333/ielts_test_backend/models"
+)
+
+var allPermissions = []Permission{
+	PermContentRead, PermContentOwn, PermContentManage, PermContentModerate, PermQuestionGen,
+	PermGrade, PermGradingManage, PermSettingsManage, PermJobsManage, PermRecordsReadAll, PermRecordsManage,
+}
+
+func TestCan(t *testing.T) {
+	tests := []struct {
+		name   string
+		roleID int
+		want   []Permission
+	}{
+		{"student", models.RoleStudent, []Permission{PermContentRead, PermContentOwn}},
+		{"teacher", models.RoleTeacher, []Permission{PermContentRead, PermContentOwn, PermQuestionGen, PermGrade}},
+		{"editor", models.RoleEditor, []Permission{PermContentRead, PermContentOwn, PermContentManage, PermQuestionGen}},
+		{"admin", models.RoleAdmin, allPermissions},
+		{"unknown role", 99, nil},
+		{"negative role", -1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			granted := make(map[Permission]bool)
+			for _, perm := range tt.want {
+				granted[perm] = true
+			}
+			for _, perm := range allPermissions {
+				if got := Can(tt.roleID, perm); got != granted[perm] {
+					t.Errorf("Can(%d, %q) = %v, want %v", tt.roleID, perm, got, granted[perm])
+				}
+			}
+			if Can(tt.roleID, Permission("unknown:perm")) {
+				t.Errorf("Can(%d, unknown:perm) = true, want false", tt.roleID)
+			}
+		})
+	}
+}
+
+func TestPrincipalCan(t *testing.T) {
+	tests := []struct {
+		roleID int
+		perm   Permission
+		want   bool
+	}{
+		{models.RoleStudent, PermContentOwn, true},
+		{models.RoleStudent, PermGrade, false},
+		{models.RoleTeacher, PermGrade, true},
+		{models.RoleTeacher, PermGradingManage, false},
+		{models.RoleEditor, PermContentManage, true},
+		{models.RoleEditor, PermContentModerate, false},
+		{models.RoleAdmin, PermRecordsManage, true},
+	}
+	for _, tt := range tests {
+		p := &Principal{RoleID: tt.roleID}
+		if got := p.Can(tt.perm); got != tt.want {
+			t.Errorf("Principal{RoleID: %d}.Can(%q) = %v, want %v", tt.roleID, tt.perm, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Queen2333/ielts_test_backend/database"
+	"github.com/Queen2333/ielts_test_backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// adminRecordTables 管理员接口中 :skill 对应的做题记录表
+var adminRecordTables = map[string]string{
+	"listening": "listening_records",
+	"reading":   "reading_records",
+	"writing":   "writing_records",
+	"speaking":  "speaking_records",
+	"testing":   "testing_records",
+}
+
+// adminContentTables 管理员接口中 :kind 对应的题目表
+var adminContentTables = map[string]string{
+	"listening":      "listening_list",
+	"listening-part": "listening_part_list",
+	"reading":        "reading_list",
+	"reading-part":   "reading_part_list",
+	"writing":        "writing_list",
+	"writing-part":   "writing_part_list",
+	"speaking":       "speaking_list",
+	"speaking-part":  "speaking_part_list",
+	"testing":        "testing_list",
+}
+
+// adminTable 根据路径参数获取表名，不支持的类型返回 404
+func adminTable(c *gin.Context, tables map[string]string, param string) (string, bool) {
+	table, ok := tables[c.Param(param)]
+	if !ok {
+		utils.HandleResponse(c, http.StatusNotFound, "", "Unknown "+param)
+		return "", false
+	}
+	return table, true
+}
+
+// adminConditions 管理员列表的查询条件：按用户、状态和类型筛选，不限制所有者
+func adminConditions(c *gin.Context) (map[string]interface{}, bool) {
+	var request struct {
+		UserID string `form:"user_id"`
+		Name   string `form:"name"`
+		Status *int   `form:"status"`
+		Type   *int   `form:"type"`
+	}
+	if err := c.ShouldBindQuery(&request); err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid request")
+		return nil, false
+	}
+
+	conditions := map[string]interface{}{}
+	if request.UserID != "" {
+		conditions["user_id"] = request.UserID
+	}
+	if request.Name != "" {
+		conditions["name"] = request.Name
+	}
+	if request.Status != nil {
+		conditions["status"] = *request.Status
+	}
+	if request.Type != nil {
+		conditions["type"] = *request.Type
+	}
+	return conditions, true
+}
+
+// adminList 分页查询，不按当前用户过滤
+func adminList(c *gin.Context, table string) {
+	conditions, ok := adminConditions(c)
+	if !ok {
+		return
+	}
+	pageNo, _ := strconv.Atoi(c.DefaultQuery("pageNo", "1"))
+	pageLimit, _ := strconv.Atoi(c.DefaultQuery("pageLimit", "-1"))
+
+	results, total, err := database.PaginationQuery(table, pageNo, pageLimit, conditions)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to execute pagination query")
+		return
+	}
+	utils.HandleResponse(c, http.StatusOK, map[string]interface{}{
+		"items": results,
+		"total": total,
+	}, "Success")
+}
+
+// adminDetail 根据 ID 查询，不检查所有者
+func adminDetail(c *gin.Context, table string) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid ID")
+		return
+	}
+
+	record, err := database.GetDataById(table, id)
+	if err != nil {
+		if database.IsNoRowsError(err) {
+			utils.HandleResponse(c, http.StatusNotFound, "", "Record not found")
+			return
+		}
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get data by id")
+		return
+	}
+	utils.HandleResponse(c, http.StatusOK, map[string]interface{}{"data": record}, "Success")
+}
+
+// adminDelete 根据 ID 删除，不检查所有者
+func adminDelete(c *gin.Context, table string) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid ID")
+		return
+	}
+
+	rowsAffected, err := database.DeleteData(table, id)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to delete data")
+		return
+	}
+	if rowsAffected == 0 {
+		utils.HandleResponse(c, http.StatusNotFound, "", "Record not found")
+		return
+	}
+	utils.HandleResponse(c, http.StatusOK, nil, "Success")
+}
+
+// @Summary 管理员获取做题记录列表
+// @Description 获取所有用户的做题记录，可按 user_id 筛选；普通接口只返回自己的记录
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param skill path string true "科目：listening / reading / writing / speaking / testing"
+// @Param user_id query string false "用户ID"
+// @Param status query int false "状态"
+// @Param pageNo query int true "页码"
+// @Param pageLimit query int false "每页条数"
+// @Success 200 {object} models.ResponseData
+// @Failure 403 {object} models.ResponseData{data=nil}
+// @Failure 404 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /admin/record/{skill}/list [get]
+func AdminRecordList(c *gin.Context) {
+	table, ok := adminTable(c, adminRecordTables, "skill")
+	if !ok {
+		return
+	}
+	adminList(c, table)
+}
+
+// @Summary 管理员获取做题记录详情
+// @Description 根据ID获取任意用户的做题记录
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param skill path string true "科目：listening / reading / writing / speaking / testing"
+// @Param id path int true "做题记录ID"
+// @Success 200 {object} models.ResponseData
+// @Failure 403 {object} models.ResponseData{data=nil}
+// @Failure 404 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /admin/record/{skill}/detail/{id} [get]
+func AdminRecordDetail(c *gin.Context) {
+	table, ok := adminTable(c, adminRecordTables, "skill")
+	if !ok {
+		return
+	}
+	adminDetail(c, table)
+}
+
+// @Summary 管理员删除做题记录
+// @Description 根据ID删除任意用户的做题记录
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param skill path string true "科目：listening / reading / writing / speaking / testing"
+// @Param id path int true "做题记录ID"
+// @Success 200 {object} models.ResponseData{data=nil}
+// @Failure 403 {object} models.ResponseData{data=nil}
+// @Failure 404 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /admin/record/{skill}/delete/{id} [delete]
+func AdminDeleteRecord(c *gin.Context) {
+	table, ok := adminTable(c, adminRecordTables, "skill")
+	if !ok {
+		return
+	}
+	adminDelete(c, table)
+}
+
+// @Summary 管理员获取题目列表
+// @Description 获取包括所有用户自建题目（type 3）在内的题目列表，可按 user_id 筛选
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param kind path string true "题目类型：listening / listening-part / reading / reading-part / writing / writing-part / speaking / speaking-part / testing"
+// @Param user_id query string false "用户ID"
+// @Param name query string false "试题名称"
+// @Param status query int false "试题状态"
+// @Param type query int false "试题类型"
+// @Param pageNo query int true "页码"
+// @Param pageLimit query int false "每页条数"
+// @Success 200 {object} models.ResponseData
+// @Failure 403 {object} models.ResponseData{data=nil}
+// @Failure 404 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /admin/content/{kind}/list [get]
+func AdminContentList(c *gin.Context) {
+	table, ok := adminTable(c, adminContentTables, "kind")
+	if !ok {
+		return
+	}
+	adminList(c, table)
+}
+
+// @Summary 管理员获取题目详情
+// @Description 根据ID获取题目，包括其他用户的自建题目
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param kind path string true "题目类型：listening / listening-part / reading / reading-part / writing / writing-part / speaking / speaking-part / testing"
+// @Param id path int true "题目ID"
+// @Success 200 {object} models.ResponseData
+// @Failure 403 {object} models.ResponseData{data=nil}
+// @Failure 404 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /admin/content/{kind}/detail/{id} [get]
+func AdminContentDetail(c *gin.Context) {
+	table, ok := adminTable(c, adminContentTables, "kind")
+	if !ok {
+		return
+	}
+	adminDetail(c, table)
+}
+
+// @Summary 管理员删除题目
+// @Description 根据ID删除题目，包括其他用户的自建题目
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param kind path string true "题目类型：listening / listening-part / reading / reading-part / writing / writing-part / speaking / speaking-part / testing"
+// @Param id path int true "题目ID"
+// @Success 200 {object} models.ResponseData{data=nil}
+// @Failure 403 {object} models.ResponseData{data=nil}
+// @Failure 404 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /admin/content/{kind}/delete/{id} [delete]
+func AdminDeleteContent(c *gin.Context) {
+	table, ok := adminTable(c, adminContentTables, "kind")
+	if !ok {
+		return
+	}
+	adminDelete(c, table)
+}
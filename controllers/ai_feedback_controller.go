@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Queen2333/ielts_test_backend/auth"
+	"github.com/Queen2333/ielts_test_backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// AIFeedbackRequest 请求 AI 批改的记录
+type AIFeedbackRequest struct {
+	RecordType string `json:"record_type"` // writing / testing
+	RecordID   int    `json:"record_id"`
+}
+
+// @Summary 获取写作 AI 批改
+// @Description 获取记录的 AI 批改（四项预估分数及说明、改错和词汇替换建议），与老师评分分开；仅记录所属学生和管理员可查看
+// @Tags AI
+// @Accept json
+// @Produce json
+// @Param record_type query string true "记录类型：writing / testing"
+// @Param record_id query int true "记录ID"
+// @Success 200 {object} models.ResponseData{data=models.AIWritingFeedbackItem}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 404 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /ai/writing/feedback [get]
+func AIWritingFeedbackDetail(c *gin.Context) {
+	user, err := utils.GetUserFromToken(c)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+		return
+	}
+
+	recordType := c.Query("record_type")
+	recordID, err := strconv.Atoi(c.Query("record_id"))
+	if err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid record ID")
+		return
+	}
+
+	// 老师评分时不展示 AI 分数，避免影响评分
+	_, ownerID, err := utils.LoadWritingEssays(recordType, recordID)
+	if err != nil || (user.ID != ownerID && !auth.Can(user.RoleID, auth.PermRecordsReadAll)) {
+		utils.HandleResponse(c, http.StatusNotFound, "", "Record not found")
+		return
+	}
+
+	feedback, err := utils.FindAIWritingFeedback(recordType, recordID)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get AI feedback")
+		return
+	}
+	if feedback == nil {
+		utils.HandleResponse(c, http.StatusNotFound, "", "AI feedback not found")
+		return
+	}
+	utils.HandleResponse(c, http.StatusOK, feedback, "Success")
+}
+
+// @Summary 重新请求写作 AI 批改
+// @Description 记录所属学生可在批改失败或完成后重新请求 AI 批改，批改在后台执行
+// @Tags AI
+// @Accept json
+// @Produce json
+// @Param request body AIFeedbackRequest true "记录"
+// @Success 200 {object} models.ResponseData{data=models.AIWritingFeedbackItem}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 404 {object} models.ResponseData{data=nil}
+// @Failure 409 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /ai/writing/feedback/request [post]
+func RequestAIWritingFeedback(c *gin.Context) {
+	userID, err := utils.GetUserIDFromToken(c)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+		return
+	}
+
+	var request AIFeedbackRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid request")
+		return
+	}
+
+	essays, ownerID, err := utils.LoadWritingEssays(request.RecordType, request.RecordID)
+	if err != nil || ownerID != userID {
+		utils.HandleResponse(c, http.StatusNotFound, "", "Record not found")
+		return
+	}
+	if len(essays) == 0 {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "No essay to review")
+		return
+	}
+
+	feedback, err := utils.RequestAIWritingFeedback(request.RecordType, request.RecordID, userID)
+	if errors.Is(err, utils.ErrAIFeedbackInProgress) {
+		utils.HandleResponse(c, http.StatusConflict, feedback, err.Error())
+		return
+	}
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to request AI feedback")
+		return
+	}
+	utils.HandleResponse(c, http.StatusOK, feedback, "Success")
+}
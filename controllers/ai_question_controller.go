@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Queen2333/ielts_test_backend/models"
+	"github.com/Queen2333/ielts_test_backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary AI 生成题目草稿
+// @Description 根据阅读文章（或已有阅读 part）或听力原文以及题型要求生成题组，结构与 part 的 type_list 一致；题号从 start_no 开始统一编号，并按录题规则校验答案。结果为草稿，不会保存，需人工审核 problems 后再通过 part 接口保存
+// @Tags AI
+// @Accept json
+// @Produce json
+// @Param request body models.QuestionDraftRequest true "文章和题型要求"
+// @Success 200 {object} models.ResponseData{data=models.QuestionDraft}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 403 {object} models.ResponseData{data=nil}
+// @Failure 502 {object} models.ResponseData{data=nil}
+// @Router /ai/question/generate [post]
+func GenerateQuestionDraft(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var request models.QuestionDraftRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid request")
+		return
+	}
+
+	draft, err := utils.GenerateQuestionDraft(c.Request.Context(), user.ID, request)
+	if err != nil {
+		if errors.Is(err, utils.ErrDraftOutput) || errors.Is(err, utils.ErrLLMRequest) {
+			utils.HandleResponse(c, http.StatusBadGateway, "", err.Error())
+			return
+		}
+		utils.HandleResponse(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+	utils.HandleResponse(c, http.StatusOK, draft, "Success")
+}
@@ -1,30 +1,28 @@
 package controllers
 
 import (
-	"encoding/json"
-	"fmt"
+	"errors"
 	"net/http"
 	"time"
 
+	"github.com/Queen2333/ielts_test_backend/auth"
 	"github.com/Queen2333/ielts_test_backend/database"
 	"github.com/Queen2333/ielts_test_backend/models"
 	"github.com/Queen2333/ielts_test_backend/utils"
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
-var jwtSecret = []byte("qC2dACu+zgx94ALrfmCTESkxoqfCG4ItCWknbz+XmfTfWNDvFeuYKOGXgAKqSq+7Bdu8jXrxZfpWwE0K0jPLHw==") // Replace with a secure secret key
-
 // @Summary 用户登录
-// @Description 用户使用邮箱和验证码登录系统，如果用户不存在，则自动创建新用户
+// @Description 用户使用邮箱和验证码登录系统，如果用户不存在，则自动创建新用户。返回短期 access token 和用于刷新的 refresh token
 // @Accept json
 // @Produce json
 // @Param email body string true "用户邮箱地址"
 // @Param code body string true "验证码"
-// @Success 200 {object} models.ResponseData
+// @Success 200 {object} models.ResponseData{data=models.TokenPair}
 // @Failure 400 {object} models.ResponseData
 // @Failure 401 {object} models.ResponseData
+// @Failure 429 {object} models.ResponseData
 // @Failure 500 {object} models.ResponseData
 // @Router /login [post]
 func LoginHandler(c *gin.Context) {
@@ -38,60 +36,77 @@ func LoginHandler(c *gin.Context) {
 		return
 	}
 
-	// 获取验证码
-	storedCode, err := utils.Get("verification_code_" + request.Email)
-	if err != nil {
-		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to retrieve verification code")
+	request.Email = utils.NormalizeEmail(request.Email)
+	if request.Email == "" || request.Code == "" {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Email and code are required")
 		return
 	}
 
-	// 验证码校验
-	if request.Code != storedCode {
-		utils.HandleResponse(c, http.StatusUnauthorized, "", "Invalid verification code")
+	// 验证码校验，成功后验证码失效；连续输错会锁定
+	ip := c.ClientIP()
+	if err := utils.VerifyOTP(request.Email, request.Code, ip); err != nil {
+		switch {
+		case errors.Is(err, utils.ErrOTPLocked):
+			utils.HandleResponse(c, http.StatusTooManyRequests, "", err.Error())
+		case errors.Is(err, utils.ErrOTPInvalid):
+			utils.HandleResponse(c, http.StatusUnauthorized, "", "Invalid verification code")
+		default:
+			utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to verify code")
+		}
 		return
 	}
 
-	// 检查数据库中是否存在用户
-	user, err := checkUserExists(request.Email)
+	// 检查数据库中是否存在用户，不存在时自动创建
+	user, err := findOrCreateUser(request.Email)
 	if err != nil {
-		if database.IsNoRowsError(err) {
-			// 符合条件的用户不存在
-			user, err = createUser(request.Email)
-			if err != nil {
-				utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to create user")
-				return
-			}
-		} else {
-			// 其他错误
-			utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to check user existence")
-			return
-		}
+		utils.HandleResponse(c, http.StatusInternalServerError, "", err.Error())
+		return
 	}
 
-	// 生成 JWT
-	token, err := GenerateJWT(c, user.ID)
+	utils.AuditAuth(models.AuditOTPVerified, request.Email, ip, user.ID, "")
+
+	respondWithToken(c, user)
+}
+
+// respondWithToken 为登录用户创建会话，返回 access token 和 refresh token
+func respondWithToken(c *gin.Context, user models.UserQuery) {
+	session, refreshToken, err := utils.CreateSession(user.ID, c.Request.UserAgent(), c.ClientIP())
 	if err != nil {
-		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to generate token")
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to create session")
 		return
 	}
 
-	_, err = utils.Get(token)
+	token, ttl, err := GenerateJWT(user, session.ID)
 	if err != nil {
-		// 将 token 存储到 Redis 中，有效期为24小时
-		userString, err := json.Marshal(user)
-		if err != nil {
-			fmt.Println("Error:", err)
-			return
-		}
-		err = utils.Set(token, userString, 24 * time.Hour)
-		if err != nil {
-			utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to store user in Redis")
-			return
-		}
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to generate token")
+		return
 	}
+	// 记录用户语言，评分完成等通知邮件使用该语言
+	utils.SaveUserLocale(user.ID, c.GetHeader("Accept-Language"))
+
+	utils.HandleResponse(c, http.StatusOK, models.TokenPair{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(ttl.Seconds()),
+		User:         user,
+	}, "success")
+}
 
-	// 返回 token
-	utils.HandleResponse(c, http.StatusOK, gin.H{"token": token, "user": user}, "success")
+// findOrCreateUser 获取邮箱对应的用户，不存在时创建
+func findOrCreateUser(email string) (models.UserQuery, error) {
+	user, err := checkUserExists(email)
+	if err == nil {
+		return user, nil
+	}
+	if !database.IsNoRowsError(err) {
+		return models.UserQuery{}, errors.New("Failed to check user existence")
+	}
+	// 符合条件的用户不存在
+	user, err = createUser(email)
+	if err != nil {
+		return models.UserQuery{}, errors.New("Failed to create user")
+	}
+	return user, nil
 }
 
 // 检查数据库中是否存在指定邮箱的用户
@@ -110,6 +125,17 @@ func checkUserExists(email string) (models.UserQuery, error) {
 	return user, nil
 }
 
+// checkUserByID 按 ID 获取用户
+func checkUserByID(userID string) (models.UserQuery, error) {
+	var user models.UserQuery
+	err := database.GetDB().QueryRow("SELECT id, email, role_id FROM user_list WHERE id = ?", userID).
+		Scan(&user.ID, &user.Email, &user.RoleID)
+	if err != nil {
+		return models.UserQuery{}, err
+	}
+	return user, nil
+}
+
 // 在数据库中创建用户
 func createUser(email string) (models.UserQuery, error) {
 	// 生成 UUID
@@ -135,30 +161,22 @@ func createUser(email string) (models.UserQuery, error) {
 	return newUser, nil
 }
 
-// GenerateJWT 生成 JWT
-func GenerateJWT(c *gin.Context, user_id string) (string, error) {
-	// 尝试从 Redis 获取已存在的 token
-	tokenString, err := utils.Get("token_" + user_id)
+// GenerateJWT 为会话生成短期 access token（sub 为用户 ID，sid 为会话 ID，header 带 kid），返回 token 和有效时间
+func GenerateJWT(user models.UserQuery, sessionID string) (string, time.Duration, error) {
+	tokenString, claims, err := auth.Sign(user.ID, user.Email, user.RoleID, sessionID)
 	if err != nil {
-		// Redis 中没有 token，创建新的 token（这是正常情况，不是错误）
-		token := jwt.New(jwt.SigningMethodHS256)
-		claims := token.Claims.(jwt.MapClaims)
-		claims["email"] = user_id
-		claims["exp"] = time.Now().Add(time.Hour * 24).Unix() // 设置过期时间为 24 小时
-
-		// 签名 JWT
-		tokenString, err = token.SignedString(jwtSecret)
-		if err != nil {
-			return "", err
-		}
-
-		// 将 token 存储到 Redis
-		err = utils.Set("token_" + user_id, tokenString, 24 * time.Hour)
-		if err != nil {
-			// 存储失败时记录日志，但不影响登录流程
-			fmt.Printf("Warning: Failed to store token in Redis: %v\n", err)
-		}
+		return "", 0, err
 	}
+	return tokenString, claims.TTL(), nil
+}
 
-	return tokenString, nil
-}
\ No newline at end of file
+// currentUser 获取当前登录用户，未登录时直接返回 401
+// 权限由路由上的 middlewares.RequirePermission 检查，处理函数中不再重复检查
+func currentUser(c *gin.Context) (models.UserQuery, bool) {
+	user, err := utils.GetUserFromToken(c)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+		return models.UserQuery{}, false
+	}
+	return user, true
+}
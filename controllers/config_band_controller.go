@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Queen2333/ielts_test_backend/database"
+	"github.com/Queen2333/ielts_test_backend/models"
+	"github.com/Queen2333/ielts_test_backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary 获取分数换算表
+// @Description 获取听力、学术类阅读、培训类阅读的答对题数与 Band 分换算表
+// @Tags Band
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.ResponseData{data=[]models.BandTableItem}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /config/band-table/list [get]
+func BandTableList(c *gin.Context) {
+	var tables []models.BandTableItem
+	for _, defaultTable := range utils.DefaultBandTables() {
+		table, err := utils.GetBandTable(defaultTable.Skill, defaultTable.Module)
+		if err != nil {
+			utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get band table")
+			return
+		}
+		tables = append(tables, table)
+	}
+
+	utils.HandleResponse(c, http.StatusOK, tables, "Success")
+}
+
+// @Summary 更新分数换算表
+// @Description 管理员修改指定科目和考试类型的分数换算表
+// @Tags Band
+// @Accept json
+// @Produce json
+// @Param table body models.BandTableItem true "换算表内容"
+// @Success 200 {object} models.ResponseData{data=nil}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 403 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /config/band-table/update [put]
+func UpdateBandTable(c *gin.Context) {
+	var table models.BandTableItem
+	if err := c.ShouldBindJSON(&table); err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid request")
+		return
+	}
+
+	switch table.Skill {
+	case models.SkillListening:
+		table.Module = ""
+	case models.SkillReading:
+		module, err := utils.NormalizeModule(table.Module)
+		if err != nil {
+			utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid module")
+			return
+		}
+		table.Module = module
+	default:
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid skill")
+		return
+	}
+
+	if err := utils.ValidateBandRows(table.Conversion); err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	// 已存在则更新，否则新增
+	existing, err := utils.GetBandTable(table.Skill, table.Module)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get band table")
+		return
+	}
+	handleType := "create"
+	if existing.ID != 0 {
+		table.ID = existing.ID
+		handleType = "update"
+	}
+
+	result, err := database.InsertData("band_tables", &table, handleType)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to save band table")
+		return
+	}
+
+	utils.HandleResponse(c, http.StatusOK, result, "Success")
+}
@@ -76,11 +76,10 @@ func ListeningDetail(c *gin.Context) {
 		return
 	}
 
-	record, err := database.GetDataById("listening_list", id)
-    if err != nil {
-        utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get data by id")
-        return
-    }
+	record, ok := visibleContent(c, "listening_list", id)
+	if !ok {
+		return
+	}
 
 	// 返回查询结果
 	response := map[string]interface{}{
@@ -107,16 +106,20 @@ func AddListening(c *gin.Context) {
 		return
 	}
 
+	// 官方题目需要编辑权限，用户题目记录创建者
+	user, ok := authorizeNewContent(c, contentTypeOf(part.Type.Int()))
+	if !ok {
+		return
+	}
+	part.UserID = ""
 	if part.Type.Int() == 3 {
-		userID, err := utils.GetUserIDFromToken(c)
-		if err != nil {
-			// 处理获取 user_id 失败的情况
-			utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
-			return
-		}
+		part.UserID = user.ID
+	}
 
-		// 将 user_id 添加到 part 中
-		part.UserID = userID
+	// 校验套题组成：4 个 part，题号 1-40
+	problems, err := utils.ValidateListeningComposition(part.PartList, part.UserID)
+	if !checkComposition(c, problems, err) {
+		return
 	}
 
 	// 将数据插入数据库
@@ -148,20 +151,20 @@ func UpdateListening(c *gin.Context) {
 		return
 	}
 
-	// 如果 type=3（用户自定义），需要保留 user_id
-	if part.Type.Int() == 3 {
-		// 如果请求中没有提供 user_id，从数据库获取原有的 user_id
-		if part.UserID == "" {
-			existingData, err := database.GetDataById("listening_list", part.ID)
-			if err != nil {
-				utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get existing data")
-				return
-			}
+	// 检查原题目和修改后的 type 的权限，创建者不能通过请求修改
+	existingData, _, ok := authorizeExistingContent(c, "listening_list", part.ID, setTypeOf(part.Type))
+	if !ok {
+		return
+	}
+	part.UserID, _ = existingData["user_id"].(string)
+	if part.Type.Int() == 0 {
+		part.Type = existingSetType(existingData)
+	}
 
-			if existingUserID, ok := existingData["user_id"].(string); ok {
-				part.UserID = existingUserID
-			}
-		}
+	// 校验套题组成：4 个 part，题号 1-40
+	problems, err := utils.ValidateListeningComposition(part.PartList, part.UserID)
+	if !checkComposition(c, problems, err) {
+		return
 	}
 
 	// 将数据更新到数据库
@@ -194,6 +197,10 @@ func DeleteListening(c *gin.Context) {
 		return
 	}
 
+	if _, _, ok := authorizeExistingContent(c, "listening_list", id, ""); !ok {
+		return
+	}
+
 	// 执行删除操作
 	rowsAffected, err := database.DeleteData("listening_list", id)
 	if err != nil {
@@ -268,11 +275,10 @@ func ListeningPartDetail(c *gin.Context) {
 		return
 	}
 
-	record, err := database.GetDataById("listening_part_list", id)
-    if err != nil {
-        utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get data by id")
-        return
-    }
+	record, ok := visibleContent(c, "listening_part_list", id)
+	if !ok {
+		return
+	}
 
 	// 返回查询结果
 	response := map[string]interface{}{
@@ -298,13 +304,12 @@ func AddListeningPart(c *gin.Context) {
 		return
 	}
 
-	// 获取当前用户ID并设置到part中
-	userID, err := utils.GetUserIDFromToken(c)
-	if err != nil {
-		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+	// 官方题目需要编辑权限；记录创建者
+	user, ok := authorizeNewContent(c, contentTypeOf(part.Type))
+	if !ok {
 		return
 	}
-	part.UserID = userID
+	part.UserID = user.ID
 
 	// 将数据插入数据库
 	result, err := database.InsertData("listening_part_list", &part, "create")
@@ -334,18 +339,14 @@ func UpdateListeningPart(c *gin.Context) {
 		return
 	}
 
-	// 获取原有数据以保留 user_id
-	existingData, err := database.GetDataById("listening_part_list", part.ID)
-	if err != nil {
-		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get existing data")
+	// 检查原题目和修改后的 type 的权限，保留原有的 user_id
+	existingData, _, ok := authorizeExistingContent(c, "listening_part_list", part.ID, contentTypeOf(part.Type))
+	if !ok {
 		return
 	}
-
-	// 如果请求中没有 user_id，则保留原有的 user_id
-	if part.UserID == "" {
-		if existingUserID, ok := existingData["user_id"].(string); ok {
-			part.UserID = existingUserID
-		}
+	part.UserID, _ = existingData["user_id"].(string)
+	if part.Type == "" {
+		part.Type = contentTypeOf(existingData["type"])
 	}
 
 	// 将数据更新到数据库
@@ -379,6 +380,10 @@ func DeleteListeningPart(c *gin.Context) {
 		return
 	}
 
+	if _, _, ok := authorizeExistingContent(c, "listening_part_list", id, ""); !ok {
+		return
+	}
+
 	// 执行删除操作
 	rowsAffected, err := database.DeleteData("listening_part_list", id)
 	if err != nil {
@@ -394,4 +399,39 @@ func DeleteListeningPart(c *gin.Context) {
 
 	// 返回成功响应
 	utils.HandleResponse(c, http.StatusOK, nil, "Success")
-}
\ No newline at end of file
+}
+
+// @Summary 重新编号听力套题题号
+// @Description 按 part 顺序重写题号，使题号从 1 连续编号到 40
+// @Tags Listening
+// @Accept json
+// @Produce json
+// @Param id path int true "听力套题ID"
+// @Success 200 {object} models.ResponseData{data=int}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /config/listening/renumber/{id} [put]
+func RenumberListening(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid listening set ID")
+		return
+	}
+
+	record, user, ok := authorizeExistingContent(c, "listening_list", id, "")
+	if !ok {
+		return
+	}
+	partIDs := utils.InterfaceToIntList(record["part_list"])
+	if !authorizeParts(c, user, "listening_part_list", partIDs) {
+		return
+	}
+
+	// 返回被修改的 part 数量
+	changed, err := utils.RenumberParts("listening_part_list", partIDs)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+	utils.HandleResponse(c, http.StatusOK, changed, "Success")
+}
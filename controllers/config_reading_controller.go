@@ -37,6 +37,13 @@ func ReadingList(c *gin.Context) {
 		return
 	}
 
+	// 列表中只展开当前用户可见的 part
+	userID, err := utils.GetUserIDFromToken(c)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+		return
+	}
+
 	// 执行分页查询
     results, total, err := database.PaginationQuery("reading_list", pageNo, pageLimit, conditions)
     if err != nil {
@@ -71,7 +78,7 @@ func ReadingList(c *gin.Context) {
 				utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to query reading parts")
 				return
 			}
-			if len(partDetail) > 0 {
+			if len(partDetail) > 0 && utils.PartVisibleTo(partDetail[0], userID) {
 				details = append(details, partDetail[0])
 			}
 		}
@@ -106,11 +113,10 @@ func ReadingDetail(c *gin.Context) {
 		return
 	}
 
-	record, err := database.GetDataById("reading_list", id)
-    if err != nil {
-        utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get data by id")
-        return
-    }
+	record, ok := visibleContent(c, "reading_list", id)
+	if !ok {
+		return
+	}
 
 	// 返回查询结果
 	response := map[string]interface{}{
@@ -137,18 +143,30 @@ func AddReading(c *gin.Context) {
 		return
 	}
 
+	// 官方题目需要编辑权限，用户题目记录创建者
+	user, ok := authorizeNewContent(c, contentTypeOf(part.Type.Int()))
+	if !ok {
+		return
+	}
+	part.UserID = ""
 	if part.Type.Int() == 3 {
-		userID, err := utils.GetUserIDFromToken(c)
-		if err != nil {
-			// 处理获取 user_id 失败的情况
-			utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
-			return
-		}
+		part.UserID = user.ID
+	}
 
-		// 将 user_id 添加到 part 中
-		part.UserID = userID
+	// 校验套题组成：3 篇文章，题号 1-40
+	problems, err := utils.ValidateReadingComposition(part.PartList, part.UserID)
+	if !checkComposition(c, problems, err) {
+		return
 	}
 
+	// 阅读考试类型：academic / general，默认 academic，其他取值返回 400
+	module, err := utils.NormalizeModule(part.Module)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid module")
+		return
+	}
+	part.Module = module
+
 	// 将数据插入数据库
 	result, err := database.InsertData("reading_list", &part, "create")
 	if err != nil {
@@ -178,22 +196,30 @@ func UpdateReading(c *gin.Context) {
 		return
 	}
 
-	// 如果 type=3（用户自定义），需要保留 user_id
-	if part.Type.Int() == 3 {
-		// 如果请求中没有提供 user_id，从数据库获取原有的 user_id
-		if part.UserID == "" {
-			existingData, err := database.GetDataById("reading_list", part.ID)
-			if err != nil {
-				utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get existing data")
-				return
-			}
+	// 检查原题目和修改后的 type 的权限，创建者不能通过请求修改
+	existingData, _, ok := authorizeExistingContent(c, "reading_list", part.ID, setTypeOf(part.Type))
+	if !ok {
+		return
+	}
+	part.UserID, _ = existingData["user_id"].(string)
+	if part.Type.Int() == 0 {
+		part.Type = existingSetType(existingData)
+	}
 
-			if existingUserID, ok := existingData["user_id"].(string); ok {
-				part.UserID = existingUserID
-			}
-		}
+	// 校验套题组成：3 篇文章，题号 1-40
+	problems, err := utils.ValidateReadingComposition(part.PartList, part.UserID)
+	if !checkComposition(c, problems, err) {
+		return
 	}
 
+	// 阅读考试类型：academic / general，默认 academic，其他取值返回 400
+	module, err := utils.NormalizeModule(part.Module)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid module")
+		return
+	}
+	part.Module = module
+
 	// 将数据更新到数据库
 	result, err := database.InsertData("reading_list", &part, "update")
 	if err != nil {
@@ -224,6 +250,10 @@ func DeleteReading(c *gin.Context) {
 		return
 	}
 
+	if _, _, ok := authorizeExistingContent(c, "reading_list", id, ""); !ok {
+		return
+	}
+
 	// 执行删除操作
 	rowsAffected, err := database.DeleteData("reading_list", id)
 	if err != nil {
@@ -298,11 +328,10 @@ func ReadingPartDetail(c *gin.Context) {
 		return
 	}
 
-	record, err := database.GetDataById("reading_part_list", id)
-    if err != nil {
-        utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get data by id")
-        return
-    }
+	record, ok := visibleContent(c, "reading_part_list", id)
+	if !ok {
+		return
+	}
 
 	// 返回查询结果
 	response := map[string]interface{}{
@@ -328,13 +357,12 @@ func AddReadingPart(c *gin.Context) {
 		return
 	}
 
-	// 获取当前用户ID并设置到part中
-	userID, err := utils.GetUserIDFromToken(c)
-	if err != nil {
-		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+	// 官方题目需要编辑权限；记录创建者
+	user, ok := authorizeNewContent(c, contentTypeOf(part.Type))
+	if !ok {
 		return
 	}
-	part.UserID = userID
+	part.UserID = user.ID
 
 	// 将数据插入数据库
 	result, err := database.InsertData("reading_part_list", &part, "create")
@@ -364,18 +392,14 @@ func UpdateReadingPart(c *gin.Context) {
 		return
 	}
 
-	// 获取原有数据以保留 user_id
-	existingData, err := database.GetDataById("reading_part_list", part.ID)
-	if err != nil {
-		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get existing data")
+	// 检查原题目和修改后的 type 的权限，保留原有的 user_id
+	existingData, _, ok := authorizeExistingContent(c, "reading_part_list", part.ID, contentTypeOf(part.Type))
+	if !ok {
 		return
 	}
-
-	// 如果请求中没有 user_id，则保留原有的 user_id
-	if part.UserID == "" {
-		if existingUserID, ok := existingData["user_id"].(string); ok {
-			part.UserID = existingUserID
-		}
+	part.UserID, _ = existingData["user_id"].(string)
+	if part.Type == "" {
+		part.Type = contentTypeOf(existingData["type"])
 	}
 
 	// 将数据更新到数据库
@@ -409,6 +433,10 @@ func DeleteReadingPart(c *gin.Context) {
 		return
 	}
 
+	if _, _, ok := authorizeExistingContent(c, "reading_part_list", id, ""); !ok {
+		return
+	}
+
 	// 执行删除操作
 	rowsAffected, err := database.DeleteData("reading_part_list", id)
 	if err != nil {
@@ -424,4 +452,39 @@ func DeleteReadingPart(c *gin.Context) {
 
 	// 返回成功响应
 	utils.HandleResponse(c, http.StatusOK, nil, "Success")
-}
\ No newline at end of file
+}
+
+// @Summary 重新编号阅读套题题号
+// @Description 按文章顺序重写题号，使题号从 1 连续编号到 40
+// @Tags Reading
+// @Accept json
+// @Produce json
+// @Param id path int true "阅读套题ID"
+// @Success 200 {object} models.ResponseData{data=int}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /config/reading/renumber/{id} [put]
+func RenumberReading(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid reading set ID")
+		return
+	}
+
+	record, user, ok := authorizeExistingContent(c, "reading_list", id, "")
+	if !ok {
+		return
+	}
+	partIDs := utils.InterfaceToIntList(record["part_list"])
+	if !authorizeParts(c, user, "reading_part_list", partIDs) {
+		return
+	}
+
+	// 返回被修改的 part 数量
+	changed, err := utils.RenumberParts("reading_part_list", partIDs)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+	utils.HandleResponse(c, http.StatusOK, changed, "Success")
+}
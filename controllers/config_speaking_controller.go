@@ -0,0 +1,280 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Queen2333/ielts_test_backend/database"
+	"github.com/Queen2333/ielts_test_backend/models"
+	"github.com/Queen2333/ielts_test_backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary 获取口语套题列表
+// @Description 根据条件获取口语列表，并返回分页结果
+// @Tags Speaking
+// @Accept json
+// @Produce json
+// @Param name query string false "口语名称"
+// @Param status query int false "口语状态"
+// @Param type query int false "试题类型"
+// @Param pageNo query int true "页码"
+// @Param pageLimit query int false "每页条数"
+// @Success 200 {object} models.ResponseData{data=models.SpeakingListResponse}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /config/speaking/list [get]
+func SpeakingList(c *gin.Context) {
+	listSets(c, "speaking_list", "speaking_part_list", "speaking")
+}
+
+// @Summary 获取口语套题详情
+// @Description 根据id获取口语详情
+// @Tags Speaking
+// @Accept json
+// @Produce json
+// @Param id query int true "口语id"
+// @Success 200 {object} models.ResponseData{data=models.SpeakingItem}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /config/speaking/detail/{id} [get]
+func SpeakingDetail(c *gin.Context) {
+	contentDetail(c, "speaking_list", "speaking set")
+}
+
+// @Summary 新增口语套题
+// @Description 新增口语套题
+// @Tags Speaking
+// @Accept json
+// @Produce json
+// @Param part body models.BasicSpeakingItem true "口语套题内容"
+// @Success 200 {object} models.ResponseData{data=nil}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /config/speaking/add [post]
+func AddSpeaking(c *gin.Context) {
+	var part models.BasicSpeakingItem
+	if err := c.ShouldBindJSON(&part); err != nil {
+		fmt.Println(err)
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid request")
+		return
+	}
+
+	// 官方题目需要编辑权限，用户题目记录创建者
+	user, ok := authorizeNewContent(c, contentTypeOf(part.Type.Int()))
+	if !ok {
+		return
+	}
+	part.UserID = ""
+	if part.Type.Int() == 3 {
+		part.UserID = user.ID
+	}
+
+	// 校验口语套题组成：Part 1、Part 2、Part 3 各一个
+	problems, err := utils.ValidateSpeakingComposition(part.PartList, part.UserID)
+	if !checkComposition(c, problems, err) {
+		return
+	}
+
+	// 将数据插入数据库
+	result, err := database.InsertData("speaking_list", &part, "create")
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to insert speaking part")
+		return
+	}
+
+	// 返回插入后的数据
+	utils.HandleResponse(c, http.StatusOK, result, "Success")
+}
+
+// @Summary 更新口语套题
+// @Description 更新口语套题
+// @Tags Speaking
+// @Accept json
+// @Produce json
+// @Param part body models.BasicSpeakingItem true "口语套题内容"
+// @Success 200 {object} models.ResponseData{data=nil}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /config/speaking/update [put]
+func UpdateSpeaking(c *gin.Context) {
+	var part models.BasicSpeakingItem
+	if err := c.ShouldBindJSON(&part); err != nil {
+		fmt.Println(err)
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid request")
+		return
+	}
+
+	// 检查原题目和修改后的 type 的权限，创建者不能通过请求修改
+	existingData, _, ok := authorizeExistingContent(c, "speaking_list", part.ID, setTypeOf(part.Type))
+	if !ok {
+		return
+	}
+	part.UserID, _ = existingData["user_id"].(string)
+	if part.Type.Int() == 0 {
+		part.Type = existingSetType(existingData)
+	}
+
+	// 校验口语套题组成：Part 1、Part 2、Part 3 各一个
+	problems, err := utils.ValidateSpeakingComposition(part.PartList, part.UserID)
+	if !checkComposition(c, problems, err) {
+		return
+	}
+
+	// 将数据更新到数据库
+	result, err := database.InsertData("speaking_list", &part, "update")
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to update speaking part")
+		return
+	}
+
+	// 返回更新后的数据
+	utils.HandleResponse(c, http.StatusOK, result, "Success")
+}
+
+// @Summary 删除口语套题
+// @Description 根据ID删除口语套题
+// @Tags Speaking
+// @Accept json
+// @Produce json
+// @Param id path int true "口语套题ID"
+// @Success 200 {object} models.ResponseData{data=nil}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 404 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /config/speaking/delete/{id} [delete]
+func DeleteSpeaking(c *gin.Context) {
+	deleteContent(c, "speaking_list", "speaking set")
+}
+
+// @Summary      获取口语part列表
+// @Description  根据条件获取口语part列表，并返回分页结果
+// @Tags         Speaking
+// @Accept       json
+// @Produce      json
+// @Param        name      query  string  false  "试题名称"
+// @Param        status    query  int     false  "试题状态"
+// @Param        type      query  int     false  "试题类型"
+// @Param        pageNo    query  int     true   "页码"
+// @Param        pageLimit query  int     false   "每页条数"
+// @Success      200  {object}  models.ResponseData{data=models.SpeakingPartListResponse}
+// @Failure      400  {object}  models.ResponseData{data=nil}
+// @Failure      500  {object}  models.ResponseData{data=nil}
+// @Router       /config/speaking-part/list [get]
+func SpeakingPartList(c *gin.Context) {
+	listContent(c, "speaking_part_list")
+}
+
+// @Summary 获取口语part详情
+// @Description 根据id获取口语part详情
+// @Tags Speaking
+// @Accept json
+// @Produce json
+// @Param id query int true "口语part id"
+// @Success 200 {object} models.ResponseData{data=models.SpeakingPartItem}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /config/speaking-part/detail/{id} [get]
+func SpeakingPartDetail(c *gin.Context) {
+	contentDetail(c, "speaking_part_list", "speaking part")
+}
+
+// @Summary 新增口语part
+// @Description 新增口语part
+// @Tags Speaking
+// @Accept json
+// @Produce json
+// @Param part body models.SpeakingPartItem true "口语part内容"
+// @Success 200 {object} models.ResponseData{data=nil}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /config/speaking-part/add [post]
+func AddSpeakingPart(c *gin.Context) {
+	var part models.SpeakingPartItem
+	if err := c.ShouldBindJSON(&part); err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid request")
+		return
+	}
+
+	// 官方题目需要编辑权限；记录创建者
+	user, ok := authorizeNewContent(c, contentTypeOf(part.Type))
+	if !ok {
+		return
+	}
+	part.UserID = user.ID
+
+	// 校验 part 内容，话题卡补充默认准备、陈述时间
+	if problems := utils.ValidateSpeakingPart(&part); len(problems) > 0 {
+		utils.HandleResponse(c, http.StatusBadRequest, problems, "Invalid speaking part")
+		return
+	}
+
+	// 将数据插入数据库
+	result, err := database.InsertData("speaking_part_list", &part, "create")
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to insert speaking part")
+		return
+	}
+
+	// 返回插入后的数据
+	utils.HandleResponse(c, http.StatusOK, result, "Success")
+}
+
+// @Summary 更新口语part
+// @Description 更新口语part
+// @Tags Speaking
+// @Accept json
+// @Produce json
+// @Param part body models.SpeakingPartItem true "口语part内容"
+// @Success 200 {object} models.ResponseData{data=nil}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /config/speaking-part/update [put]
+func UpdateSpeakingPart(c *gin.Context) {
+	var part models.SpeakingPartItem
+	if err := c.ShouldBindJSON(&part); err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid request")
+		return
+	}
+
+	// 校验 part 内容，话题卡补充默认准备、陈述时间
+	if problems := utils.ValidateSpeakingPart(&part); len(problems) > 0 {
+		utils.HandleResponse(c, http.StatusBadRequest, problems, "Invalid speaking part")
+		return
+	}
+
+	// 检查原题目和修改后的 type 的权限，保留原有的 user_id
+	existingData, _, ok := authorizeExistingContent(c, "speaking_part_list", part.ID, contentTypeOf(part.Type))
+	if !ok {
+		return
+	}
+	part.UserID, _ = existingData["user_id"].(string)
+	if part.Type == "" {
+		part.Type = contentTypeOf(existingData["type"])
+	}
+
+	// 将数据更新到数据库
+	result, err := database.InsertData("speaking_part_list", &part, "update")
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to update speaking part")
+		return
+	}
+
+	// 返回更新后的数据
+	utils.HandleResponse(c, http.StatusOK, result, "Success")
+}
+
+// @Summary 删除口语part
+// @Description 根据ID删除口语part
+// @Tags Speaking
+// @Accept json
+// @Produce json
+// @Param id path int true "口语partID"
+// @Success 200 {object} models.ResponseData{data=nil}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 404 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /config/speaking-part/delete/{id} [delete]
+func DeleteSpeakingPart(c *gin.Context) {
+	deleteContent(c, "speaking_part_list", "speaking part")
+}
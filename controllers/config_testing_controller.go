@@ -37,6 +37,13 @@ func TestingList(c *gin.Context) {
 		return
 	}
 
+	// 列表中只展开当前用户可见的 part
+	userID, err := utils.GetUserIDFromToken(c)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+		return
+	}
+
 	// 执行分页查询
     results, total, err := database.PaginationQuery("testing_list", pageNo, pageLimit, conditions)
     if err != nil {
@@ -49,18 +56,21 @@ func TestingList(c *gin.Context) {
 		"reading_ids":   "reading_part_list",
 		"listening_ids": "listening_part_list",
 		"writing_ids":   "writing_part_list",
+		"speaking_ids":  "speaking_part_list",
 	}
 
 	fieldToNameMap := map[string]string{
 		"reading_ids":   "reading_parts",
 		"listening_ids": "listening_parts",
 		"writing_ids":   "writing_parts",
+		"speaking_ids":  "speaking_parts",
 	}
 	// 遍历 results 提取所有的 part_list ID
 	for i, result := range results {
 		for field, table := range fieldToTableMap {
+			// 旧套题没有 speaking_ids，视为空
 			partListInterface, ok := result[field].([]interface{})
-			if !ok {
+			if !ok && result[field] != nil {
 				utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to parse part_list")
 				return
 			}
@@ -82,7 +92,7 @@ func TestingList(c *gin.Context) {
 					utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to query testing parts")
 					return
 				}
-				if len(partDetail) > 0 {
+				if len(partDetail) > 0 && utils.PartVisibleTo(partDetail[0], userID) {
 					details = append(details, partDetail[0])
 				}
 			}
@@ -118,28 +128,37 @@ func TestingDetail(c *gin.Context) {
 		return
 	}
 
-	record, err := database.GetDataById("testing_list", id)
-    if err != nil {
-        utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get data by id")
-        return
-    }
+	user, err := utils.GetUserFromToken(c)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+		return
+	}
+	record, ok := loadVisibleContent(c, user, "testing_list", id)
+	if !ok {
+		return
+	}
+	// 套题引用的 part 同样按所有者过滤，其他用户的 part 不返回
+	userID := user.ID
 
 	// 定义字段与对应表的映射关系
 	fieldToTableMap := map[string]string{
 		"reading_ids":   "reading_part_list",
 		"listening_ids": "listening_part_list",
 		"writing_ids":   "writing_part_list",
+		"speaking_ids":  "speaking_part_list",
 	}
 
 	fieldToNameMap := map[string]string{
 		"reading_ids":   "reading_parts",
 		"listening_ids": "listening_parts",
 		"writing_ids":   "writing_parts",
+		"speaking_ids":  "speaking_parts",
 	}
 
 	for field, table := range fieldToTableMap {
+		// 旧套题没有 speaking_ids，视为空
 		partListInterface, ok := record[field].([]interface{})
-		if !ok {
+		if !ok && record[field] != nil {
 			utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to parse part_list")
 			return
 		}
@@ -161,7 +180,7 @@ func TestingDetail(c *gin.Context) {
 				utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to query testing parts")
 				return
 			}
-			if len(partDetail) > 0 {
+			if len(partDetail) > 0 && utils.PartVisibleTo(partDetail[0], userID) {
 				details = append(details, partDetail[0])
 			}
 		}
@@ -193,17 +212,29 @@ func AddTesting(c *gin.Context) {
 		return
 	}
 
+	// 官方题目需要编辑权限，用户题目记录创建者
+	user, ok := authorizeNewContent(c, contentTypeOf(part.Type.Int()))
+	if !ok {
+		return
+	}
+	part.UserID = ""
 	if part.Type.Int() == 3 {
-		userID, err := utils.GetUserIDFromToken(c)
-		if err != nil {
-			// 处理获取 user_id 失败的情况
-			utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
-			return
-		}
+		part.UserID = user.ID
+	}
 
-		// 将 user_id 添加到 part 中
-		part.UserID = userID
+	// 校验套题组成：听力 4 个 part、阅读 3 篇、写作 2 篇，题号 1-40；口语可选，Part 1-3 各一个
+	problems, err := utils.ValidateTestingComposition(part.ListeningIDs, part.ReadingIDs, part.WritingIDs, part.SpeakingIDs, part.UserID)
+	if !checkComposition(c, problems, err) {
+		return
+	}
+
+	// 阅读考试类型：academic / general，默认 academic，其他取值返回 400
+	module, err := utils.NormalizeModule(part.Module)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid module")
+		return
 	}
+	part.Module = module
 
 	// 将数据插入数据库
 	result, err := database.InsertData("testing_list", &part, "create")
@@ -234,17 +265,29 @@ func UpdateTesting(c *gin.Context) {
 		return
 	}
 
-	if part.Type.Int() == 3 {
-		userID, err := utils.GetUserIDFromToken(c)
-		if err != nil {
-			// 处理获取 user_id 失败的情况
-			utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
-			return
-		}
+	// 检查原题目和修改后的 type 的权限，创建者不能通过请求修改
+	existingData, _, ok := authorizeExistingContent(c, "testing_list", part.ID, setTypeOf(part.Type))
+	if !ok {
+		return
+	}
+	part.UserID, _ = existingData["user_id"].(string)
+	if part.Type.Int() == 0 {
+		part.Type = existingSetType(existingData)
+	}
+
+	// 校验套题组成：听力 4 个 part、阅读 3 篇、写作 2 篇，题号 1-40；口语可选，Part 1-3 各一个
+	problems, err := utils.ValidateTestingComposition(part.ListeningIDs, part.ReadingIDs, part.WritingIDs, part.SpeakingIDs, part.UserID)
+	if !checkComposition(c, problems, err) {
+		return
+	}
 
-		// 将 user_id 添加到 part 中
-		part.UserID = userID
+	// 阅读考试类型：academic / general，默认 academic，其他取值返回 400
+	module, err := utils.NormalizeModule(part.Module)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid module")
+		return
 	}
+	part.Module = module
 
 	// 将数据插入数据库
 	result, err := database.InsertData("testing_list", &part, "update")
@@ -276,6 +319,10 @@ func DeleteTesting(c *gin.Context) {
 		return
 	}
 
+	if _, _, ok := authorizeExistingContent(c, "testing_list", id, ""); !ok {
+		return
+	}
+
 	// 执行删除操作
 	rowsAffected, err := database.DeleteData("testing_list", id)
 	if err != nil {
@@ -291,4 +338,62 @@ func DeleteTesting(c *gin.Context) {
 
 	// 返回成功响应
 	utils.HandleResponse(c, http.StatusOK, nil, "Success")
-}
\ No newline at end of file
+}
+
+// @Summary 重新编号测试套题题号
+// @Description 按听力、阅读 part 的顺序重写题号，使每个科目的题号从 1 连续编号到 40
+// @Tags Testing
+// @Accept json
+// @Produce json
+// @Param id path int true "测试套题ID"
+// @Success 200 {object} models.ResponseData{data=map[string]int}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /config/testing/renumber/{id} [put]
+func RenumberTesting(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid testing set ID")
+		return
+	}
+
+	record, user, ok := authorizeExistingContent(c, "testing_list", id, "")
+	if !ok {
+		return
+	}
+	listeningIDs := utils.InterfaceToIntList(record["listening_ids"])
+	readingIDs := utils.InterfaceToIntList(record["reading_ids"])
+	// 听力和阅读的 part 都检查通过后才开始写入
+	if !authorizeParts(c, user, "listening_part_list", listeningIDs) || !authorizeParts(c, user, "reading_part_list", readingIDs) {
+		return
+	}
+
+	listeningChanged, err := utils.RenumberParts("listening_part_list", listeningIDs)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+	readingChanged, err := utils.RenumberParts("reading_part_list", readingIDs)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+
+	utils.HandleResponse(c, http.StatusOK, map[string]int{
+		"listening_parts_changed": listeningChanged,
+		"reading_parts_changed":   readingChanged,
+	}, "Success")
+}
+
+// checkComposition 统一处理套题组成校验结果，校验不通过时返回 400 和问题列表
+func checkComposition(c *gin.Context, problems []string, err error) bool {
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to validate composition")
+		return false
+	}
+	if len(problems) > 0 {
+		utils.HandleResponse(c, http.StatusBadRequest, problems, "Invalid set composition")
+		return false
+	}
+	return true
+}
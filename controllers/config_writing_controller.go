@@ -3,8 +3,6 @@ package controllers
 import (
 	"fmt"
 	"net/http"
-	"strconv"
-	"strings"
 
 	"github.com/Queen2333/ielts_test_backend/database"
 	"github.com/Queen2333/ielts_test_backend/models"
@@ -27,65 +25,7 @@ import (
 // @Failure 500 {object} models.ResponseData{data=nil}
 // @Router /config/writing/list [get]
 func WritingList(c *gin.Context) {
-
-	pageNo, _ := strconv.Atoi(c.DefaultQuery("pageNo", "1"))
-	pageLimit, _ := strconv.Atoi(c.DefaultQuery("pageLimit", "-1"))
-
-	conditions, err := utils.ProcessRequest(c)
-	if err != nil {
-		// 错误处理已在 ProcessRequest 中处理
-		return
-	}
-
-	// 执行分页查询
-    results, total, err := database.PaginationQuery("writing_list", pageNo, pageLimit, conditions)
-    if err != nil {
-        utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to execute pagination query")
-        return
-    }
-
-	// 遍历 results 提取所有的 part_list ID
-	for i, result := range results {
-
-		// 处理 []interface{} 类型的 part_list
-		partListInterface, ok := result["part_list"].([]interface{})
-		if !ok {
-			utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to parse part_list")
-			return
-		}
-
-		// 转换为字符串数组
-		var partListStrArray []string
-		for _, part := range partListInterface {
-			partListStrArray = append(partListStrArray, fmt.Sprint(part))
-		}
-
-		var partListStr = strings.Join(partListStrArray, ",")
-
-		partList := utils.StringToList(partListStr)
-		// 查询 part_list 中的详细信息
-		var details []map[string]interface{}
-		for _, id := range partList {
-			partDetail, err := database.GetPartsByIds("writing_part_list", []int{id})
-			if err != nil {
-				utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to query writing parts")
-				return
-			}
-			if len(partDetail) > 0 {
-				details = append(details, partDetail[0])
-			}
-		}
-		// 将查询结果放回到对应的 part_list 中
-		results[i]["part_list"] = details
-		// }
-	}
-
-	// 返回查询结果
-	response := map[string]interface{}{
-		"items": results,
-		"total":   total,
-	}
-	utils.HandleResponse(c, http.StatusOK, response, "Success")
+	listSets(c, "writing_list", "writing_part_list", "writing")
 }
 
 // @Summary 获取写作套题详情
@@ -99,24 +39,7 @@ func WritingList(c *gin.Context) {
 // @Failure 500 {object} models.ResponseData{data=nil}
 // @Router /config/writing/detail/{id} [get]
 func WritingDetail(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid writing set ID")
-		return
-	}
-
-	record, err := database.GetDataById("writing_list", id)
-    if err != nil {
-        utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get data by id")
-        return
-    }
-
-	// 返回查询结果
-	response := map[string]interface{}{
-		"data": record,
-	}
-	utils.HandleResponse(c, http.StatusOK, response, "Success")
+	contentDetail(c, "writing_list", "writing set")
 }
 
 // @Summary 新增写作套题
@@ -137,16 +60,20 @@ func AddWriting(c *gin.Context) {
 		return
 	}
 
+	// 官方题目需要编辑权限，用户题目记录创建者
+	user, ok := authorizeNewContent(c, contentTypeOf(part.Type.Int()))
+	if !ok {
+		return
+	}
+	part.UserID = ""
 	if part.Type.Int() == 3 {
-		userID, err := utils.GetUserIDFromToken(c)
-		if err != nil {
-			// 处理获取 user_id 失败的情况
-			utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
-			return
-		}
+		part.UserID = user.ID
+	}
 
-		// 将 user_id 添加到 part 中
-		part.UserID = userID
+	// 套题只能引用官方 part 或创建者自己的 part
+	problems, err := utils.ValidatePartReferences("writing", "writing_part_list", part.PartList, part.UserID)
+	if !checkComposition(c, problems, err) {
+		return
 	}
 
 	// 将数据插入数据库
@@ -178,20 +105,20 @@ func UpdateWriting(c *gin.Context) {
 		return
 	}
 
-	// 如果 type=3（用户自定义），需要保留 user_id
-	if part.Type.Int() == 3 {
-		// 如果请求中没有提供 user_id，从数据库获取原有的 user_id
-		if part.UserID == "" {
-			existingData, err := database.GetDataById("writing_list", part.ID)
-			if err != nil {
-				utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get existing data")
-				return
-			}
+	// 检查原题目和修改后的 type 的权限，创建者不能通过请求修改
+	existingData, _, ok := authorizeExistingContent(c, "writing_list", part.ID, setTypeOf(part.Type))
+	if !ok {
+		return
+	}
+	part.UserID, _ = existingData["user_id"].(string)
+	if part.Type.Int() == 0 {
+		part.Type = existingSetType(existingData)
+	}
 
-			if existingUserID, ok := existingData["user_id"].(string); ok {
-				part.UserID = existingUserID
-			}
-		}
+	// 套题只能引用官方 part 或创建者自己的 part
+	problems, err := utils.ValidatePartReferences("writing", "writing_part_list", part.PartList, part.UserID)
+	if !checkComposition(c, problems, err) {
+		return
 	}
 
 	// 将数据更新到数据库
@@ -217,28 +144,7 @@ func UpdateWriting(c *gin.Context) {
 // @Failure 500 {object} models.ResponseData{data=nil}
 // @Router /config/writing/delete/{id} [delete]
 func DeleteWriting(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid writing set ID")
-		return
-	}
-
-	// 执行删除操作
-	rowsAffected, err := database.DeleteData("writing_list", id)
-	if err != nil {
-		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to delete writing set")
-		return
-	}
-
-	// 检查是否有记录被删除
-	if rowsAffected == 0 {
-		utils.HandleResponse(c, http.StatusNotFound, "", "writing set not found")
-		return
-	}
-
-	// 返回成功响应
-	utils.HandleResponse(c, http.StatusOK, nil, "Success")
+	deleteContent(c, "writing_list", "writing set")
 }
 
 // @Summary      获取写作篇列表
@@ -255,29 +161,8 @@ func DeleteWriting(c *gin.Context) {
 // @Failure      400  {object}  models.ResponseData{data=nil}
 // @Failure      500  {object}  models.ResponseData{data=nil}
 // @Router       /config/writing-part/list [get]
-func WritingPartList(c * gin.Context) {
-	pageNo, _ := strconv.Atoi(c.DefaultQuery("pageNo", "1"))
-	pageLimit, _ := strconv.Atoi(c.DefaultQuery("pageLimit", "-1"))
-
-	conditions, err := utils.ProcessRequest(c)
-	if err != nil {
-		// 错误处理已在 ProcessRequest 中处理
-		return
-	}
-
-	// 执行分页查询
-    results, total, err := database.PaginationQuery("writing_part_list", pageNo, pageLimit, conditions)
-    if err != nil {
-        utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to execute pagination query")
-        return
-    }
-
-	// 返回查询结果
-	response := map[string]interface{}{
-		"items": results,
-		"total":   total,
-	}
-	utils.HandleResponse(c, http.StatusOK, response, "Success")
+func WritingPartList(c *gin.Context) {
+	listContent(c, "writing_part_list")
 }
 
 // @Summary 获取写作part详情
@@ -291,24 +176,7 @@ func WritingPartList(c * gin.Context) {
 // @Failure 500 {object} models.ResponseData{data=nil}
 // @Router /config/writing-part/detail/{id} [get]
 func WritingPartDetail(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid writing part set ID")
-		return
-	}
-
-	record, err := database.GetDataById("writing_part_list", id)
-    if err != nil {
-        utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get data by id")
-        return
-    }
-
-	// 返回查询结果
-	response := map[string]interface{}{
-		"data": record,
-	}
-	utils.HandleResponse(c, http.StatusOK, response, "Success")
+	contentDetail(c, "writing_part_list", "writing part")
 }
 
 // @Summary 新增写作part
@@ -328,13 +196,12 @@ func AddWritingPart(c *gin.Context) {
 		return
 	}
 
-	// 获取当前用户ID并设置到part中
-	userID, err := utils.GetUserIDFromToken(c)
-	if err != nil {
-		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+	// 官方题目需要编辑权限；记录创建者
+	user, ok := authorizeNewContent(c, contentTypeOf(part.Type))
+	if !ok {
 		return
 	}
-	part.UserID = userID
+	part.UserID = user.ID
 
 	// 将数据插入数据库
 	result, err := database.InsertData("writing_part_list", &part, "create")
@@ -364,18 +231,14 @@ func UpdateWritingPart(c *gin.Context) {
 		return
 	}
 
-	// 获取原有数据以保留 user_id
-	existingData, err := database.GetDataById("writing_part_list", part.ID)
-	if err != nil {
-		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get existing data")
+	// 检查原题目和修改后的 type 的权限，保留原有的 user_id
+	existingData, _, ok := authorizeExistingContent(c, "writing_part_list", part.ID, contentTypeOf(part.Type))
+	if !ok {
 		return
 	}
-
-	// 如果请求中没有 user_id，则保留原有的 user_id
-	if part.UserID == "" {
-		if existingUserID, ok := existingData["user_id"].(string); ok {
-			part.UserID = existingUserID
-		}
+	part.UserID, _ = existingData["user_id"].(string)
+	if part.Type == "" {
+		part.Type = contentTypeOf(existingData["type"])
 	}
 
 	// 将数据更新到数据库
@@ -402,26 +265,5 @@ func UpdateWritingPart(c *gin.Context) {
 // @Failure 500 {object} models.ResponseData{data=nil}
 // @Router /config/writing-part/delete/{id} [delete]
 func DeleteWritingPart(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid writing set ID")
-		return
-	}
-
-	// 执行删除操作
-	rowsAffected, err := database.DeleteData("writing_part_list", id)
-	if err != nil {
-		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to delete writing set")
-		return
-	}
-
-	// 检查是否有记录被删除
-	if rowsAffected == 0 {
-		utils.HandleResponse(c, http.StatusNotFound, "", "writing set not found")
-		return
-	}
-
-	// 返回成功响应
-	utils.HandleResponse(c, http.StatusOK, nil, "Success")
-}
\ No newline at end of file
+	deleteContent(c, "writing_part_list", "writing part")
+}
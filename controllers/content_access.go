@@ -0,0 +1,159 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Queen2333/ielts_test_backend/auth"
+	"github.com/Queen2333/ielts_test_backend/database"
+	"github.com/Queen2333/ielts_test_backend/models"
+	"github.com/Queen2333/ielts_test_backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// contentTypeUser 题目数据来源：3=用户自己创建的题目，其余（1=系统，2=官方）为官方题目
+const contentTypeUser = "3"
+
+// contentTypeOf 统一题目 type 的格式（请求中可能是数字或字符串，数据库中可能是数字）
+func contentTypeOf(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	return strings.TrimSpace(fmt.Sprint(value))
+}
+
+// setTypeOf 套题的 type（FlexInt），请求中未传（0）时返回空，表示沿用原有的 type
+func setTypeOf(value models.FlexInt) string {
+	if value.Int() == 0 {
+		return ""
+	}
+	return contentTypeOf(value.Int())
+}
+
+// existingSetType 读取已有套题的 type，用于更新时未传 type 的情况
+func existingSetType(existing map[string]interface{}) models.FlexInt {
+	value, _ := strconv.Atoi(contentTypeOf(existing["type"]))
+	return models.FlexInt(value)
+}
+
+// canWriteContent 系统和官方题目需要 content:manage；用户题目只能由创建者修改
+func canWriteContent(user models.UserQuery, contentType, ownerID string) bool {
+	if contentType != contentTypeUser {
+		return auth.Can(user.RoleID, auth.PermContentManage)
+	}
+	return auth.Can(user.RoleID, auth.PermContentOwn) && ownerID == user.ID
+}
+
+// authorizeNewContent 检查当前用户能否新增该类型的题目，没有权限时直接返回 403
+func authorizeNewContent(c *gin.Context, contentType string) (models.UserQuery, bool) {
+	user, err := utils.GetUserFromToken(c)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+		return models.UserQuery{}, false
+	}
+	if !canWriteContent(user, contentType, user.ID) {
+		utils.HandleResponse(c, http.StatusForbidden, "", "Permission denied")
+		return models.UserQuery{}, false
+	}
+	return user, true
+}
+
+// authorizeExistingContent 读取已有题目并检查当前用户能否修改或删除，返回原数据
+// 其他用户的题目（type 3）按不存在处理，返回 404，避免暴露 ID；
+// newType 不为空时（更新）同时检查修改后的 type，防止把自己的题目改成官方题目
+func authorizeExistingContent(c *gin.Context, table string, id int, newType string) (map[string]interface{}, models.UserQuery, bool) {
+	user, err := utils.GetUserFromToken(c)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+		return nil, models.UserQuery{}, false
+	}
+
+	existing, ok := loadVisibleContent(c, user, table, id)
+	if !ok {
+		return nil, models.UserQuery{}, false
+	}
+	ownerID, _ := existing["user_id"].(string)
+	if !canWriteContent(user, contentTypeOf(existing["type"]), ownerID) {
+		utils.HandleResponse(c, http.StatusForbidden, "", "Permission denied")
+		return nil, models.UserQuery{}, false
+	}
+	if newType != "" && !canWriteContent(user, newType, ownerID) {
+		utils.HandleResponse(c, http.StatusForbidden, "", "Permission denied")
+		return nil, models.UserQuery{}, false
+	}
+	return existing, user, true
+}
+
+// loadVisibleContent 读取当前用户可见的题目：官方题目和自己的题目，其他用户的题目返回 404
+func loadVisibleContent(c *gin.Context, user models.UserQuery, table string, id int) (map[string]interface{}, bool) {
+	record, err := database.GetDataById(table, id)
+	if err != nil {
+		if database.IsNoRowsError(err) {
+			utils.HandleResponse(c, http.StatusNotFound, "", "Content not found")
+		} else {
+			utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get data by id")
+		}
+		return nil, false
+	}
+	ownerID, _ := record["user_id"].(string)
+	if contentTypeOf(record["type"]) == contentTypeUser && ownerID != user.ID {
+		utils.HandleResponse(c, http.StatusNotFound, "", "Content not found")
+		return nil, false
+	}
+	return record, true
+}
+
+// visibleContent 读取当前用户可见的题目详情，用于详情和交卷接口
+func visibleContent(c *gin.Context, table string, id int) (map[string]interface{}, bool) {
+	user, err := utils.GetUserFromToken(c)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+		return nil, false
+	}
+	return loadVisibleContent(c, user, table, id)
+}
+
+// loadOwnedRecord 读取当前用户自己的做题记录；记录不存在或属于其他用户时都返回 404，避免暴露 ID
+// 管理员查看、删除其他用户的记录使用 /admin 下的接口
+func loadOwnedRecord(c *gin.Context, table string, id int) (map[string]interface{}, models.UserQuery, bool) {
+	user, err := utils.GetUserFromToken(c)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+		return nil, models.UserQuery{}, false
+	}
+
+	record, err := database.GetDataById(table, id)
+	if err != nil {
+		if database.IsNoRowsError(err) {
+			utils.HandleResponse(c, http.StatusNotFound, "", "Record not found")
+		} else {
+			utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get data by id")
+		}
+		return nil, models.UserQuery{}, false
+	}
+	if ownerID, _ := record["user_id"].(string); ownerID != user.ID {
+		utils.HandleResponse(c, http.StatusNotFound, "", "Record not found")
+		return nil, models.UserQuery{}, false
+	}
+	return record, user, true
+}
+
+// authorizeParts 检查当前用户能否修改套题引用的所有 part，用于会改写 part 内容的接口（如重新编号）
+// 用户自己的套题可以引用官方 part，但不能借此修改官方 part；任一 part 不能修改时直接返回 403，不写入任何数据
+func authorizeParts(c *gin.Context, user models.UserQuery, table string, ids []int) bool {
+	parts, _, err := utils.LoadParts(table, ids)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", err.Error())
+		return false
+	}
+	for _, part := range parts {
+		ownerID, _ := part["user_id"].(string)
+		if !canWriteContent(user, contentTypeOf(part["type"]), ownerID) {
+			utils.HandleResponse(c, http.StatusForbidden, "", "Permission denied")
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Queen2333/ielts_test_backend/database"
+	"github.com/Queen2333/ielts_test_backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// 写作、口语等题库接口共用的查询和删除逻辑；新增、更新因模型和校验规则不同，仍由各科目的处理函数实现
+
+// listSets 分页查询套题，并把 part_list 展开为当前用户可见的 part 详情
+func listSets(c *gin.Context, table, partTable, skill string) {
+	pageNo, _ := strconv.Atoi(c.DefaultQuery("pageNo", "1"))
+	pageLimit, _ := strconv.Atoi(c.DefaultQuery("pageLimit", "-1"))
+
+	conditions, err := utils.ProcessRequest(c)
+	if err != nil {
+		// 错误处理已在 ProcessRequest 中处理
+		return
+	}
+
+	// 列表中只展开当前用户可见的 part
+	userID, err := utils.GetUserIDFromToken(c)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+		return
+	}
+
+	// 执行分页查询
+	results, total, err := database.PaginationQuery(table, pageNo, pageLimit, conditions)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to execute pagination query")
+		return
+	}
+
+	for i, result := range results {
+		partListInterface, ok := result["part_list"].([]interface{})
+		if !ok {
+			utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to parse part_list")
+			return
+		}
+		details, err := utils.GetPartDetails(partListInterface, partTable, userID)
+		if err != nil {
+			utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to query "+skill+" parts")
+			return
+		}
+		// 将查询结果放回到对应的 part_list 中
+		results[i]["part_list"] = details
+	}
+
+	// 返回查询结果
+	response := map[string]interface{}{
+		"items": results,
+		"total": total,
+	}
+	utils.HandleResponse(c, http.StatusOK, response, "Success")
+}
+
+// listContent 分页查询题目（part），不展开
+func listContent(c *gin.Context, table string) {
+	pageNo, _ := strconv.Atoi(c.DefaultQuery("pageNo", "1"))
+	pageLimit, _ := strconv.Atoi(c.DefaultQuery("pageLimit", "-1"))
+
+	conditions, err := utils.ProcessRequest(c)
+	if err != nil {
+		// 错误处理已在 ProcessRequest 中处理
+		return
+	}
+
+	// 执行分页查询
+	results, total, err := database.PaginationQuery(table, pageNo, pageLimit, conditions)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to execute pagination query")
+		return
+	}
+
+	// 返回查询结果
+	response := map[string]interface{}{
+		"items": results,
+		"total": total,
+	}
+	utils.HandleResponse(c, http.StatusOK, response, "Success")
+}
+
+// contentDetail 根据路径中的 id 获取当前用户可见的题目详情；name 用于错误信息，如 "writing set"
+func contentDetail(c *gin.Context, table, name string) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid "+name+" ID")
+		return
+	}
+
+	record, ok := visibleContent(c, table, id)
+	if !ok {
+		return
+	}
+
+	// 返回查询结果
+	response := map[string]interface{}{
+		"data": record,
+	}
+	utils.HandleResponse(c, http.StatusOK, response, "Success")
+}
+
+// deleteContent 根据路径中的 id 删除题目，只有有权限修改该题目的用户可以删除
+func deleteContent(c *gin.Context, table, name string) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid "+name+" ID")
+		return
+	}
+
+	if _, _, ok := authorizeExistingContent(c, table, id, ""); !ok {
+		return
+	}
+
+	// 执行删除操作
+	rowsAffected, err := database.DeleteData(table, id)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to delete "+name)
+		return
+	}
+
+	// 检查是否有记录被删除
+	if rowsAffected == 0 {
+		utils.HandleResponse(c, http.StatusNotFound, "", name+" not found")
+		return
+	}
+
+	// 返回成功响应
+	utils.HandleResponse(c, http.StatusOK, nil, "Success")
+}
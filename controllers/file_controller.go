@@ -8,6 +8,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Queen2333/ielts_test_backend/auth"
+	"github.com/Queen2333/ielts_test_backend/database"
+	"github.com/Queen2333/ielts_test_backend/models"
 	"github.com/Queen2333/ielts_test_backend/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -29,6 +32,12 @@ var allowedAudioTypes = map[string]bool{
 	".ogg": true,
 }
 
+// uploadDirs 文件 URL（/files/<类型>/<文件名>）中的类型对应的上传目录
+var uploadDirs = map[string]string{
+	"images": "uploads/images",
+	"audios": "uploads/audio",
+}
+
 // @Summary 上传文件（Go原生）
 // @Description 上传图片或音频文件，直接保存到本地
 // @Tags File
@@ -113,6 +122,20 @@ func UploadFileNative(c *gin.Context) {
 	// 返回文件访问 URL
 	fileURL := fmt.Sprintf("/files/%s/%s", fileType+"s", newFilename)
 
+	// 记录上传者，删除时检查
+	userID, err := utils.GetUserIDFromToken(c)
+	if err != nil {
+		os.Remove(filePath)
+		utils.HandleResponse(c, http.StatusUnauthorized, nil, err.Error())
+		return
+	}
+	record := models.UploadedFileItem{URL: fileURL, UserID: userID, CreatedAt: time.Now().Format("2006-01-02 15:04:05")}
+	if _, err := database.InsertData("uploaded_files", &record, "create"); err != nil {
+		os.Remove(filePath)
+		utils.HandleResponse(c, http.StatusInternalServerError, nil, "Failed to save file record")
+		return
+	}
+
 	response := map[string]interface{}{
 		"url":      fileURL,
 		"filename": newFilename,
@@ -124,13 +147,14 @@ func UploadFileNative(c *gin.Context) {
 }
 
 // @Summary 删除文件
-// @Description 根据文件 URL 删除文件
+// @Description 根据文件 URL 删除文件，只能删除自己上传的文件；有 content:manage 权限的用户可以删除任何文件
 // @Tags File
 // @Accept json
 // @Produce json
 // @Param url body map[string]string true "文件 URL"
 // @Success 200 {object} models.ResponseData{data=nil}
 // @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 404 {object} models.ResponseData{data=nil}
 // @Failure 500 {object} models.ResponseData{data=nil}
 // @Router /upload/delete [delete]
 func DeleteFile(c *gin.Context) {
@@ -146,16 +170,45 @@ func DeleteFile(c *gin.Context) {
 	// 从 URL 提取文件路径
 	// URL 格式：/files/images/20260125_abc12345_image.jpg
 	parts := strings.Split(request.URL, "/")
-	if len(parts) < 3 {
+	if len(parts) != 4 || parts[1] != "files" {
+		utils.HandleResponse(c, http.StatusBadRequest, nil, "Invalid file URL")
+		return
+	}
+
+	// 只允许删除上传目录中的文件
+	uploadDir, ok := uploadDirs[parts[2]] // images 或 audios
+	filename := parts[3]
+	if !ok || filename == "" || filename == "." || filename == ".." {
 		utils.HandleResponse(c, http.StatusBadRequest, nil, "Invalid file URL")
 		return
 	}
 
-	fileType := parts[2] // images 或 audios
-	filename := parts[len(parts)-1]
+	// 只能删除自己上传的文件，其他用户的文件按不存在处理
+	user, err := utils.GetUserFromToken(c)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusUnauthorized, nil, err.Error())
+		return
+	}
+	records, _, err := database.PaginationQuery("uploaded_files", 1, -1, map[string]interface{}{"url": request.URL})
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, nil, "Failed to get file record")
+		return
+	}
+	var record *models.UploadedFileItem
+	if len(records) > 0 {
+		record = &models.UploadedFileItem{}
+		if err := utils.DecodeJSONValue(records[0], record); err != nil {
+			utils.HandleResponse(c, http.StatusInternalServerError, nil, "Failed to get file record")
+			return
+		}
+	}
+	if !auth.Can(user.RoleID, auth.PermContentManage) && (record == nil || record.UserID != user.ID) {
+		utils.HandleResponse(c, http.StatusNotFound, nil, "File not found")
+		return
+	}
 
 	// 构建实际文件路径
-	filePath := filepath.Join("uploads", fileType, filename)
+	filePath := filepath.Join(uploadDir, filename)
 
 	// 检查文件是否存在
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
@@ -168,6 +221,9 @@ func DeleteFile(c *gin.Context) {
 		utils.HandleResponse(c, http.StatusInternalServerError, nil, "Failed to delete file")
 		return
 	}
+	if record != nil {
+		database.DeleteData("uploaded_files", record.ID)
+	}
 
 	utils.HandleResponse(c, http.StatusOK, nil, "File deleted successfully")
 }
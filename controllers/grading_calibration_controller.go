@@ -0,0 +1,243 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Queen2333/ielts_test_backend/database"
+	"github.com/Queen2333/ielts_test_backend/models"
+	"github.com/Queen2333/ielts_test_backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary 获取校准作文列表
+// @Description 管理员查看校准作文及参考评分
+// @Tags Calibration
+// @Accept json
+// @Produce json
+// @Param pageNo query int true "页码"
+// @Param pageLimit query int false "每页条数"
+// @Success 200 {object} models.ResponseData{data=nil}
+// @Failure 403 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /grading/calibration/essay/list [get]
+func CalibrationEssayList(c *gin.Context) {
+	pageNo, _ := strconv.Atoi(c.DefaultQuery("pageNo", "1"))
+	pageLimit, _ := strconv.Atoi(c.DefaultQuery("pageLimit", "-1"))
+
+	results, total, err := database.PaginationQuery("calibration_essays", pageNo, pageLimit, map[string]interface{}{})
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to execute pagination query")
+		return
+	}
+
+	response := map[string]interface{}{
+		"items": results,
+		"total": total,
+	}
+	utils.HandleResponse(c, http.StatusOK, response, "Success")
+}
+
+// @Summary 新增校准作文
+// @Description 管理员录入作文及四项参考评分，参考分数自动计算
+// @Tags Calibration
+// @Accept json
+// @Produce json
+// @Param essay body models.CalibrationEssayItem true "校准作文"
+// @Success 200 {object} models.ResponseData{data=nil}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 403 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /grading/calibration/essay/add [post]
+func AddCalibrationEssay(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var essay models.CalibrationEssayItem
+	if err := c.ShouldBindJSON(&essay); err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid request")
+		return
+	}
+	if problems := utils.PrepareCalibrationEssay(&essay); len(problems) > 0 {
+		utils.HandleResponse(c, http.StatusBadRequest, problems, "Invalid calibration essay")
+		return
+	}
+	essay.CreatedBy = user.ID
+
+	result, err := database.InsertData("calibration_essays", &essay, "create")
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to insert calibration essay")
+		return
+	}
+
+	utils.HandleResponse(c, http.StatusOK, result, "Success")
+}
+
+// @Summary 分配校准任务
+// @Description 管理员把校准作文分配给指定老师（grader_ids 为空时分配给所有老师）
+// @Tags Calibration
+// @Accept json
+// @Produce json
+// @Param assignment body object true "{essay_id, grader_ids}"
+// @Success 200 {object} models.ResponseData{data=nil}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 403 {object} models.ResponseData{data=nil}
+// @Router /grading/calibration/assign [post]
+func AssignCalibration(c *gin.Context) {
+	var request struct {
+		EssayID   int      `json:"essay_id" binding:"required"`
+		GraderIDs []string `json:"grader_ids"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid request")
+		return
+	}
+
+	count, err := utils.AssignCalibration(request.EssayID, request.GraderIDs)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	utils.HandleResponse(c, http.StatusOK, gin.H{"assigned": count}, "Success")
+}
+
+// @Summary 获取待完成的校准任务
+// @Description 老师获取分配给自己的校准作文（不包含参考评分）
+// @Tags Calibration
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.ResponseData{data=nil}
+// @Failure 403 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /grading/calibration/pending [get]
+func PendingCalibration(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	items, err := utils.PendingCalibration(user.ID)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get calibration items")
+		return
+	}
+
+	utils.HandleResponse(c, http.StatusOK, items, "Success")
+}
+
+// @Summary 提交校准评分
+// @Description 老师按四项标准为校准作文评分，提交后不能修改
+// @Tags Calibration
+// @Accept json
+// @Produce json
+// @Param grade body object true "{id, scores}"
+// @Success 200 {object} models.ResponseData{data=nil}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 404 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /grading/calibration/submit [post]
+func SubmitCalibration(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		ID     int                   `json:"id" binding:"required"`
+		Scores models.CriteriaScores `json:"scores"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid request")
+		return
+	}
+
+	band, problems, err := utils.SubmitCalibration(request.ID, user.ID, request.Scores)
+	if len(problems) > 0 {
+		utils.HandleResponse(c, http.StatusBadRequest, problems, "Invalid grade")
+		return
+	}
+	if errors.Is(err, utils.ErrGradingNotFound) {
+		utils.HandleResponse(c, http.StatusNotFound, "", "Calibration item not found")
+		return
+	}
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to submit calibration")
+		return
+	}
+
+	utils.HandleResponse(c, http.StatusOK, gin.H{"band": band}, "Success")
+}
+
+// @Summary 获取校准报告
+// @Description 管理员查看每位老师各项标准与参考评分的平均偏差和一致率
+// @Tags Calibration
+// @Accept json
+// @Produce json
+// @Param grader_id query string false "老师ID"
+// @Success 200 {object} models.ResponseData{data=[]models.GraderCalibrationReport}
+// @Failure 403 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /grading/calibration/report [get]
+func CalibrationReport(c *gin.Context) {
+	reports, err := utils.CalibrationReport(c.Query("grader_id"))
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to build calibration report")
+		return
+	}
+
+	utils.HandleResponse(c, http.StatusOK, reports, "Success")
+}
+
+// @Summary 获取双评和校准设置
+// @Description 获取写作双评比例（百分比）和校准间隔（每完成多少次评分自动分配一篇校准作文，0 表示不自动分配）
+// @Tags Calibration
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.ResponseData{data=nil}
+// @Failure 403 {object} models.ResponseData{data=nil}
+// @Router /grading/calibration/settings [get]
+func DoubleMarkingSettings(c *gin.Context) {
+	utils.HandleResponse(c, http.StatusOK, gin.H{
+		"double_marking_rate":  utils.GetDoubleMarkingRate(),
+		"calibration_interval": utils.GetCalibrationInterval(),
+	}, "Success")
+}
+
+// @Summary 更新双评和校准设置
+// @Description 设置提交的写作中需要双评的比例（0-100）和校准间隔（0-1000），未传的字段保持不变
+// @Tags Calibration
+// @Accept json
+// @Produce json
+// @Param settings body object true "{double_marking_rate, calibration_interval}"
+// @Success 200 {object} models.ResponseData{data=nil}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 403 {object} models.ResponseData{data=nil}
+// @Router /grading/calibration/settings [put]
+func UpdateDoubleMarkingSettings(c *gin.Context) {
+	var request struct {
+		DoubleMarkingRate   *int `json:"double_marking_rate"`
+		CalibrationInterval *int `json:"calibration_interval"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid request")
+		return
+	}
+	if request.DoubleMarkingRate != nil {
+		if err := utils.SetDoubleMarkingRate(*request.DoubleMarkingRate); err != nil {
+			utils.HandleResponse(c, http.StatusBadRequest, "", err.Error())
+			return
+		}
+	}
+	if request.CalibrationInterval != nil {
+		if err := utils.SetCalibrationInterval(*request.CalibrationInterval); err != nil {
+			utils.HandleResponse(c, http.StatusBadRequest, "", err.Error())
+			return
+		}
+	}
+
+	utils.HandleResponse(c, http.StatusOK, nil, "Success")
+}
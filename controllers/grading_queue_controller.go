@@ -0,0 +1,229 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Queen2333/ielts_test_backend/auth"
+	"github.com/Queen2333/ielts_test_backend/database"
+	"github.com/Queen2333/ielts_test_backend/models"
+	"github.com/Queen2333/ielts_test_backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary 获取评分队列
+// @Description 老师可查看待分配的以及分配给自己的队列项，管理员可查看全部
+// @Tags Grading
+// @Accept json
+// @Produce json
+// @Param status query string false "状态：pending / assigned / completed"
+// @Param skill query string false "科目：writing / speaking"
+// @Param grader_id query string false "老师ID（仅管理员）"
+// @Param overdue query int false "为 1 时只返回已超时的队列项"
+// @Success 200 {object} models.ResponseData{data=[]models.GradingQueueView}
+// @Failure 403 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /grading/queue/list [get]
+func GradingQueueList(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	conditions := make(map[string]interface{})
+	for _, key := range []string{"status", "skill"} {
+		if value := c.Query(key); value != "" {
+			conditions[key] = value
+		}
+	}
+	if graderID := c.Query("grader_id"); graderID != "" && auth.Can(user.RoleID, auth.PermGradingManage) {
+		conditions["grader_id"] = graderID
+	}
+
+	items, err := utils.ListGradingQueue(conditions, c.Query("overdue") == "1")
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get grading queue")
+		return
+	}
+
+	// 老师只能看到待分配的和自己的队列项
+	if !auth.Can(user.RoleID, auth.PermGradingManage) {
+		visible := []models.GradingQueueView{}
+		for _, item := range items {
+			if item.Status == models.QueuePending || (item.GraderID != nil && *item.GraderID == user.ID) {
+				visible = append(visible, item)
+			}
+		}
+		items = visible
+	}
+
+	utils.HandleResponse(c, http.StatusOK, items, "Success")
+}
+
+// @Summary 领取评分任务
+// @Description 老师领取一个待分配的队列项，已被其他老师领取时返回 409
+// @Tags Grading
+// @Accept json
+// @Produce json
+// @Param id path int true "队列项ID"
+// @Success 200 {object} models.ResponseData{data=nil}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 409 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /grading/queue/claim/{id} [put]
+func ClaimGradingItem(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid grading item ID")
+		return
+	}
+
+	if err := utils.ClaimGradingItem(id, user.ID); errors.Is(err, utils.ErrGradingTaken) || errors.Is(err, utils.ErrGradingDuplicate) {
+		utils.HandleResponse(c, http.StatusConflict, "", err.Error())
+		return
+	} else if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to claim grading item")
+		return
+	}
+
+	utils.HandleResponse(c, http.StatusOK, nil, "Success")
+}
+
+// @Summary 分配评分任务
+// @Description 管理员把队列项分配（或重新分配）给指定老师
+// @Tags Grading
+// @Accept json
+// @Produce json
+// @Param assignment body object true "{id, grader_id}"
+// @Success 200 {object} models.ResponseData{data=nil}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 404 {object} models.ResponseData{data=nil}
+// @Failure 409 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /grading/queue/assign [put]
+func AssignGradingItem(c *gin.Context) {
+	var request struct {
+		ID       int    `json:"id" binding:"required"`
+		GraderID string `json:"grader_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid request")
+		return
+	}
+
+	isGrader, err := utils.IsGrader(request.GraderID)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to check grader")
+		return
+	}
+	if !isGrader {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "User is not a grader")
+		return
+	}
+
+	if err := utils.AssignGradingItem(request.ID, request.GraderID); errors.Is(err, utils.ErrGradingNotFound) {
+		utils.HandleResponse(c, http.StatusNotFound, "", err.Error())
+		return
+	} else if errors.Is(err, utils.ErrGradingDuplicate) {
+		utils.HandleResponse(c, http.StatusConflict, "", err.Error())
+		return
+	} else if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to assign grading item")
+		return
+	}
+
+	utils.HandleResponse(c, http.StatusOK, nil, "Success")
+}
+
+// @Summary 自动分配评分任务
+// @Description 管理员把所有待分配的队列项按提交时间分给当前待评数量最少的老师
+// @Tags Grading
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.ResponseData{data=nil}
+// @Failure 403 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /grading/queue/auto-assign [post]
+func AutoAssignGrading(c *gin.Context) {
+	count, err := utils.AutoAssignGrading()
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+
+	utils.HandleResponse(c, http.StatusOK, gin.H{"assigned": count}, "Success")
+}
+
+// @Summary 提交口语评分
+// @Description 老师为口语记录或套题中的口语部分打分，分数写回记录并通知学生
+// @Tags Grading
+// @Accept json
+// @Produce json
+// @Param grade body models.SpeakingGradeItem true "评分内容"
+// @Success 200 {object} models.ResponseData{data=nil}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 404 {object} models.ResponseData{data=nil}
+// @Failure 409 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /grading/speaking/submit [post]
+func SubmitSpeakingGrade(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var grade models.SpeakingGradeItem
+	if err := c.ShouldBindJSON(&grade); err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid request")
+		return
+	}
+
+	tableName := map[string]string{
+		models.GradingRecordSpeaking: "speaking_records",
+		models.GradingRecordTesting:  "testing_records",
+	}[grade.RecordType]
+	if tableName == "" {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid record type")
+		return
+	}
+	if _, err := database.GetDataById(tableName, grade.RecordID); err != nil {
+		utils.HandleResponse(c, http.StatusNotFound, "", "Record not found")
+		return
+	}
+
+	if !claimForGrading(c, grade.RecordType, grade.RecordID, models.SkillSpeaking, user) {
+		return
+	}
+
+	if err := utils.ApplySpeakingBand(grade.RecordType, grade.RecordID, grade.Band); err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+	if _, err := utils.CompleteGrading(grade.RecordType, grade.RecordID, models.SkillSpeaking, user); err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to update grading queue")
+		return
+	}
+	utils.NotifyGradingFinished(grade.RecordType, grade.RecordID, models.SkillSpeaking, grade.Band)
+
+	utils.HandleResponse(c, http.StatusOK, nil, "Success")
+}
+
+// claimForGrading 评分前领取队列项，已被其他老师领取时返回 409
+func claimForGrading(c *gin.Context, recordType string, recordID int, skill string, user models.UserQuery) bool {
+	err := utils.ClaimForGrading(recordType, recordID, skill, user)
+	if errors.Is(err, utils.ErrGradingTaken) {
+		utils.HandleResponse(c, http.StatusConflict, "", err.Error())
+		return false
+	}
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to check grading queue")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Queen2333/ielts_test_backend/auth"
+	"github.com/Queen2333/ielts_test_backend/models"
+	"github.com/Queen2333/ielts_test_backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary 获取写作评分详情
+// @Description 获取记录中的作文及评分；管理员可查看所有评分，老师只能查看自己的评分，学生只能查看自己记录的最终评分
+// @Tags Grading
+// @Accept json
+// @Produce json
+// @Param record_type query string true "记录类型：writing / testing"
+// @Param record_id query int true "记录ID"
+// @Success 200 {object} models.ResponseData{data=nil}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 404 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /grading/writing/detail [get]
+func WritingGradeDetail(c *gin.Context) {
+	user, err := utils.GetUserFromToken(c)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+		return
+	}
+
+	recordType := c.Query("record_type")
+	recordID, err := strconv.Atoi(c.Query("record_id"))
+	if err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid record ID")
+		return
+	}
+
+	essays, ownerID, err := utils.LoadWritingEssays(recordType, recordID)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusNotFound, "", "Record not found")
+		return
+	}
+
+	isGrader := auth.Can(user.RoleID, auth.PermGrade)
+	if !isGrader && user.ID != ownerID {
+		utils.HandleResponse(c, http.StatusNotFound, "", "Record not found")
+		return
+	}
+
+	grades, err := utils.FindWritingGrades(recordType, recordID, "")
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get writing grades")
+		return
+	}
+	// 学生只能看到已完成的评分；双评需要盲评，老师只能看到自己的评分
+	visible := []models.WritingGradeItem{}
+	for _, grade := range grades {
+		switch {
+		case auth.Can(user.RoleID, auth.PermGradingManage):
+			visible = append(visible, grade)
+		case auth.Can(user.RoleID, auth.PermGrade):
+			if grade.GraderID == user.ID {
+				visible = append(visible, grade)
+			}
+		case grade.Status == models.GradeFinal:
+			visible = append(visible, grade)
+		}
+	}
+	grades = visible
+
+	response := map[string]interface{}{
+		"essays": essays,
+		"grades": grades,
+	}
+	utils.HandleResponse(c, http.StatusOK, response, "Success")
+}
+
+// @Summary 提交写作评分
+// @Description 老师按四项评分标准为每篇作文打分并添加批注；status=final 且所有评分人完成时把写作分数写回记录并通知学生
+// @Tags Grading
+// @Accept json
+// @Produce json
+// @Param grade body models.WritingGradeItem true "评分内容"
+// @Success 200 {object} models.ResponseData{data=nil}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 403 {object} models.ResponseData{data=nil}
+// @Failure 404 {object} models.ResponseData{data=nil}
+// @Failure 409 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /grading/writing/submit [post]
+func SubmitWritingGrade(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var grade models.WritingGradeItem
+	if err := c.ShouldBindJSON(&grade); err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid request")
+		return
+	}
+	if grade.Status != models.GradeFinal {
+		grade.Status = models.GradeDraft
+	}
+	grade.GraderID = user.ID
+	grade.ID = 0
+
+	essays, _, err := utils.LoadWritingEssays(grade.RecordType, grade.RecordID)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusNotFound, "", "Record not found")
+		return
+	}
+
+	if problems := utils.PrepareWritingGrade(&grade, essays); len(problems) > 0 {
+		utils.HandleResponse(c, http.StatusBadRequest, problems, "Invalid grade")
+		return
+	}
+
+	if !claimForGrading(c, grade.RecordType, grade.RecordID, models.SkillWriting, user) {
+		return
+	}
+
+	if err := utils.SaveWritingGrade(&grade); err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to save writing grade")
+		return
+	}
+
+	// 双评时等所有评分人完成后再确定分数，分歧过大时加入仲裁
+	resolved := false
+	if grade.Status == models.GradeFinal {
+		if _, err := utils.CompleteGrading(grade.RecordType, grade.RecordID, models.SkillWriting, user); err != nil {
+			utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to update grading queue")
+			return
+		}
+		band, done, err := utils.ResolveWritingBand(grade.RecordType, grade.RecordID, grade)
+		if err != nil {
+			utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to resolve writing band")
+			return
+		}
+		if done {
+			if err := utils.ApplyWritingBand(grade.RecordType, grade.RecordID, band); err != nil {
+				utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to update record band")
+				return
+			}
+			utils.NotifyGradingFinished(grade.RecordType, grade.RecordID, models.SkillWriting, band)
+		}
+		resolved = done
+	}
+
+	utils.HandleResponse(c, http.StatusOK, gin.H{"grade": grade, "resolved": resolved}, "Success")
+}
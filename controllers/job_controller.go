@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Queen2333/ielts_test_backend/auth"
+	"github.com/Queen2333/ielts_test_backend/jobs"
+	"github.com/Queen2333/ielts_test_backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary 获取后台任务状态
+// @Description 获取任务状态、执行次数和最后一次错误；只能查看自己创建的任务，管理员可查看全部
+// @Tags Jobs
+// @Accept json
+// @Produce json
+// @Param id path string true "任务ID"
+// @Success 200 {object} models.ResponseData{data=jobs.Job}
+// @Failure 404 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /jobs/{id} [get]
+func JobDetail(c *gin.Context) {
+	user, err := utils.GetUserFromToken(c)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+		return
+	}
+
+	job, err := jobs.Get(c.Param("id"))
+	if errors.Is(err, jobs.ErrJobNotFound) || (err == nil && job.UserID != user.ID && !auth.Can(user.RoleID, auth.PermJobsManage)) {
+		utils.HandleResponse(c, http.StatusNotFound, "", "Job not found")
+		return
+	}
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get job")
+		return
+	}
+	utils.HandleResponse(c, http.StatusOK, job, "Success")
+}
+
+// @Summary 获取死信任务列表
+// @Description 获取重试次数用完的任务（最近 100 条），仅管理员可查看
+// @Tags Jobs
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.ResponseData{data=[]jobs.Job}
+// @Failure 403 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /jobs/dead [get]
+func DeadJobList(c *gin.Context) {
+	list, err := jobs.DeadJobs(100)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get dead jobs")
+		return
+	}
+	if list == nil {
+		list = []*jobs.Job{}
+	}
+	utils.HandleResponse(c, http.StatusOK, list, "Success")
+}
@@ -0,0 +1,328 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Queen2333/ielts_test_backend/auth"
+	"github.com/Queen2333/ielts_test_backend/database"
+	"github.com/Queen2333/ielts_test_backend/mailer"
+	"github.com/Queen2333/ielts_test_backend/models"
+	"github.com/Queen2333/ielts_test_backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRequest 注册请求：邮箱验证码用于验证邮箱
+type RegisterRequest struct {
+	Email    string `json:"email"`
+	Code     string `json:"code"`
+	Password string `json:"password"`
+}
+
+// PasswordLoginRequest 密码登录请求
+type PasswordLoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// PasswordForgotRequest 申请重置密码
+type PasswordForgotRequest struct {
+	Email string `json:"email"`
+}
+
+// PasswordResetRequest 通过邮件中的令牌重置密码
+type PasswordResetRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// PasswordChangeRequest 修改密码，没有设置过密码时用邮箱验证码 code 代替 old_password
+type PasswordChangeRequest struct {
+	OldPassword string `json:"old_password"`
+	Code        string `json:"code"`
+	NewPassword string `json:"new_password"`
+}
+
+// @Summary 密码注册
+// @Description 使用邮箱验证码验证邮箱并设置密码，注册成功后直接登录。已通过验证码登录过的用户可以用这个接口补充设置密码
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param request body RegisterRequest true "邮箱、验证码和密码"
+// @Success 200 {object} models.ResponseData{data=models.TokenPair}
+// @Failure 400 {object} models.ResponseData
+// @Failure 401 {object} models.ResponseData
+// @Failure 409 {object} models.ResponseData
+// @Failure 429 {object} models.ResponseData
+// @Failure 500 {object} models.ResponseData
+// @Router /register [post]
+func RegisterHandler(c *gin.Context) {
+	var request RegisterRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid request")
+		return
+	}
+	request.Email = utils.NormalizeEmail(request.Email)
+	if !utils.IsValidEmail(request.Email) || request.Code == "" {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Email and code are required")
+		return
+	}
+	// 先检查密码，避免弱密码消耗掉验证码
+	if err := utils.ValidatePassword(request.Password); err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	ip := c.ClientIP()
+	if err := utils.VerifyOTP(request.Email, request.Code, ip); err != nil {
+		switch {
+		case errors.Is(err, utils.ErrOTPLocked):
+			utils.HandleResponse(c, http.StatusTooManyRequests, "", err.Error())
+		case errors.Is(err, utils.ErrOTPInvalid):
+			utils.HandleResponse(c, http.StatusUnauthorized, "", "Invalid verification code")
+		default:
+			utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to verify code")
+		}
+		return
+	}
+
+	user, err := findOrCreateUser(request.Email)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+	hash, err := utils.UserPasswordHash(user.ID)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to check user existence")
+		return
+	}
+	if hash != "" {
+		utils.HandleResponse(c, http.StatusConflict, "", "Email already registered, please log in or reset your password")
+		return
+	}
+	if err := utils.SetUserPassword(user.ID, request.Password); err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to set password")
+		return
+	}
+	utils.AuditAuth(models.AuditPasswordRegistered, request.Email, ip, user.ID, "")
+
+	respondWithToken(c, user)
+}
+
+// @Summary 密码登录
+// @Description 使用邮箱和密码登录；连续输错5次后锁定15分钟
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param request body PasswordLoginRequest true "邮箱和密码"
+// @Success 200 {object} models.ResponseData{data=models.TokenPair}
+// @Failure 400 {object} models.ResponseData
+// @Failure 401 {object} models.ResponseData
+// @Failure 429 {object} models.ResponseData
+// @Failure 500 {object} models.ResponseData
+// @Router /login/password [post]
+func PasswordLoginHandler(c *gin.Context) {
+	var request PasswordLoginRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid request")
+		return
+	}
+	request.Email = utils.NormalizeEmail(request.Email)
+	if request.Email == "" || request.Password == "" {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Email and password are required")
+		return
+	}
+
+	ip := c.ClientIP()
+	user, err := utils.AuthenticatePassword(request.Email, request.Password, ip)
+	if err != nil {
+		switch {
+		case errors.Is(err, utils.ErrPasswordLocked):
+			utils.HandleResponse(c, http.StatusTooManyRequests, "", err.Error())
+		case errors.Is(err, utils.ErrInvalidCredentials):
+			utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+		default:
+			utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to log in")
+		}
+		return
+	}
+	utils.AuditAuth(models.AuditPasswordLogin, request.Email, ip, user.ID, "")
+
+	respondWithToken(c, user)
+}
+
+// @Summary 申请重置密码
+// @Description 向邮箱发送重置密码的链接（30分钟内有效，只能使用一次）；无论邮箱是否注册都返回成功
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param request body PasswordForgotRequest true "邮箱"
+// @Success 200 {object} models.ResponseData
+// @Failure 400 {object} models.ResponseData
+// @Failure 500 {object} models.ResponseData
+// @Router /password/forgot [post]
+func PasswordForgotHandler(c *gin.Context) {
+	var request PasswordForgotRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid request")
+		return
+	}
+	request.Email = utils.NormalizeEmail(request.Email)
+	if !utils.IsValidEmail(request.Email) {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid email")
+		return
+	}
+
+	ip := c.ClientIP()
+	user, err := checkUserExists(request.Email)
+	if err != nil {
+		if !database.IsNoRowsError(err) {
+			utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to check user existence")
+			return
+		}
+		// 不暴露邮箱是否注册
+		utils.AuditAuth(models.AuditPasswordResetRequested, request.Email, ip, "", "unknown email")
+		utils.HandleResponse(c, http.StatusOK, "", "success")
+		return
+	}
+
+	token, link, err := utils.IssuePasswordReset(user.ID)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to create reset token")
+		return
+	}
+	_, err = mailer.Send(request.Email, mailer.TemplatePasswordReset, c.GetHeader("Accept-Language"), map[string]interface{}{
+		"Token":   token,
+		"Link":    link,
+		"Minutes": int(utils.PasswordResetTokenTTL.Minutes()),
+	})
+	if err != nil {
+		utils.ConsumePasswordReset(token)
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to send reset email")
+		return
+	}
+	utils.AuditAuth(models.AuditPasswordResetRequested, request.Email, ip, user.ID, "")
+
+	utils.HandleResponse(c, http.StatusOK, "", "success")
+}
+
+// @Summary 重置密码
+// @Description 使用邮件中的令牌设置新密码，成功后令牌失效，所有登录会话都会被注销
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param request body PasswordResetRequest true "令牌和新密码"
+// @Success 200 {object} models.ResponseData
+// @Failure 400 {object} models.ResponseData
+// @Failure 500 {object} models.ResponseData
+// @Router /password/reset [post]
+func PasswordResetHandler(c *gin.Context) {
+	var request PasswordResetRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid request")
+		return
+	}
+	if err := utils.ValidatePassword(request.Password); err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	userID, err := utils.ConsumePasswordReset(request.Token)
+	if err != nil {
+		if errors.Is(err, utils.ErrPasswordResetInvalid) {
+			utils.HandleResponse(c, http.StatusBadRequest, "", err.Error())
+			return
+		}
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to verify reset token")
+		return
+	}
+	if err := utils.SetUserPassword(userID, request.Password); err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to set password")
+		return
+	}
+
+	email, _ := userEmailByID(userID)
+	utils.ClearPasswordLockout(email)
+	utils.RevokeUserSessions(userID, "")
+	utils.AuditAuth(models.AuditPasswordReset, email, c.ClientIP(), userID, "")
+
+	utils.HandleResponse(c, http.StatusOK, "", "success")
+}
+
+// @Summary 修改密码
+// @Description 登录后修改密码；已设置过密码时需要提供原密码，首次设置密码时需要提供发送到邮箱的验证码。修改后除当前会话外的其他会话都会被注销
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param request body PasswordChangeRequest true "原密码或验证码，以及新密码"
+// @Success 200 {object} models.ResponseData
+// @Failure 400 {object} models.ResponseData
+// @Failure 401 {object} models.ResponseData
+// @Failure 429 {object} models.ResponseData
+// @Failure 500 {object} models.ResponseData
+// @Router /password/change [put]
+func PasswordChangeHandler(c *gin.Context) {
+	user, err := utils.GetUserFromToken(c)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+		return
+	}
+
+	var request PasswordChangeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid request")
+		return
+	}
+	if err := utils.ValidatePassword(request.NewPassword); err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	hash, err := utils.UserPasswordHash(user.ID)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get user")
+		return
+	}
+	ip := c.ClientIP()
+	if hash != "" && !utils.CheckPassword(hash, request.OldPassword) {
+		utils.AuditAuth(models.AuditPasswordFailed, user.Email, ip, user.ID, "change password")
+		utils.HandleResponse(c, http.StatusUnauthorized, "", "Current password is incorrect")
+		return
+	}
+	// 首次设置密码需要重新验证邮箱，避免仅凭访问令牌就能添加永久的密码登录方式
+	if hash == "" {
+		if request.Code == "" {
+			utils.HandleResponse(c, http.StatusBadRequest, "", "Verification code is required")
+			return
+		}
+		if err := utils.VerifyOTP(user.Email, request.Code, ip); err != nil {
+			switch {
+			case errors.Is(err, utils.ErrOTPLocked):
+				utils.HandleResponse(c, http.StatusTooManyRequests, "", err.Error())
+			case errors.Is(err, utils.ErrOTPInvalid):
+				utils.AuditAuth(models.AuditPasswordFailed, user.Email, ip, user.ID, "set password: invalid code")
+				utils.HandleResponse(c, http.StatusUnauthorized, "", "Invalid verification code")
+			default:
+				utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to verify code")
+			}
+			return
+		}
+	}
+
+	if err := utils.SetUserPassword(user.ID, request.NewPassword); err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to set password")
+		return
+	}
+	principal, _ := auth.PrincipalFrom(c)
+	utils.RevokeUserSessions(user.ID, principal.SessionID)
+	utils.AuditAuth(models.AuditPasswordChanged, user.Email, ip, user.ID, "")
+
+	utils.HandleResponse(c, http.StatusOK, "", "success")
+}
+
+// userEmailByID 获取用户邮箱
+func userEmailByID(userID string) (string, error) {
+	var email string
+	err := database.GetDB().QueryRow("SELECT email FROM user_list WHERE id = ?", userID).Scan(&email)
+	return email, err
+}
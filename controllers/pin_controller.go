@@ -1,27 +1,28 @@
 package controllers
 
 import (
-	"fmt"
+	"errors"
+	"math"
 	"net/http"
-	"time"
+	"strconv"
 
+	"github.com/Queen2333/ielts_test_backend/mailer"
+	"github.com/Queen2333/ielts_test_backend/models"
 	"github.com/Queen2333/ielts_test_backend/utils"
 	"github.com/gin-gonic/gin"
 )
 
 // @Summary 发送验证码到邮箱
-// @Description 生成6位随机验证码并发送到指定邮箱地址
+// @Description 生成6位随机验证码并发送到指定邮箱地址，有效期15分钟，只能使用一次；验证码不会在响应中返回。同一邮箱60秒、同一IP 10秒内只能发送一次
 // @Accept json
 // @Produce json
 // @Param email body string true "目标邮箱地址"
 // @Success 200 {object} models.ResponseData
 // @Failure 400 {object} models.ResponseData
-// @Failure 400 {object} models.ResponseData
+// @Failure 429 {object} models.ResponseData
 // @Failure 500 {object} models.ResponseData
 // @Router /send-code [post]
 func SendCodeHandler(c *gin.Context) {
-	utils.InitRedis()
-
 	// 解析请求参数
 	var request struct {
 		Email string `json:"email"`
@@ -29,27 +30,45 @@ func SendCodeHandler(c *gin.Context) {
 
 	if err := c.ShouldBindJSON(&request); err != nil {
 		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid request")
-        return
-    }
+		return
+	}
+	request.Email = utils.NormalizeEmail(request.Email)
 
 	// 验证邮箱格式
 	if err := utils.IsValidEmail(request.Email); !err {
 		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid email")
-        return
+		return
 	}
 
-	// 生成6位随机验证码
-	code := utils.GenerateRandomNumber(6)
-
-	// 将验证码存储到 Redis 中，有效期为5分钟
-	err := utils.Set("verification_code_" + request.Email, code, 15 * time.Minute)
-    if err != nil {
-		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to store verification code")
-        return
-    }
+	// 生成验证码（Redis 中只保存哈希），检查锁定和发送间隔
+	ip := c.ClientIP()
+	code, err := utils.IssueOTP(request.Email, ip)
+	if err != nil {
+		var cooldown *utils.OTPCooldownError
+		switch {
+		case errors.As(err, &cooldown):
+			retryAfter := int(math.Ceil(cooldown.RetryAfter.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			utils.HandleResponse(c, http.StatusTooManyRequests, gin.H{"retry_after": retryAfter}, err.Error())
+		case errors.Is(err, utils.ErrOTPLocked):
+			utils.HandleResponse(c, http.StatusTooManyRequests, "", err.Error())
+		default:
+			utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to generate verification code")
+		}
+		return
+	}
 
-	utils.SendEmail("邮箱登录验证", fmt.Sprintf("您的验证码为: %s, 有效期为15分钟", code), request.Email)
+	// 写入发件箱，由后台任务发送
+	_, err = mailer.Send(request.Email, mailer.TemplateVerificationCode, c.GetHeader("Accept-Language"), map[string]interface{}{
+		"Code":    code,
+		"Minutes": int(utils.OTPTTL.Minutes()),
+	})
+	if err != nil {
+		utils.RevokeOTP(request.Email, ip)
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to send verification code")
+		return
+	}
+	utils.AuditAuth(models.AuditOTPSent, request.Email, ip, "", "")
 
-	// 返回验证码
-	utils.HandleResponse(c, http.StatusOK, code, "success")
+	utils.HandleResponse(c, http.StatusOK, "", "success")
 }
@@ -36,6 +36,9 @@ func ListeningRecords(c *gin.Context) {
 		// 错误处理已在 ProcessRequest 中处理
 		return
 	}
+	// 只返回当前用户自己的记录，查看所有用户的记录使用 /admin/record 下的接口
+	userID, _ := utils.GetUserIDFromToken(c)
+	conditions["user_id"] = userID
 
 	
 	// 执行分页查询
@@ -70,11 +73,10 @@ func ListeningRecordDetail(c *gin.Context) {
 		return
 	}
 
-	record, err := database.GetDataById("listening_records", id)
-    if err != nil {
-        utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get data by id")
-        return
-    }
+	record, _, ok := loadOwnedRecord(c, "listening_records", id)
+	if !ok {
+		return
+	}
 
 	// 返回查询结果
 	response := map[string]interface{}{
@@ -111,6 +113,8 @@ func AddListeningRecord(c *gin.Context) {
 	}
 	// 将 user_id 添加到 part 中
 	part.UserID = userID
+	// 分数和评分结果只能由交卷接口写入
+	part.Score, part.RawScore, part.Band, part.Result = 0, 0, 0, nil
 
 	// 将数据插入数据库
 	result, err := database.InsertData("listening_records", &part, "create")
@@ -141,26 +145,31 @@ func UpdateListeningRecord(c *gin.Context) {
 		return
 	}
 
-	part.Status = "0"
-	part.Type = "3"
-	userID, err := utils.GetUserIDFromToken(c)
-	if err != nil {
-		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+	// 只能修改自己的记录，user_id 不能通过请求修改
+	_, user, ok := loadOwnedRecord(c, "listening_records", part.ID)
+	if !ok {
 		return
 	}
-	// 将 user_id 添加到 part 中
-	part.UserID = userID
+	part.UserID = user.ID
 
-	// 将数据插入数据库
-	result, err := database.InsertData("listening_records", &part, "update")
-	fmt.Println(err, "err")
+	part.Status = "0"
+	part.Type = "3"
+
+	// 只更新作答相关的字段，分数和评分结果只能由交卷接口写入
+	err := database.UpdateColumns("listening_records", part.ID, map[string]interface{}{
+		"name":         part.Name,
+		"status":       part.Status,
+		"type":         part.Type,
+		"answers":      part.Answers,
+		"rest_seconds": part.RestSeconds,
+		"test_id":      part.TestID,
+	})
 	if err != nil {
 		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to update listening records")
 		return
 	}
 
-	// 返回插入后的数据
-	utils.HandleResponse(c, http.StatusOK, result, "Success")
+	utils.HandleResponse(c, http.StatusOK, nil, "Success")
 }
 
 // @Summary 删除听力做题记录
@@ -182,6 +191,10 @@ func DeleteListeningRecord(c *gin.Context) {
 		return
 	}
 
+	if _, _, ok := loadOwnedRecord(c, "listening_records", id); !ok {
+		return
+	}
+
 	// 执行删除操作
 	rowsAffected, err := database.DeleteData("listening_records", id)
 	if err != nil {
@@ -219,12 +232,17 @@ func SubmitListeningRecord(c *gin.Context) {
 		return
 	}
 
+	// 只能提交自己的记录
+	_, user, ok := loadOwnedRecord(c, "listening_records", part.ID)
+	if !ok {
+		return
+	}
+
 	// 根据test_id获取听力列表
-	test, err := database.GetDataById("listening_list", part.TestID)
-    if err != nil {
-        utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get data by id")
-        return
-    }
+	test, ok := visibleContent(c, "listening_list", part.TestID)
+	if !ok {
+		return
+	}
 	// 获取part_list
 	partListInterface, ok := test["part_list"].([]interface{})
 	if !ok {
@@ -233,18 +251,28 @@ func SubmitListeningRecord(c *gin.Context) {
 	}
 
 	// 调用 GetPartDetails 获取part详细信息
-	details, err := utils.GetPartDetails(partListInterface, "listening_part_list")
+	details, err := utils.GetPartDetails(partListInterface, "listening_part_list", user.ID)
 	if err != nil {
 		utils.HandleResponse(c, http.StatusInternalServerError, "", "failed to get part detail")
 		return
 	}
 
-	// 继续使用 parsedDetails 进行评分计算
-	score := utils.CalculateScore(details, part.Answers)
+	// 逐题评分，并把答对题数换算为 Band 分
+	scoreResult := utils.GradeParts(details, part.Answers)
+	rawScore := scoreResult.RawScore
+	band, err := utils.CalculateBand(models.SkillListening, "", rawScore)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to convert band score")
+		return
+	}
 
 	part.Status = "0"
 	part.Type = "3"
-	part.Score = int(score)
+	part.Score = int(band)
+	part.RawScore = rawScore
+	part.Band = band
+	scoreResult.Band = band
+	part.Result = &scoreResult
 	userID, err := utils.GetUserIDFromToken(c)
 	if err != nil {
 		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
@@ -263,4 +291,36 @@ func SubmitListeningRecord(c *gin.Context) {
 
 	// // 返回插入后的数据
 	utils.HandleResponse(c, http.StatusOK, result, "Success")
-}
\ No newline at end of file
+}
+
+// @Summary 查看听力做题结果
+// @Description 返回提交后保存的逐题评分结果：原始分、Band 分以及每道题的作答与正确答案
+// @Tags Listening
+// @Accept json
+// @Produce json
+// @Param id path int true "听力做题记录ID"
+// @Success 200 {object} models.ResponseData{data=models.ScoreResult}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 404 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /record/listening/review/{id} [get]
+func ListeningRecordReview(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid listening record ID")
+		return
+	}
+
+	record, _, ok := loadOwnedRecord(c, "listening_records", id)
+	if !ok {
+		return
+	}
+
+	// 未提交的记录没有评分结果
+	if record["result"] == nil {
+		utils.HandleResponse(c, http.StatusNotFound, "", "Listening record has not been submitted")
+		return
+	}
+
+	utils.HandleResponse(c, http.StatusOK, record["result"], "Success")
+}
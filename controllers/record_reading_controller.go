@@ -36,6 +36,9 @@ func ReadingRecords(c *gin.Context) {
 		// 错误处理已在 ProcessRequest 中处理
 		return
 	}
+	// 只返回当前用户自己的记录，查看所有用户的记录使用 /admin/record 下的接口
+	userID, _ := utils.GetUserIDFromToken(c)
+	conditions["user_id"] = userID
 
 	
 	// 执行分页查询
@@ -71,11 +74,10 @@ func ReadingRecordDetail(c *gin.Context) {
 		return
 	}
 
-	record, err := database.GetDataById("reading_records", id)
-    if err != nil {
-        utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get data by id")
-        return
-    }
+	record, _, ok := loadOwnedRecord(c, "reading_records", id)
+	if !ok {
+		return
+	}
 
 	// 返回查询结果
 	response := map[string]interface{}{
@@ -102,6 +104,16 @@ func AddReadingRecord(c *gin.Context) {
 		return
 	}
 
+	userID, err := utils.GetUserIDFromToken(c)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+		return
+	}
+	// 记录属于当前用户
+	part.UserID = userID
+	// 分数和评分结果只能由交卷接口写入
+	part.Score, part.RawScore, part.Band, part.Result = 0, 0, 0, nil
+
 	// 将数据插入数据库
 	result, err := database.InsertData("reading_records", &part, "create")
 	if err != nil {
@@ -130,16 +142,28 @@ func UpdateReadingRecord(c *gin.Context) {
 		return
 	}
 
-	// 将数据插入数据库
-	result, err := database.InsertData("reading_records", &part, "update")
-	fmt.Println(err, "err")
+	// 只能修改自己的记录，user_id 不能通过请求修改
+	_, user, ok := loadOwnedRecord(c, "reading_records", part.ID)
+	if !ok {
+		return
+	}
+	part.UserID = user.ID
+
+	// 只更新作答相关的字段，分数和评分结果只能由交卷接口写入
+	err := database.UpdateColumns("reading_records", part.ID, map[string]interface{}{
+		"name":         part.Name,
+		"status":       part.Status,
+		"type":         part.Type,
+		"answers":      part.Answers,
+		"rest_seconds": part.RestSeconds,
+		"test_id":      part.TestID,
+	})
 	if err != nil {
 		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to update reading record")
 		return
 	}
 
-	// 返回插入后的数据
-	utils.HandleResponse(c, http.StatusOK, result, "Success")
+	utils.HandleResponse(c, http.StatusOK, nil, "Success")
 }
 
 // @Summary 删除阅读做题记录
@@ -161,6 +185,10 @@ func DeleteReadingRecord(c *gin.Context) {
 		return
 	}
 
+	if _, _, ok := loadOwnedRecord(c, "reading_records", id); !ok {
+		return
+	}
+
 	// 执行删除操作
 	rowsAffected, err := database.DeleteData("reading_records", id)
 	if err != nil {
@@ -198,12 +226,17 @@ func SubmitReadingRecord(c *gin.Context) {
 		return
 	}
 
+	// 只能提交自己的记录
+	_, user, ok := loadOwnedRecord(c, "reading_records", part.ID)
+	if !ok {
+		return
+	}
+
 	// 根据test_id获取阅读列表
-	test, err := database.GetDataById("reading_list", part.TestID)
-    if err != nil {
-        utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get data by id")
-        return
-    }
+	test, ok := visibleContent(c, "reading_list", part.TestID)
+	if !ok {
+		return
+	}
 	// 获取part_list
 	partListInterface, ok := test["part_list"].([]interface{})
 	if !ok {
@@ -212,19 +245,30 @@ func SubmitReadingRecord(c *gin.Context) {
 	}
 
 	// 调用 GetPartDetails 获取part详细信息
-	details, err := utils.GetPartDetails(partListInterface, "reading_part_list")
+	details, err := utils.GetPartDetails(partListInterface, "reading_part_list", user.ID)
 	if err != nil {
 		utils.HandleResponse(c, http.StatusInternalServerError, "", "failed to get part detail")
 		return
 	}
 	spew.Dump(partListInterface, "partListInterface")
 
-	// 继续使用 parsedDetails 进行评分计算
-	score := utils.CalculateScore(details, part.Answers)
+	// 逐题评分，并按学术类/培训类把答对题数换算为 Band 分
+	scoreResult := utils.GradeParts(details, part.Answers)
+	rawScore := scoreResult.RawScore
+	module, _ := test["module"].(string)
+	band, err := utils.CalculateBand(models.SkillReading, module, rawScore)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to convert band score")
+		return
+	}
 
 	part.Status = "0"
 	part.Type = "3"
-	part.Score = int(score)
+	part.Score = int(band)
+	part.RawScore = rawScore
+	part.Band = band
+	scoreResult.Band = band
+	part.Result = &scoreResult
 	userID, err := utils.GetUserIDFromToken(c)
 	if err != nil {
 		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
@@ -243,4 +287,36 @@ func SubmitReadingRecord(c *gin.Context) {
 
 	// // 返回插入后的数据
 	utils.HandleResponse(c, http.StatusOK, result, "Success")
-}
\ No newline at end of file
+}
+
+// @Summary 查看阅读做题结果
+// @Description 返回提交后保存的逐题评分结果：原始分、Band 分以及每道题的作答与正确答案
+// @Tags Reading
+// @Accept json
+// @Produce json
+// @Param id path int true "阅读做题记录ID"
+// @Success 200 {object} models.ResponseData{data=models.ScoreResult}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 404 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /record/reading/review/{id} [get]
+func ReadingRecordReview(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid reading record ID")
+		return
+	}
+
+	record, _, ok := loadOwnedRecord(c, "reading_records", id)
+	if !ok {
+		return
+	}
+
+	// 未提交的记录没有评分结果
+	if record["result"] == nil {
+		utils.HandleResponse(c, http.StatusNotFound, "", "Reading record has not been submitted")
+		return
+	}
+
+	utils.HandleResponse(c, http.StatusOK, record["result"], "Success")
+}
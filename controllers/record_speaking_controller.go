@@ -0,0 +1,271 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Queen2333/ielts_test_backend/database"
+	"github.com/Queen2333/ielts_test_backend/models"
+	"github.com/Queen2333/ielts_test_backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary 获取口语做题记录列表
+// @Description 根据条件获取口语记录列表，并返回分页结果
+// @Tags Speaking
+// @Accept json
+// @Produce json
+// @Param name query string false "名称"
+// @Param status query int false "状态"
+// @Param type query int false "试题类型"
+// @Param pageNo query int true "页码"
+// @Param pageLimit query int false "每页条数"
+// @Success 200 {object} models.ResponseData{data=models.SpeakingRecordsResponse}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /record/speaking/list [get]
+func SpeakingRecords(c *gin.Context) {
+
+	pageNo, _ := strconv.Atoi(c.DefaultQuery("pageNo", "1"))
+	pageLimit, _ := strconv.Atoi(c.DefaultQuery("pageLimit", "-1"))
+
+	conditions, err := utils.ProcessRequest(c)
+	if err != nil {
+		// 错误处理已在 ProcessRequest 中处理
+		return
+	}
+	// 只返回当前用户自己的记录，查看所有用户的记录使用 /admin/record 下的接口
+	userID, _ := utils.GetUserIDFromToken(c)
+	conditions["user_id"] = userID
+
+	// 执行分页查询
+	results, total, err := database.PaginationQuery("speaking_records", pageNo, pageLimit, conditions)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to execute pagination query")
+		return
+	}
+
+	// 返回查询结果
+	response := map[string]interface{}{
+		"items": results,
+		"total": total,
+	}
+	utils.HandleResponse(c, http.StatusOK, response, "Success")
+}
+
+// @Summary 获取口语做题记录详情
+// @Description 根据id获取口语做题记录详情
+// @Tags Speaking
+// @Accept json
+// @Produce json
+// @Param id query int true "口语做题记录id"
+// @Success 200 {object} models.ResponseData{data=models.SpeakingRecordsItem}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /record/speaking/detail/{id} [get]
+func SpeakingRecordDetail(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid speaking record ID")
+		return
+	}
+
+	record, _, ok := loadOwnedRecord(c, "speaking_records", id)
+	if !ok {
+		return
+	}
+
+	// 返回查询结果
+	response := map[string]interface{}{
+		"data": record,
+	}
+	utils.HandleResponse(c, http.StatusOK, response, "Success")
+}
+
+// @Summary 新增口语做题记录
+// @Description 新增口语做题记录
+// @Tags Speaking
+// @Accept json
+// @Produce json
+// @Param part body models.SpeakingRecordsItem true "口语做题记录内容"
+// @Success 200 {object} models.ResponseData{data=nil}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /record/speaking/add [post]
+func AddSpeakingRecord(c *gin.Context) {
+	var part models.SpeakingRecordsItem
+	if err := c.ShouldBindJSON(&part); err != nil {
+		fmt.Println(err)
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid request")
+		return
+	}
+
+	userID, err := utils.GetUserIDFromToken(c)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+		return
+	}
+	// 记录属于当前用户
+	part.UserID = userID
+	// 评分只能由评分接口写入
+	part.Band = nil
+
+	// 将数据插入数据库
+	result, err := database.InsertData("speaking_records", &part, "create")
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to insert speaking records")
+		return
+	}
+
+	// 返回插入后的数据
+	utils.HandleResponse(c, http.StatusOK, result, "Success")
+}
+
+// @Summary 更新口语做题记录
+// @Description 更新口语做题记录
+// @Tags Speaking
+// @Accept json
+// @Produce json
+// @Param part body models.SpeakingRecordsItem true "口语做题记录内容"
+// @Success 200 {object} models.ResponseData{data=nil}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /record/speaking/update [put]
+func UpdateSpeakingRecord(c *gin.Context) {
+	var part models.SpeakingRecordsItem
+	if err := c.ShouldBindJSON(&part); err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid request")
+		return
+	}
+
+	// 只能修改自己的记录，user_id 不能通过请求修改
+	if _, _, ok := loadOwnedRecord(c, "speaking_records", part.ID); !ok {
+		return
+	}
+
+	// 只更新作答相关的字段，评分只能由评分接口写入
+	err := database.UpdateColumns("speaking_records", part.ID, map[string]interface{}{
+		"name":         part.Name,
+		"status":       part.Status,
+		"type":         part.Type,
+		"speaking_id":  part.SpeakingID,
+		"answers":      part.Answers,
+		"rest_seconds": part.RestSeconds,
+	})
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to update speaking record")
+		return
+	}
+
+	utils.HandleResponse(c, http.StatusOK, nil, "Success")
+}
+
+// @Summary 删除口语做题记录
+// @Description 根据ID删除口语做题记录
+// @Tags Speaking
+// @Accept json
+// @Produce json
+// @Param id path int true "口语做题记录ID"
+// @Success 200 {object} models.ResponseData{data=nil}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 404 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /record/speaking/delete/{id} [delete]
+func DeleteSpeakingRecord(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid speaking record ID")
+		return
+	}
+
+	if _, _, ok := loadOwnedRecord(c, "speaking_records", id); !ok {
+		return
+	}
+
+	// 执行删除操作
+	rowsAffected, err := database.DeleteData("speaking_records", id)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to delete speaking record")
+		return
+	}
+
+	// 检查是否有记录被删除
+	if rowsAffected == 0 {
+		utils.HandleResponse(c, http.StatusNotFound, "", "speaking record not found")
+		return
+	}
+
+	// 返回成功响应
+	utils.HandleResponse(c, http.StatusOK, nil, "Success")
+}
+
+// @Summary 提交口语做题记录
+// @Description 提交口语录音，录音需先通过 /upload/file 上传，返回未录音的题目
+// @Tags Speaking
+// @Accept json
+// @Produce json
+// @Param part body models.SpeakingRecordsItem true "口语做题记录内容"
+// @Success 200 {object} models.ResponseData{data=nil}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /record/speaking/submit [post]
+func SubmitSpeakingRecord(c *gin.Context) {
+	var part models.SpeakingRecordsItem
+	if err := c.ShouldBindJSON(&part); err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid request")
+		return
+	}
+
+	// 只能提交自己的记录
+	_, user, ok := loadOwnedRecord(c, "speaking_records", part.ID)
+	if !ok {
+		return
+	}
+
+	// 根据speaking_id获取口语套题
+	test, ok := visibleContent(c, "speaking_list", part.SpeakingID)
+	if !ok {
+		return
+	}
+
+	details, _, err := utils.LoadVisibleParts("speaking_part_list", utils.InterfaceToIntList(test["part_list"]), user.ID)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "failed to get part detail")
+		return
+	}
+
+	// 校验录音对应的题目以及音频文件
+	problems, missing := utils.ValidateSpeakingAnswers(details, part.Answers)
+	if len(problems) > 0 {
+		utils.HandleResponse(c, http.StatusBadRequest, problems, "Invalid answers")
+		return
+	}
+
+	userID, err := utils.GetUserIDFromToken(c)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+		return
+	}
+	part.Status = "0"
+	part.Type = "3"
+	part.Band = nil
+	part.UserID = userID
+
+	// 将数据插入数据库
+	result, err := database.InsertData("speaking_records", &part, "update")
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to update speaking records")
+		return
+	}
+
+	// 加入评分队列，等待老师评分
+	if err := utils.EnqueueGrading(models.GradingRecordSpeaking, part.ID, models.SkillSpeaking, userID); err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to enqueue grading")
+		return
+	}
+
+	utils.HandleResponse(c, http.StatusOK, gin.H{"id": result, "missing": missing}, "Success")
+}
@@ -8,7 +8,6 @@ import (
 	"github.com/Queen2333/ielts_test_backend/database"
 	"github.com/Queen2333/ielts_test_backend/models"
 	"github.com/Queen2333/ielts_test_backend/utils"
-	"github.com/davecgh/go-spew/spew"
 	"github.com/gin-gonic/gin"
 )
 
@@ -36,6 +35,9 @@ func TestingRecords(c *gin.Context) {
 		// 错误处理已在 ProcessRequest 中处理
 		return
 	}
+	// 只返回当前用户自己的记录，查看所有用户的记录使用 /admin/record 下的接口
+	userID, _ := utils.GetUserIDFromToken(c)
+	conditions["user_id"] = userID
 
 	
 	// 执行分页查询
@@ -71,11 +73,18 @@ func TestingRecordDetail(c *gin.Context) {
 		return
 	}
 
-	record, err := database.GetDataById("testing_records", id)
-    if err != nil {
-        utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get data by id")
-        return
-    }
+	record, _, ok := loadOwnedRecord(c, "testing_records", id)
+	if !ok {
+		return
+	}
+
+	// 旧记录没有保存综合分时，根据各科目成绩计算
+	if record["overall"] == nil {
+		sections, err := utils.ParseSections(record["sections"])
+		if err == nil && len(sections) > 0 {
+			record["overall"] = utils.OverallFromSections(sections, false)
+		}
+	}
 
 	// 返回查询结果
 	response := map[string]interface{}{
@@ -102,6 +111,17 @@ func AddTestingRecord(c *gin.Context) {
 		return
 	}
 
+	userID, err := utils.GetUserIDFromToken(c)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+		return
+	}
+	// 记录属于当前用户
+	part.UserID = userID
+	// 分数、各科目成绩和综合分只能由交卷、评分接口写入
+	part.Score, part.RawScores, part.Bands, part.Sections = nil, nil, nil, nil
+	part.WritingMetrics, part.OverallBand, part.Overall = nil, nil, nil
+
 	// 将数据插入数据库
 	result, err := database.InsertData("testing_records", &part, "create")
 	if err != nil {
@@ -130,16 +150,26 @@ func UpdateTestingRecord(c *gin.Context) {
 		return
 	}
 
-	// 将数据插入数据库
-	result, err := database.InsertData("testing_records", &part, "update")
-	fmt.Println(err, "err")
+	// 只能修改自己的记录，user_id 不能通过请求修改
+	if _, _, ok := loadOwnedRecord(c, "testing_records", part.ID); !ok {
+		return
+	}
+
+	// 只更新作答相关的字段，分数、各科目成绩和综合分只能由交卷、评分接口写入
+	err := database.UpdateColumns("testing_records", part.ID, map[string]interface{}{
+		"name":         part.Name,
+		"status":       part.Status,
+		"type":         part.Type,
+		"answers":      part.Answers,
+		"rest_seconds": part.RestSeconds,
+		"test_id":      part.TestID,
+	})
 	if err != nil {
 		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to update testing record")
 		return
 	}
 
-	// 返回插入后的数据
-	utils.HandleResponse(c, http.StatusOK, result, "Success")
+	utils.HandleResponse(c, http.StatusOK, nil, "Success")
 }
 
 // @Summary 删除测试做题记录
@@ -161,6 +191,10 @@ func DeleteTestingRecord(c *gin.Context) {
 		return
 	}
 
+	if _, _, ok := loadOwnedRecord(c, "testing_records", id); !ok {
+		return
+	}
+
 	// 执行删除操作
 	rowsAffected, err := database.DeleteData("testing_records", id)
 	if err != nil {
@@ -197,51 +231,83 @@ func SubmitTestingRecord(c *gin.Context) {
 		return
 	}
 
-	// 根据test_id获取套题列表
-	test, err := database.GetDataById("testing_list", part.TestID)
-    if err != nil {
-        utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get data by id")
-        return
-    }
-
-	// 获取part_list
-	listeningPartListInterface, ok := test["listening_ids"].([]interface{})
+	// 只能提交自己的记录
+	_, user, ok := loadOwnedRecord(c, "testing_records", part.ID)
 	if !ok {
-		utils.HandleResponse(c, http.StatusInternalServerError, "", "failed to parse listening_ids")
 		return
 	}
 
-	// 调用 GetPartDetails 获取part详细信息
-	listeningDetails, err := utils.GetPartDetails(listeningPartListInterface, "listening_part_list")
-	if err != nil {
-		utils.HandleResponse(c, http.StatusInternalServerError, "", "failed to get listening part detail")
+	// 根据test_id获取套题列表
+	test, ok := visibleContent(c, "testing_list", part.TestID)
+	if !ok {
 		return
 	}
-	spew.Dump(listeningPartListInterface, "listeningPartListInterface")
 
-	// 获取part_list
-	readingPartListInterface, ok := test["reading_ids"].([]interface{})
-	if !ok {
-		utils.HandleResponse(c, http.StatusInternalServerError, "", "failed to parse reading_ids")
-		return
+	// 按顺序获取套题中各科目的 part 详情
+	parts := utils.TestingParts{}
+	for skill, field := range map[string]string{
+		models.SkillListening: "listening_ids",
+		models.SkillReading:   "reading_ids",
+		models.SkillWriting:   "writing_ids",
+		models.SkillSpeaking:  "speaking_ids",
+	} {
+		details, _, err := utils.LoadVisibleParts(skill+"_part_list", utils.InterfaceToIntList(test[field]), user.ID)
+		if err != nil {
+			utils.HandleResponse(c, http.StatusInternalServerError, "", "failed to get "+skill+" part detail")
+			return
+		}
+		parts[skill] = details
 	}
 
-	readingDetails, err := utils.GetPartDetails(readingPartListInterface, "reading_part_list")
-	if err != nil {
-		utils.HandleResponse(c, http.StatusInternalServerError, "", "failed to get reading part detail")
+	// 把作答对应到各 part，多余或无法识别的作答直接拒绝
+	mapped := utils.MapTestingAnswers(part.Answers, parts)
+	if len(mapped.Problems) > 0 {
+		utils.HandleResponse(c, http.StatusBadRequest, mapped.Problems, "Invalid answers")
 		return
 	}
-	spew.Dump(readingPartListInterface, "readingPartListInterface")
 
-	listeningAnswers := part.Answers[:40] // 前40个答案
-	readingAnswers := part.Answers[40:80] // 后40个答案（从第41个到第80个）
+	listening := utils.GradeSection(models.SkillListening, parts[models.SkillListening], mapped.Answers[models.SkillListening])
+	reading := utils.GradeSection(models.SkillReading, parts[models.SkillReading], mapped.Answers[models.SkillReading])
+	writing := utils.WritingSection(parts[models.SkillWriting], mapped.Writing)
+	includeSpeaking := len(parts[models.SkillSpeaking]) > 0
+	var speaking models.SectionScore
+	if includeSpeaking {
+		var problems []string
+		speaking, problems = utils.SpeakingSection(parts[models.SkillSpeaking], mapped.Speaking)
+		if len(problems) > 0 {
+			utils.HandleResponse(c, http.StatusBadRequest, problems, "Invalid answers")
+			return
+		}
+	}
 
-	listeningScore := utils.CalculateScore(listeningDetails, listeningAnswers)
-	readingScore := utils.CalculateScore(readingDetails, readingAnswers)
+	module, _ := test["module"].(string)
+	listeningBand, err := utils.CalculateBand(models.SkillListening, "", listening.RawScore)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to convert band score")
+		return
+	}
+	readingBand, err := utils.CalculateBand(models.SkillReading, module, reading.RawScore)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to convert band score")
+		return
+	}
+	listening.Band, listening.Result.Band = &listeningBand, listeningBand
+	reading.Band, reading.Result.Band = &readingBand, readingBand
 
 	part.Status = "0"
 	part.Type = "3"
-	part.Score = []int{int(listeningScore), int(readingScore)}
+	part.Score = []int{int(listeningBand), int(readingBand)}
+	part.RawScores = []int{listening.RawScore, reading.RawScore}
+	part.Bands = []float64{listeningBand, readingBand}
+	part.Sections = []models.SectionScore{listening, reading, writing}
+	part.WritingMetrics = utils.BuildWritingMetrics(parts[models.SkillWriting], mapped.Writing, mapped.Seconds)
+	if includeSpeaking {
+		part.Sections = append(part.Sections, speaking)
+	}
+	// 写作、口语尚未评分，综合分先按听力、阅读暂定
+	overall := utils.OverallFromSections(part.Sections, includeSpeaking)
+	part.Overall = &overall
+	part.OverallBand = overall.Band
 	userID, err := utils.GetUserIDFromToken(c)
 	if err != nil {
 		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
@@ -258,7 +324,29 @@ func SubmitTestingRecord(c *gin.Context) {
 		return
 	}
 
-	// // 返回插入后的数据
-	utils.HandleResponse(c, http.StatusOK, result, "Success")
+	// 写作、口语有作答时加入评分队列
+	queue := map[string]bool{
+		models.SkillWriting:  writing.RawScore > 0,
+		models.SkillSpeaking: includeSpeaking && speaking.RawScore > 0,
+	}
+	for skill, enqueue := range queue {
+		if !enqueue {
+			continue
+		}
+		if err := utils.EnqueueGrading(models.GradingRecordTesting, part.ID, skill, userID); err != nil {
+			utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to enqueue grading")
+			return
+		}
+	}
+
+	// 写作有作答时在后台生成 AI 批改，失败不影响提交
+	if queue[models.SkillWriting] {
+		if _, err := utils.RequestAIWritingFeedback(models.GradingRecordTesting, part.ID, userID); err != nil {
+			// 记录到请求日志（middlewares.Logger）
+			c.Error(fmt.Errorf("AI 写作批改请求失败: %w", err))
+		}
+	}
 
+	// 返回各科目成绩（包含未作答的题号）
+	utils.HandleResponse(c, http.StatusOK, gin.H{"id": result, "sections": part.Sections, "overall": part.Overall, "writing_metrics": part.WritingMetrics}, "Success")
 }
\ No newline at end of file
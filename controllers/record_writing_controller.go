@@ -35,6 +35,9 @@ func WritingRecords(c *gin.Context) {
 		// 错误处理已在 ProcessRequest 中处理
 		return
 	}
+	// 只返回当前用户自己的记录，查看所有用户的记录使用 /admin/record 下的接口
+	userID, _ := utils.GetUserIDFromToken(c)
+	conditions["user_id"] = userID
 
 	
 	// 执行分页查询
@@ -70,11 +73,10 @@ func WritingRecordDetail(c *gin.Context) {
 		return
 	}
 
-	record, err := database.GetDataById("writing_records", id)
-    if err != nil {
-        utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get data by id")
-        return
-    }
+	record, _, ok := loadOwnedRecord(c, "writing_records", id)
+	if !ok {
+		return
+	}
 
 	// 返回查询结果
 	response := map[string]interface{}{
@@ -101,6 +103,16 @@ func AddWritingRecord(c *gin.Context) {
 		return
 	}
 
+	userID, err := utils.GetUserIDFromToken(c)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+		return
+	}
+	// 记录属于当前用户
+	part.UserID = userID
+	// 评分和字数统计只能由评分、交卷接口写入
+	part.Band, part.Metrics = nil, nil
+
 	// 将数据插入数据库
 	result, err := database.InsertData("writing_records", &part, "create")
 	if err != nil {
@@ -129,16 +141,26 @@ func UpdateWritingRecord(c *gin.Context) {
 		return
 	}
 
-	// 将数据插入数据库
-	result, err := database.InsertData("writing_records", &part, "update")
-	fmt.Println(err, "err")
+	// 只能修改自己的记录，user_id 不能通过请求修改
+	if _, _, ok := loadOwnedRecord(c, "writing_records", part.ID); !ok {
+		return
+	}
+
+	// 只更新作答相关的字段，评分和字数统计只能由评分、交卷接口写入
+	err := database.UpdateColumns("writing_records", part.ID, map[string]interface{}{
+		"name":         part.Name,
+		"status":       part.Status,
+		"type":         part.Type,
+		"answers":      part.Answers,
+		"rest_seconds": part.RestSeconds,
+		"test_id":      part.TestID,
+	})
 	if err != nil {
 		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to update writing record")
 		return
 	}
 
-	// 返回插入后的数据
-	utils.HandleResponse(c, http.StatusOK, result, "Success")
+	utils.HandleResponse(c, http.StatusOK, nil, "Success")
 }
 
 // @Summary 删除写作做题记录
@@ -160,6 +182,10 @@ func DeleteWritingRecord(c *gin.Context) {
 		return
 	}
 
+	if _, _, ok := loadOwnedRecord(c, "writing_records", id); !ok {
+		return
+	}
+
 	// 执行删除操作
 	rowsAffected, err := database.DeleteData("writing_records", id)
 	if err != nil {
@@ -175,4 +201,96 @@ func DeleteWritingRecord(c *gin.Context) {
 
 	// 返回成功响应
 	utils.HandleResponse(c, http.StatusOK, nil, "Success")
-}
\ No newline at end of file
+}
+
+// @Summary 提交写作做题记录
+// @Description 提交作文，作文需对应写作套题中的 part；返回每篇字数、是否字数不足和用时，提交后等待老师评分，同时在后台生成 AI 批改
+// @Tags Writing
+// @Accept json
+// @Produce json
+// @Param part body models.WritingRecordsItem true "写作做题记录内容"
+// @Success 200 {object} models.ResponseData{data=nil}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /record/writing/submit [post]
+func SubmitWritingRecord(c *gin.Context) {
+	var part models.WritingRecordsItem
+	if err := c.ShouldBindJSON(&part); err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid request")
+		return
+	}
+
+	// 只能提交自己的记录
+	_, user, ok := loadOwnedRecord(c, "writing_records", part.ID)
+	if !ok {
+		return
+	}
+
+	// 根据test_id获取写作套题
+	test, ok := visibleContent(c, "writing_list", part.TestID)
+	if !ok {
+		return
+	}
+
+	details, _, err := utils.LoadVisibleParts("writing_part_list", utils.InterfaceToIntList(test["part_list"]), user.ID)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "failed to get part detail")
+		return
+	}
+
+	// 作文必须对应套题中的 part，且每个 part 只能提交一篇
+	partIDs := make(map[int]bool)
+	for _, detail := range details {
+		id, _ := detail["id"].(int)
+		partIDs[id] = true
+	}
+	var problems []string
+	texts := make(map[int]string)
+	seconds := make(map[int]int)
+	for _, answer := range part.Answers {
+		if !partIDs[answer.PartID] {
+			problems = append(problems, fmt.Sprintf("writing part %d is not part of this test", answer.PartID))
+		} else if _, ok := texts[answer.PartID]; ok {
+			problems = append(problems, fmt.Sprintf("writing part %d answered more than once", answer.PartID))
+		}
+		texts[answer.PartID] = answer.Text
+		seconds[answer.PartID] = answer.SecondsSpent
+	}
+	if len(problems) > 0 {
+		utils.HandleResponse(c, http.StatusBadRequest, problems, "Invalid answers")
+		return
+	}
+
+	userID, err := utils.GetUserIDFromToken(c)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+		return
+	}
+	part.Status = "0"
+	part.Type = "3"
+	part.Band = nil
+	part.UserID = userID
+	// 计算字数、是否字数不足以及每篇用时
+	part.Metrics = utils.BuildWritingMetrics(details, texts, seconds)
+
+	// 将数据插入数据库
+	result, err := database.InsertData("writing_records", &part, "update")
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to update writing records")
+		return
+	}
+
+	// 加入评分队列，等待老师评分
+	if err := utils.EnqueueGrading(models.GradingRecordWriting, part.ID, models.SkillWriting, userID); err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to enqueue grading")
+		return
+	}
+
+	// AI 批改在后台执行，失败不影响提交
+	if _, err := utils.RequestAIWritingFeedback(models.GradingRecordWriting, part.ID, userID); err != nil {
+		// 记录到请求日志（middlewares.Logger）
+		c.Error(fmt.Errorf("AI 写作批改请求失败: %w", err))
+	}
+
+	utils.HandleResponse(c, http.StatusOK, gin.H{"id": result, "metrics": part.Metrics}, "Success")
+}
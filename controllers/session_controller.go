@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Queen2333/ielts_test_backend/auth"
+	"github.com/Queen2333/ielts_test_backend/models"
+	"github.com/Queen2333/ielts_test_backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// RefreshTokenRequest 刷新 token 请求
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// @Summary 刷新 token
+// @Description 使用 refresh token 换取新的 access token 和 refresh token，旧的 refresh token 立即失效。已使用过的 refresh token 再次使用时会注销整个会话
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param request body RefreshTokenRequest true "refresh token"
+// @Success 200 {object} models.ResponseData{data=models.TokenPair}
+// @Failure 400 {object} models.ResponseData
+// @Failure 401 {object} models.ResponseData
+// @Failure 500 {object} models.ResponseData
+// @Router /token/refresh [post]
+func RefreshTokenHandler(c *gin.Context) {
+	var request RefreshTokenRequest
+	if err := c.ShouldBindJSON(&request); err != nil || request.RefreshToken == "" {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid request")
+		return
+	}
+
+	session, refreshToken, err := utils.RotateRefreshToken(request.RefreshToken, c.ClientIP())
+	if err != nil {
+		if errors.Is(err, utils.ErrRefreshTokenInvalid) || errors.Is(err, utils.ErrRefreshTokenReused) {
+			utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+			return
+		}
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to refresh token")
+		return
+	}
+
+	// 角色等信息以数据库为准
+	user, err := checkUserByID(session.UserID)
+	if err != nil {
+		utils.RevokeSession(session.UserID, session.ID)
+		utils.HandleResponse(c, http.StatusUnauthorized, "", "User not found")
+		return
+	}
+	token, ttl, err := GenerateJWT(user, session.ID)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to generate token")
+		return
+	}
+
+	utils.HandleResponse(c, http.StatusOK, models.TokenPair{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(ttl.Seconds()),
+		User:         user,
+	}, "success")
+}
+
+// @Summary 退出登录
+// @Description 注销当前会话，会话下的 access token 和 refresh token 立即失效
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.ResponseData
+// @Failure 401 {object} models.ResponseData
+// @Failure 500 {object} models.ResponseData
+// @Router /logout [post]
+func LogoutHandler(c *gin.Context) {
+	principal, err := auth.PrincipalFrom(c)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+		return
+	}
+
+	if err := utils.RevokeSession(principal.ID, principal.SessionID); err != nil && !errors.Is(err, utils.ErrSessionNotFound) {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to log out")
+		return
+	}
+	utils.AuditAuth(models.AuditLogout, principal.Email, c.ClientIP(), principal.ID, "")
+	utils.HandleResponse(c, http.StatusOK, "", "success")
+}
+
+// @Summary 获取登录会话列表
+// @Description 获取当前用户所有有效的登录会话（设备、IP、最后访问时间），current 为当前请求使用的会话
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.ResponseData{data=[]models.SessionItem}
+// @Failure 401 {object} models.ResponseData
+// @Failure 500 {object} models.ResponseData
+// @Router /sessions [get]
+func SessionList(c *gin.Context) {
+	principal, err := auth.PrincipalFrom(c)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+		return
+	}
+
+	sessions, err := utils.ListSessions(principal.ID, principal.SessionID)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get sessions")
+		return
+	}
+	utils.HandleResponse(c, http.StatusOK, sessions, "Success")
+}
+
+// @Summary 注销登录会话
+// @Description 注销当前用户的某个会话（如在其他设备上退出登录）
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param id path string true "会话ID"
+// @Success 200 {object} models.ResponseData
+// @Failure 401 {object} models.ResponseData
+// @Failure 404 {object} models.ResponseData
+// @Failure 500 {object} models.ResponseData
+// @Router /sessions/{id} [delete]
+func RevokeSessionHandler(c *gin.Context) {
+	principal, err := auth.PrincipalFrom(c)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+		return
+	}
+
+	id := c.Param("id")
+	if err := utils.RevokeSession(principal.ID, id); err != nil {
+		if errors.Is(err, utils.ErrSessionNotFound) {
+			utils.HandleResponse(c, http.StatusNotFound, "", "Session not found")
+			return
+		}
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to revoke session")
+		return
+	}
+	utils.AuditAuth(models.AuditSessionRevoked, principal.Email, c.ClientIP(), principal.ID, "session "+id)
+	utils.HandleResponse(c, http.StatusOK, "", "success")
+}
+
+// @Summary 注销其他登录会话
+// @Description 注销当前用户除当前会话以外的所有会话
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.ResponseData
+// @Failure 401 {object} models.ResponseData
+// @Failure 500 {object} models.ResponseData
+// @Router /sessions [delete]
+func RevokeOtherSessions(c *gin.Context) {
+	principal, err := auth.PrincipalFrom(c)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+		return
+	}
+
+	count, err := utils.RevokeUserSessions(principal.ID, principal.SessionID)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to revoke sessions")
+		return
+	}
+	utils.AuditAuth(models.AuditSessionRevoked, principal.Email, c.ClientIP(), principal.ID, "all other sessions")
+	utils.HandleResponse(c, http.StatusOK, gin.H{"revoked": count}, "success")
+}
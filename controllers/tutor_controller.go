@@ -0,0 +1,253 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Queen2333/ielts_test_backend/database"
+	"github.com/Queen2333/ielts_test_backend/models"
+	"github.com/Queen2333/ielts_test_backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// TutorMessageRequest 提问内容
+type TutorMessageRequest struct {
+	Content string `json:"content"`
+}
+
+// tutorMessageMaxLength 单条提问的最大字符数
+const tutorMessageMaxLength = 2000
+
+// @Summary 获取 AI 辅导对话列表
+// @Description 获取当前用户的所有对话，最近更新的在前
+// @Tags AI
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.ResponseData{data=[]models.TutorConversationItem}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /ai/tutor/conversation/list [get]
+func TutorConversationList(c *gin.Context) {
+	userID, err := utils.GetUserIDFromToken(c)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+		return
+	}
+
+	conversations, err := utils.ListTutorConversations(userID)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get conversations")
+		return
+	}
+	utils.HandleResponse(c, http.StatusOK, conversations, "Success")
+}
+
+// @Summary 获取 AI 辅导对话详情
+// @Description 获取对话及其所有消息
+// @Tags AI
+// @Accept json
+// @Produce json
+// @Param id path int true "对话ID"
+// @Success 200 {object} models.ResponseData{data=nil}
+// @Failure 404 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /ai/tutor/conversation/detail/{id} [get]
+func TutorConversationDetail(c *gin.Context) {
+	conversation, ok := loadTutorConversation(c)
+	if !ok {
+		return
+	}
+
+	messages, err := utils.TutorMessages(conversation.ID)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get messages")
+		return
+	}
+	utils.HandleResponse(c, http.StatusOK, gin.H{"conversation": conversation, "messages": messages}, "Success")
+}
+
+// @Summary 创建 AI 辅导对话
+// @Description 创建关联某个 part 的对话；传入做题记录和题号时会带上学生的作答和正确答案（如“为什么第 14 题是 FALSE”）
+// @Tags AI
+// @Accept json
+// @Produce json
+// @Param conversation body models.TutorConversationItem true "skill、part_id，可选 record_type、record_id、question_no"
+// @Success 200 {object} models.ResponseData{data=models.TutorConversationItem}
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 404 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /ai/tutor/conversation/add [post]
+func AddTutorConversation(c *gin.Context) {
+	userID, err := utils.GetUserIDFromToken(c)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+		return
+	}
+
+	var conversation models.TutorConversationItem
+	if err := c.ShouldBindJSON(&conversation); err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid request")
+		return
+	}
+	conversation.ID = 0
+	conversation.UserID = userID
+	conversation.Title = strings.TrimSpace(conversation.Title)
+
+	if err := utils.PrepareTutorConversation(userID, &conversation); err != nil {
+		if errors.Is(err, utils.ErrTutorNotFound) {
+			utils.HandleResponse(c, http.StatusNotFound, "", "Record not found")
+			return
+		}
+		utils.HandleResponse(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	conversation.CreatedAt = now
+	conversation.UpdatedAt = now
+	id, err := database.InsertData("tutor_conversations", &conversation, "create")
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to create conversation")
+		return
+	}
+	conversation.ID = id
+	conversation.Context = ""
+	utils.HandleResponse(c, http.StatusOK, conversation, "Success")
+}
+
+// @Summary 删除 AI 辅导对话
+// @Description 删除对话及其所有消息
+// @Tags AI
+// @Accept json
+// @Produce json
+// @Param id path int true "对话ID"
+// @Success 200 {object} models.ResponseData{data=nil}
+// @Failure 404 {object} models.ResponseData{data=nil}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /ai/tutor/conversation/delete/{id} [delete]
+func DeleteTutorConversation(c *gin.Context) {
+	conversation, ok := loadTutorConversation(c)
+	if !ok {
+		return
+	}
+
+	if err := utils.DeleteTutorConversation(conversation.ID); err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to delete conversation")
+		return
+	}
+	utils.HandleResponse(c, http.StatusOK, "", "Deleted successfully")
+}
+
+// @Summary AI 辅导提问
+// @Description 在对话中提问，回答通过 Server-Sent Events 流式返回：delta 事件为新生成的内容，done 事件为保存后的完整回答，error 事件为错误信息；每个用户每天的提问次数有限
+// @Tags AI
+// @Accept json
+// @Produce text/event-stream
+// @Param id path int true "对话ID"
+// @Param message body TutorMessageRequest true "提问内容"
+// @Success 200 {string} string "event-stream"
+// @Failure 400 {object} models.ResponseData{data=nil}
+// @Failure 404 {object} models.ResponseData{data=nil}
+// @Failure 429 {object} models.ResponseData{data=models.TutorQuota}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /ai/tutor/conversation/chat/{id} [post]
+func TutorChat(c *gin.Context) {
+	conversation, ok := loadTutorConversation(c)
+	if !ok {
+		return
+	}
+
+	var request TutorMessageRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid request")
+		return
+	}
+	request.Content = strings.TrimSpace(request.Content)
+	if request.Content == "" || len([]rune(request.Content)) > tutorMessageMaxLength {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Message must be between 1 and 2000 characters")
+		return
+	}
+
+	if err := utils.ReserveTutorQuota(conversation.UserID); err != nil {
+		if errors.Is(err, utils.ErrTutorQuotaExceeded) {
+			quota, _ := utils.GetTutorQuota(conversation.UserID)
+			utils.HandleResponse(c, http.StatusTooManyRequests, quota, err.Error())
+			return
+		}
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to check quota")
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	reply, err := utils.StreamTutorReply(c.Request.Context(), conversation, request.Content, func(delta string) error {
+		if err := c.Request.Context().Err(); err != nil {
+			return err
+		}
+		c.SSEvent("delta", gin.H{"content": delta})
+		c.Writer.Flush()
+		return nil
+	})
+	if reply == nil {
+		// 没有生成回答时退回提问次数
+		utils.ReleaseTutorQuota(conversation.UserID)
+	}
+	if err != nil {
+		c.SSEvent("error", gin.H{"message": "Failed to get reply"})
+		c.Writer.Flush()
+		return
+	}
+	c.SSEvent("done", reply)
+	c.Writer.Flush()
+}
+
+// @Summary 获取 AI 辅导提问次数
+// @Description 获取当前用户当天已用和剩余的提问次数
+// @Tags AI
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.ResponseData{data=models.TutorQuota}
+// @Failure 500 {object} models.ResponseData{data=nil}
+// @Router /ai/tutor/quota [get]
+func TutorQuota(c *gin.Context) {
+	userID, err := utils.GetUserIDFromToken(c)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+		return
+	}
+
+	quota, err := utils.GetTutorQuota(userID)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to get quota")
+		return
+	}
+	utils.HandleResponse(c, http.StatusOK, quota, "Success")
+}
+
+// loadTutorConversation 获取路径中的对话，只能访问自己的对话
+func loadTutorConversation(c *gin.Context) (*models.TutorConversationItem, bool) {
+	userID, err := utils.GetUserIDFromToken(c)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+		return nil, false
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleResponse(c, http.StatusBadRequest, "", "Invalid ID")
+		return nil, false
+	}
+
+	conversation, err := utils.FindTutorConversation(userID, id)
+	if err != nil {
+		utils.HandleResponse(c, http.StatusNotFound, "", "Conversation not found")
+		return nil, false
+	}
+	return conversation, true
+}
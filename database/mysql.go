@@ -41,6 +41,9 @@ func IsNoRowsError(err error) bool {
 	return errors.Is(err, sql.ErrNoRows)
 }
 
+// VisibleToCondition 分页查询条件：值为用户 ID，只返回非用户题目（type 3 以外）和该用户自己的题目
+const VisibleToCondition = "visible_to"
+
 // PaginationQuery 封装分页查询列表的方法
 func PaginationQuery(tableName string, pageNo, pageLimit int, conditions map[string]interface{}) ([]map[string]interface{}, int, error) {
 
@@ -52,6 +55,9 @@ func PaginationQuery(tableName string, pageNo, pageLimit int, conditions map[str
 			conditionsStr += fmt.Sprintf(" AND %s LIKE ?", key)
 			// 将 name 字段的值包裹在 % 符号中，实现模糊查询
 			args = append(args, fmt.Sprintf("%%%s%%", value))
+		} else if key == VisibleToCondition {
+			conditionsStr += " AND (type IS NULL OR type <> 3 OR user_id = ?)"
+			args = append(args, value)
 		} else {
 			conditionsStr += fmt.Sprintf(" AND %s = ?", key)
 			args = append(args, value)
@@ -246,7 +252,10 @@ func InsertData(tableName string, data interface{}, handleType string) (int, err
         columns = append(columns, column)
         placeholders = append(placeholders, "?")
         value := v.Field(i).Interface()
-        if isStructOrSlice(v.Field(i)) {
+        if v.Field(i).Kind() == reflect.Ptr && v.Field(i).IsNil() {
+            // 空指针字段写入 NULL
+            values = append(values, nil)
+        } else if isStructOrSlice(v.Field(i)) {
             jsonValue, _ := json.Marshal(value)
             values = append(values, string(jsonValue))
         } else {
@@ -351,8 +360,10 @@ func checkIDExists(tableName string, idValue interface{}) (bool, error) {
 
 func isStructOrSlice(value reflect.Value) bool {
 	switch value.Kind() {
-	case reflect.Struct, reflect.Slice:
+	case reflect.Struct, reflect.Slice, reflect.Map:
 		return true
+	case reflect.Ptr:
+		return value.Elem().Kind() == reflect.Struct
 	default:
 		return false
 	}
@@ -421,7 +432,7 @@ func GetDataById(tableName string, id int) (map[string]interface{}, error) {
     err = row.Scan(scanArgs...)
     if err != nil {
         if err == sql.ErrNoRows {
-            return nil, fmt.Errorf("no data found with id: %d", id)
+            return nil, fmt.Errorf("no data found with id: %d: %w", id, err)
         }
         return nil, err
     }
@@ -433,4 +444,34 @@ func GetDataById(tableName string, id int) (map[string]interface{}, error) {
     }
 
     return result, nil
-}
\ No newline at end of file
+}
+
+// UpdateColumns 根据ID更新指定字段，slice/map/struct 类型的值会序列化为 JSON
+func UpdateColumns(tableName string, id interface{}, fields map[string]interface{}) error {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	var setClauses []string
+	var values []interface{}
+	for column, value := range fields {
+		setClauses = append(setClauses, fmt.Sprintf("%s=?", column))
+		switch reflect.ValueOf(value).Kind() {
+		case reflect.Struct, reflect.Slice, reflect.Map:
+			jsonValue, err := json.Marshal(value)
+			if err != nil {
+				return fmt.Errorf("failed to marshal column %s: %w", column, err)
+			}
+			values = append(values, string(jsonValue))
+		default:
+			values = append(values, value)
+		}
+	}
+	values = append(values, id)
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE id=?", tableName, strings.Join(setClauses, ","))
+	if _, err := db.Exec(query, values...); err != nil {
+		return fmt.Errorf("failed to execute query: %v, error: %w", query, err)
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// 任务状态
+const (
+	StatusQueued    = "queued"    // 等待执行
+	StatusScheduled = "scheduled" // 延时执行或等待重试
+	StatusRunning   = "running"   // 执行中
+	StatusSucceeded = "succeeded" // 成功
+	StatusDead      = "dead"      // 重试次数用完，已放入死信列表
+)
+
+// Redis 中的键
+const (
+	keyJobPrefix  = "jobs:job:"       // 任务详情
+	keyReady      = "jobs:ready"      // 待执行队列
+	keyScheduled  = "jobs:scheduled"  // 延时任务，score 为执行时间
+	keyDead       = "jobs:dead"       // 死信列表
+	keyProcessing = "jobs:processing" // 执行中的任务，worker 崩溃后由 reaper 放回
+	keyLeases     = "jobs:leases"     // 执行中任务的租约，score 为超时时间
+)
+
+// deadListMax 死信列表最多保留的任务数
+const deadListMax = 1000
+
+// DefaultMaxAttempts 默认最多执行次数（包括第一次）
+const DefaultMaxAttempts = 5
+
+// jobTTL 任务详情的保留时间
+const jobTTL = 7 * 24 * time.Hour
+
+// ErrJobNotFound 任务不存在或已过期
+var ErrJobNotFound = errors.New("job not found")
+
+// Job 后台任务
+type Job struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	UserID      string          `json:"user_id,omitempty"` // 创建任务的用户，用于查询状态时校验权限
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	LastError   string          `json:"last_error,omitempty"`
+	RunAt       time.Time       `json:"run_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+}
+
+// Decode 解析任务参数
+func (j *Job) Decode(out interface{}) error {
+	return json.Unmarshal(j.Payload, out)
+}
+
+// Option 任务选项
+type Option func(*Job)
+
+// WithDelay 延时执行
+func WithDelay(delay time.Duration) Option {
+	return func(j *Job) { j.RunAt = time.Now().Add(delay) }
+}
+
+// WithRunAt 在指定时间执行
+func WithRunAt(runAt time.Time) Option {
+	return func(j *Job) { j.RunAt = runAt }
+}
+
+// WithMaxAttempts 设置最多执行次数
+func WithMaxAttempts(attempts int) Option {
+	return func(j *Job) {
+		if attempts > 0 {
+			j.MaxAttempts = attempts
+		}
+	}
+}
+
+// WithUserID 记录创建任务的用户
+func WithUserID(userID string) Option {
+	return func(j *Job) { j.UserID = userID }
+}
+
+var clientFunc func() (*redis.Client, error)
+
+// UseClient 设置获取 Redis 连接的方法（由 utils 在初始化时设置，使用同一个连接）
+func UseClient(fn func() (*redis.Client, error)) {
+	clientFunc = fn
+}
+
+func client() (*redis.Client, error) {
+	if clientFunc == nil {
+		return nil, fmt.Errorf("jobs: redis client is not configured")
+	}
+	return clientFunc()
+}
+
+// Enqueue 创建任务；指定了执行时间时放入延时队列
+func Enqueue(jobType string, payload interface{}, opts ...Option) (*Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	job := &Job{
+		ID:          uuid.NewString(),
+		Type:        jobType,
+		Payload:     data,
+		Status:      StatusQueued,
+		MaxAttempts: DefaultMaxAttempts,
+		RunAt:       now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	for _, opt := range opts {
+		opt(job)
+	}
+
+	rdb, err := client()
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	if job.RunAt.After(now) {
+		job.Status = StatusScheduled
+	}
+	if err := save(ctx, rdb, job); err != nil {
+		return nil, err
+	}
+	if job.Status == StatusScheduled {
+		err = rdb.ZAdd(ctx, keyScheduled, redis.Z{Score: float64(job.RunAt.Unix()), Member: job.ID}).Err()
+	} else {
+		err = rdb.LPush(ctx, keyReady, job.ID).Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Get 获取任务状态
+func Get(id string) (*Job, error) {
+	rdb, err := client()
+	if err != nil {
+		return nil, err
+	}
+	return load(context.Background(), rdb, id)
+}
+
+// DeadJobs 死信列表中的任务，最新的在前
+func DeadJobs(limit int64) ([]*Job, error) {
+	rdb, err := client()
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	ids, err := rdb.LRange(ctx, keyDead, 0, limit-1).Result()
+	if err != nil {
+		return nil, err
+	}
+	var list []*Job
+	for _, id := range ids {
+		if job, err := load(ctx, rdb, id); err == nil {
+			list = append(list, job)
+		}
+	}
+	return list, nil
+}
+
+func save(ctx context.Context, rdb *redis.Client, job *Job) error {
+	job.UpdatedAt = time.Now()
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return rdb.Set(ctx, keyJobPrefix+job.ID, data, jobTTL).Err()
+}
+
+func load(ctx context.Context, rdb *redis.Client, id string) (*Job, error) {
+	data, err := rdb.Get(ctx, keyJobPrefix+id).Bytes()
+	if err == redis.Nil {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var job Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
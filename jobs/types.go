@@ -0,0 +1,32 @@
+package jobs
+
+// 任务类型
+const (
+	TypeSendEmail         = "send_email"          // 发送发件箱中的邮件
+	TypeAIWritingFeedback = "ai_writing_feedback" // AI 写作批改
+	TypeGradingReminder   = "grading_reminder"    // 评分任务到期提醒
+	TypeCalibrationAssign = "calibration_assign"  // 给老师分配下一篇校准作文
+)
+
+// SendEmailPayload 发送发件箱中的邮件
+type SendEmailPayload struct {
+	OutboxID int `json:"outbox_id"`
+}
+
+// AIWritingFeedbackPayload AI 写作批改
+type AIWritingFeedbackPayload struct {
+	FeedbackID int    `json:"feedback_id"`
+	RecordType string `json:"record_type"`
+	RecordID   int    `json:"record_id"`
+}
+
+// GradingReminderPayload 评分任务到期提醒，执行时队列项仍分配给该老师且未完成才发送
+type GradingReminderPayload struct {
+	QueueID  int    `json:"queue_id"`
+	GraderID string `json:"grader_id"`
+}
+
+// CalibrationAssignPayload 给老师分配下一篇校准作文
+type CalibrationAssignPayload struct {
+	GraderID string `json:"grader_id"`
+}
@@ -0,0 +1,287 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+// HandlerFunc 任务处理函数，返回错误时按指数退避重试
+type HandlerFunc func(ctx context.Context, job *Job) error
+
+// JobTimeout 单个任务的最长执行时间
+const JobTimeout = 10 * time.Minute
+
+// visibilityTimeout 任务租约时长，超时仍未完成的任务视为 worker 已崩溃，由 reaper 按失败处理
+const visibilityTimeout = JobTimeout + time.Minute
+
+// reapInterval 检查超时任务的间隔
+const reapInterval = 30 * time.Second
+
+// errWorkerLost 执行任务的 worker 在任务完成前退出
+var errWorkerLost = errors.New("worker lost before the job finished")
+
+const (
+	backoffBase = 5 * time.Second
+	backoffMax  = 30 * time.Minute
+)
+
+var (
+	handlersMu sync.RWMutex
+	handlers   = make(map[string]HandlerFunc)
+)
+
+// Register 注册任务处理函数
+func Register(jobType string, handler HandlerFunc) {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	handlers[jobType] = handler
+}
+
+// Handle 注册带类型参数的任务处理函数，参数解析失败的任务不会重试
+func Handle[T any](jobType string, handler func(ctx context.Context, payload T) error) {
+	Register(jobType, func(ctx context.Context, job *Job) error {
+		var payload T
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return Permanent(fmt.Errorf("invalid payload: %w", err))
+		}
+		return handler(ctx, payload)
+	})
+}
+
+// permanentError 不需要重试的错误
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent 标记错误不需要重试，任务直接进入死信列表
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// Backoff 第 attempt 次失败后的等待时间：5s、10s、20s……最多 30 分钟
+func Backoff(attempt int) time.Duration {
+	delay := backoffBase
+	for i := 1; i < attempt && delay < backoffMax; i++ {
+		delay *= 2
+	}
+	if delay > backoffMax {
+		delay = backoffMax
+	}
+	return delay
+}
+
+// Run 启动 concurrency 个 worker 和延时任务调度，ctx 取消后等待执行中的任务完成再返回
+func Run(ctx context.Context, concurrency int) error {
+	rdb, err := client()
+	if err != nil {
+		return err
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(concurrency + 2)
+	go func() {
+		defer wg.Done()
+		schedule(ctx, rdb)
+	}()
+	go func() {
+		defer wg.Done()
+		reap(ctx, rdb)
+	}()
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			work(ctx, rdb)
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+// schedule 每秒把到期的延时任务移到待执行队列
+// ZREM 成功的进程才会入队，多个 worker 进程同时运行时任务不会重复执行
+func schedule(ctx context.Context, rdb *redis.Client) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ids, err := rdb.ZRangeByScore(ctx, keyScheduled, &redis.ZRangeBy{
+			Min: "-inf",
+			Max: strconv.FormatInt(time.Now().Unix(), 10),
+		}).Result()
+		if err != nil {
+			continue
+		}
+		for _, id := range ids {
+			removed, err := rdb.ZRem(ctx, keyScheduled, id).Result()
+			if err != nil || removed == 0 {
+				continue
+			}
+			if job, err := load(ctx, rdb, id); err == nil {
+				job.Status = StatusQueued
+				save(ctx, rdb, job)
+			}
+			rdb.LPush(ctx, keyReady, id)
+		}
+	}
+}
+
+// work 从待执行队列取出任务，同时原子地放入执行中列表并设置租约
+// worker 崩溃时任务仍在执行中列表里，租约到期后由 reap 放回
+func work(ctx context.Context, rdb *redis.Client) {
+	for ctx.Err() == nil {
+		id, err := rdb.BLMove(ctx, keyReady, keyProcessing, "RIGHT", "LEFT", 5*time.Second).Result()
+		if err != nil {
+			continue
+		}
+		lease(context.Background(), rdb, id)
+		process(rdb, id)
+	}
+}
+
+// lease 设置或延长任务的租约
+func lease(ctx context.Context, rdb *redis.Client, id string) {
+	deadline := time.Now().Add(visibilityTimeout)
+	rdb.ZAdd(ctx, keyLeases, redis.Z{Score: float64(deadline.Unix()), Member: id})
+}
+
+// release 任务处理完成后移出执行中列表
+func release(ctx context.Context, rdb *redis.Client, id string) {
+	rdb.LRem(ctx, keyProcessing, 1, id)
+	rdb.ZRem(ctx, keyLeases, id)
+}
+
+// process 执行任务；失败时按退避时间放回延时队列，重试次数用完放入死信列表
+func process(rdb *redis.Client, id string) {
+	ctx := context.Background()
+	defer release(ctx, rdb, id)
+
+	job, err := load(ctx, rdb, id)
+	if err != nil {
+		return
+	}
+
+	handlersMu.RLock()
+	handler, ok := handlers[job.Type]
+	handlersMu.RUnlock()
+
+	job.Status = StatusRunning
+	job.Attempts++
+	save(ctx, rdb, job)
+
+	if !ok {
+		err = Permanent(fmt.Errorf("no handler registered for job type %q", job.Type))
+	} else {
+		err = runHandler(handler, job)
+	}
+
+	if err == nil {
+		now := time.Now()
+		job.Status = StatusSucceeded
+		job.LastError = ""
+		job.FinishedAt = &now
+		save(ctx, rdb, job)
+		return
+	}
+	fail(ctx, rdb, job, err)
+}
+
+// fail 记录失败：可重试的放回延时队列，否则放入死信列表
+func fail(ctx context.Context, rdb *redis.Client, job *Job, err error) {
+	now := time.Now()
+	job.LastError = err.Error()
+	var permanent permanentError
+	if errors.As(err, &permanent) || job.Attempts >= job.MaxAttempts {
+		job.Status = StatusDead
+		job.FinishedAt = &now
+		save(ctx, rdb, job)
+		rdb.LPush(ctx, keyDead, job.ID)
+		rdb.LTrim(ctx, keyDead, 0, deadListMax-1)
+		logrus.WithFields(logrus.Fields{"job_id": job.ID, "job_type": job.Type}).WithError(err).Error("任务执行失败，已放入死信列表")
+		return
+	}
+
+	job.Status = StatusScheduled
+	job.RunAt = now.Add(Backoff(job.Attempts))
+	save(ctx, rdb, job)
+	rdb.ZAdd(ctx, keyScheduled, redis.Z{Score: float64(job.RunAt.Unix()), Member: job.ID})
+}
+
+// reap 定期检查执行中列表，把租约到期的任务按失败处理（重试或放入死信列表）
+// 没有租约的任务（取出后还没来得及设置租约时崩溃）补上租约，下次到期后处理
+func reap(ctx context.Context, rdb *redis.Client) {
+	ticker := time.NewTicker(reapInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ids, err := rdb.LRange(ctx, keyProcessing, 0, -1).Result()
+		if err != nil {
+			continue
+		}
+		now := time.Now().Unix()
+		for _, id := range ids {
+			deadline, err := rdb.ZScore(ctx, keyLeases, id).Result()
+			if err == redis.Nil {
+				lease(ctx, rdb, id)
+				continue
+			}
+			if err != nil || int64(deadline) > now {
+				continue
+			}
+			// LREM 成功的进程才会处理，多个 worker 进程同时运行时任务不会重复放回
+			removed, err := rdb.LRem(ctx, keyProcessing, 1, id).Result()
+			if err != nil || removed == 0 {
+				continue
+			}
+			rdb.ZRem(ctx, keyLeases, id)
+			job, err := load(ctx, rdb, id)
+			if err != nil {
+				continue
+			}
+			switch job.Status {
+			case StatusQueued:
+				// 取出后还没开始执行，直接放回待执行队列
+				rdb.LPush(ctx, keyReady, id)
+				continue
+			case StatusRunning:
+			default:
+				continue
+			}
+			logrus.WithFields(logrus.Fields{"job_id": job.ID, "job_type": job.Type}).Warn("任务执行超时，worker 可能已退出")
+			fail(ctx, rdb, job, errWorkerLost)
+		}
+	}
+}
+
+// runHandler 执行处理函数，panic 按失败处理
+func runHandler(handler HandlerFunc, job *Job) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), JobTimeout)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
@@ -0,0 +1,168 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, 5 * time.Second},
+		{1, 5 * time.Second},
+		{2, 10 * time.Second},
+		{3, 20 * time.Second},
+		{5, 80 * time.Second},
+		{9, 1280 * time.Second},
+		{10, backoffMax},
+		{11, backoffMax},
+		{100, backoffMax},
+	}
+	for _, tt := range tests {
+		if got := Backoff(tt.attempt); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestPermanent(t *testing.T) {
+	cause := errors.New("bad input")
+	err := Permanent(cause)
+
+	var permanent permanentError
+	if !errors.As(err, &permanent) {
+		t.Errorf("errors.As(Permanent(err), permanentError) = false, want true")
+	}
+	if !errors.Is(err, cause) {
+		t.Errorf("errors.Is(Permanent(err), err) = false, want true")
+	}
+	if err.Error() != cause.Error() {
+		t.Errorf("Permanent(err).Error() = %q, want %q", err.Error(), cause.Error())
+	}
+	if errors.As(cause, &permanent) {
+		t.Errorf("errors.As(plain error, permanentError) = true, want false")
+	}
+}
+
+type testPayload struct {
+	RecordID int `json:"record_id"`
+}
+
+func TestHandle(t *testing.T) {
+	const jobType = "test:handle"
+	handlerErr := errors.New("handler failed")
+	var got testPayload
+	Handle(jobType, func(ctx context.Context, payload testPayload) error {
+		got = payload
+		if payload.RecordID < 0 {
+			return handlerErr
+		}
+		return nil
+	})
+	t.Cleanup(func() {
+		handlersMu.Lock()
+		delete(handlers, jobType)
+		handlersMu.Unlock()
+	})
+
+	handlersMu.RLock()
+	handler := handlers[jobType]
+	handlersMu.RUnlock()
+
+	tests := []struct {
+		name          string
+		payload       string
+		wantErr       error
+		wantPermanent bool
+		wantRecordID  int
+	}{
+		{"valid payload", `{"record_id": 42}`, nil, false, 42},
+		{"handler error is retried", `{"record_id": -1}`, handlerErr, false, -1},
+		{"wrong field type", `{"record_id": "42"}`, nil, true, 0},
+		{"not json", `record 42`, nil, true, 0},
+		{"array instead of object", `[42]`, nil, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = testPayload{}
+			err := handler(context.Background(), &Job{Type: jobType, Payload: json.RawMessage(tt.payload)})
+
+			var permanent permanentError
+			if isPermanent := errors.As(err, &permanent); isPermanent != tt.wantPermanent {
+				t.Fatalf("handler() error = %v, permanent = %v, want permanent %v", err, isPermanent, tt.wantPermanent)
+			}
+			if tt.wantPermanent {
+				if !strings.Contains(err.Error(), "invalid payload") {
+					t.Errorf("handler() error = %q, want invalid payload", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("handler() error = %v, want %v", err, tt.wantErr)
+			}
+			if got.RecordID != tt.wantRecordID {
+				t.Errorf("handler() payload record_id = %d, want %d", got.RecordID, tt.wantRecordID)
+			}
+		})
+	}
+}
+
+func TestRunHandler(t *testing.T) {
+	handlerErr := errors.New("handler failed")
+	tests := []struct {
+		name    string
+		handler HandlerFunc
+		wantErr string
+	}{
+		{"success", func(ctx context.Context, job *Job) error { return nil }, ""},
+		{"error", func(ctx context.Context, job *Job) error { return handlerErr }, handlerErr.Error()},
+		{"panic recovered", func(ctx context.Context, job *Job) error { panic("boom") }, "panic: boom"},
+		{"context has deadline", func(ctx context.Context, job *Job) error {
+			if _, ok := ctx.Deadline(); !ok {
+				return errors.New("no deadline")
+			}
+			return nil
+		}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := runHandler(tt.handler, &Job{ID: "job-1"})
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if got != tt.wantErr {
+				t.Errorf("runHandler() error = %q, want %q", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJobOptions(t *testing.T) {
+	job := &Job{MaxAttempts: DefaultMaxAttempts}
+	WithMaxAttempts(0)(job)
+	if job.MaxAttempts != DefaultMaxAttempts {
+		t.Errorf("WithMaxAttempts(0) changed MaxAttempts to %d", job.MaxAttempts)
+	}
+	WithMaxAttempts(3)(job)
+	if job.MaxAttempts != 3 {
+		t.Errorf("WithMaxAttempts(3) = %d, want 3", job.MaxAttempts)
+	}
+
+	runAt := time.Date(2026, 10, 20, 9, 0, 0, 0, time.UTC)
+	WithRunAt(runAt)(job)
+	if !job.RunAt.Equal(runAt) {
+		t.Errorf("WithRunAt() = %v, want %v", job.RunAt, runAt)
+	}
+	before := time.Now()
+	WithDelay(time.Minute)(job)
+	if job.RunAt.Before(before.Add(time.Minute)) {
+		t.Errorf("WithDelay(1m) = %v, want at least %v", job.RunAt, before.Add(time.Minute))
+	}
+}
@@ -0,0 +1,103 @@
+package mailer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultSMTPPort 没有配置 SMTP_PORT 时使用
+const defaultSMTPPort = 25
+
+var (
+	transport   Transport
+	transportMu sync.RWMutex
+	from        string
+)
+
+// ErrNotConfigured 启动时没有调用 Init
+var ErrNotConfigured = errors.New("mailer is not configured")
+
+// 通过环境变量配置发送方式
+//
+//	MAIL_TRANSPORT  smtp / file / memory，默认 smtp
+//	MAIL_FROM       发件人，默认为 SMTP 用户名
+//	MAIL_DIR        file 方式的保存目录，默认 tmp/mail
+//	SMTP_HOST、SMTP_USERNAME、SMTP_PASSWORD  smtp 方式必须配置
+//	SMTP_PORT       默认 25
+func newTransportFromEnv() (Transport, string, error) {
+	username := os.Getenv("SMTP_USERNAME")
+	sender := envOr("MAIL_FROM", username)
+
+	switch envOr("MAIL_TRANSPORT", "smtp") {
+	case "smtp":
+		var missing []string
+		for _, name := range []string{"SMTP_HOST", "SMTP_USERNAME", "SMTP_PASSWORD"} {
+			if os.Getenv(name) == "" {
+				missing = append(missing, name)
+			}
+		}
+		if len(missing) > 0 {
+			return nil, "", fmt.Errorf("smtp mail transport requires %s", strings.Join(missing, ", "))
+		}
+		port := defaultSMTPPort
+		if value := os.Getenv("SMTP_PORT"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed <= 0 {
+				return nil, "", fmt.Errorf("invalid SMTP_PORT %q", value)
+			}
+			port = parsed
+		}
+		return &SMTPTransport{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     port,
+			Username: username,
+			Password: os.Getenv("SMTP_PASSWORD"),
+		}, sender, nil
+	case "file":
+		return &FileTransport{Dir: envOr("MAIL_DIR", "tmp/mail")}, sender, nil
+	case "memory":
+		return &MemoryTransport{}, sender, nil
+	}
+	return nil, "", fmt.Errorf("unknown mail transport %q", os.Getenv("MAIL_TRANSPORT"))
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// Init 根据环境变量创建发送方式，启动时调用，配置缺失时返回错误
+func Init() error {
+	t, sender, err := newTransportFromEnv()
+	if err != nil {
+		return err
+	}
+	SetTransport(t, sender)
+	return nil
+}
+
+// DefaultTransport 返回 Init 创建的发送方式，没有调用 Init 时返回 nil
+func DefaultTransport() Transport {
+	transportMu.RLock()
+	defer transportMu.RUnlock()
+	return transport
+}
+
+// SetTransport 替换发送方式（测试时可替换为 MemoryTransport）
+func SetTransport(t Transport, sender string) {
+	transportMu.Lock()
+	transport, from = t, sender
+	transportMu.Unlock()
+}
+
+func sender() string {
+	transportMu.RLock()
+	defer transportMu.RUnlock()
+	return from
+}
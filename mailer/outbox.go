@@ -0,0 +1,192 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Queen2333/ielts_test_backend/database"
+	"github.com/Queen2333/ielts_test_backend/jobs"
+)
+
+// 发件箱状态
+const (
+	OutboxPending = "pending" // 等待发送（包括等待重试）
+	OutboxSent    = "sent"    // 已发送
+	OutboxFailed  = "failed"  // 重试次数用完
+)
+
+// MaxAttempts 每封邮件最多发送次数
+const MaxAttempts = 5
+
+const timeLayout = "2006-01-02 15:04:05"
+
+// sensitiveTemplates 正文中包含验证码等一次性凭证的模板，发送成功或放弃重试后清空正文，发件箱中不长期保留明文
+var sensitiveTemplates = map[string]bool{
+	TemplateVerificationCode: true,
+	TemplatePasswordReset:    true,
+}
+
+// redactedBody 清空后的正文
+const redactedBody = "[redacted]"
+
+// finishFields 发送结束（成功或放弃重试）的敏感邮件同时清空正文
+func finishFields(template string, fields map[string]interface{}) map[string]interface{} {
+	if fields["status"] != OutboxPending && sensitiveTemplates[template] {
+		fields["html_body"] = redactedBody
+		fields["text_body"] = redactedBody
+	}
+	return fields
+}
+
+// OutboxItem 发件箱中的邮件
+type OutboxItem struct {
+	ID         int     `json:"id,omitempty"`
+	Template   string  `json:"template"`
+	Locale     string  `json:"locale"`
+	ToEmail    string  `json:"to_email"`
+	Subject    string  `json:"subject"`
+	HTMLBody   string  `json:"html_body"`
+	TextBody   string  `json:"text_body"`
+	Status     string  `json:"status"`
+	Attempts   int     `json:"attempts"`
+	LastError  string  `json:"last_error"`
+	CreatedAt  string  `json:"created_at"`
+	EnqueuedAt *string `json:"enqueued_at"` // 加入任务队列的时间，为空表示需要重新加入
+	SentAt     *string `json:"sent_at"`
+}
+
+// Send 渲染模板、写入发件箱并加入后台任务队列发送，返回发件箱 ID
+// 加入队列失败时邮件仍保留在发件箱中，由 RequeuePending 重新加入队列
+func Send(to, name, locale string, data interface{}) (int, error) {
+	msg, err := Render(name, locale, data)
+	if err != nil {
+		return 0, err
+	}
+
+	item := OutboxItem{
+		Template:  name,
+		Locale:    ResolveLocale(locale),
+		ToEmail:   to,
+		Subject:   msg.Subject,
+		HTMLBody:  msg.HTML,
+		TextBody:  msg.Text,
+		Status:    OutboxPending,
+		CreatedAt: time.Now().Format(timeLayout),
+	}
+	id, err := database.InsertData("email_outbox", &item, "create")
+	if err != nil {
+		return 0, err
+	}
+	if err := enqueue(id); err != nil {
+		fmt.Println("邮件加入发送队列失败，等待重新加入:", id, err)
+	}
+	return id, nil
+}
+
+func enqueue(id int) error {
+	if _, err := jobs.Enqueue(jobs.TypeSendEmail, jobs.SendEmailPayload{OutboxID: id}, jobs.WithMaxAttempts(MaxAttempts)); err != nil {
+		return err
+	}
+	return database.UpdateColumns("email_outbox", id, map[string]interface{}{"enqueued_at": time.Now().Format(timeLayout)})
+}
+
+// Deliver 发送发件箱中的邮件（由后台任务调用），已发送的邮件不会重复发送
+// 发送失败时返回错误，由任务队列按退避时间重试
+func Deliver(ctx context.Context, id int) error {
+	row, err := database.GetDataById("email_outbox", id)
+	if err != nil {
+		return err
+	}
+	if row["status"] == OutboxSent {
+		return nil
+	}
+	transport := DefaultTransport()
+	if transport == nil {
+		return ErrNotConfigured
+	}
+
+	fields, sendErr := deliver(ctx, transport, row)
+	if err := database.UpdateColumns("email_outbox", id, fields); err != nil && sendErr == nil {
+		return err
+	}
+	return sendErr
+}
+
+// deliver 通过 transport 发送发件箱中的一行邮件，返回需要更新的发件箱字段，发送失败时同时返回错误
+// 失败次数达到 MaxAttempts 后状态改为 failed，不再重试
+func deliver(ctx context.Context, transport Transport, row map[string]interface{}) (map[string]interface{}, error) {
+	attempts := toInt(row["attempts"]) + 1
+	template := fmt.Sprint(row["template"])
+
+	msg := Message{
+		From:    sender(),
+		To:      fmt.Sprint(row["to_email"]),
+		Subject: fmt.Sprint(row["subject"]),
+		HTML:    fmt.Sprint(row["html_body"]),
+		Text:    fmt.Sprint(row["text_body"]),
+	}
+	if err := transport.Send(ctx, msg); err != nil {
+		status := OutboxPending
+		if attempts >= MaxAttempts {
+			status = OutboxFailed
+		}
+		return finishFields(template, map[string]interface{}{
+			"status":     status,
+			"attempts":   attempts,
+			"last_error": err.Error(),
+		}), err
+	}
+
+	return finishFields(template, map[string]interface{}{
+		"status":     OutboxSent,
+		"attempts":   attempts,
+		"last_error": "",
+		"sent_at":    time.Now().Format(timeLayout),
+	}), nil
+}
+
+// requeueAfter 加入队列后超过该时间仍未发送的邮件视为任务已丢失（如 worker 崩溃后任务进入死信列表），重新加入队列
+// 远大于 MaxAttempts 次重试的退避时间之和，正常重试中的邮件不会被重复加入
+const requeueAfter = time.Hour
+
+// RequeuePending 把未能加入任务队列（如发送时 Redis 不可用）或任务已丢失的邮件重新加入队列
+func RequeuePending() (int, error) {
+	staleBefore := time.Now().Add(-requeueAfter).Format(timeLayout)
+	rows, err := database.GetDB().Query(
+		"SELECT id FROM email_outbox WHERE status = ? AND (enqueued_at IS NULL OR enqueued_at < ?)",
+		OutboxPending, staleBefore,
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	count := 0
+	for _, id := range ids {
+		if err := enqueue(id); err == nil {
+			count++
+		}
+	}
+	return count, rows.Err()
+}
+
+func toInt(value interface{}) int {
+	switch v := value.(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return 0
+}
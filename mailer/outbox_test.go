@@ -0,0 +1,76 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func outboxRow(template string, attempts int) map[string]interface{} {
+	return map[string]interface{}{
+		"template":  template,
+		"to_email":  "student@example.com",
+		"subject":   "subject",
+		"html_body": "<p>body</p>",
+		"text_body": "body",
+		"attempts":  attempts,
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	SetTransport(nil, "noreply@example.com")
+	errSMTP := errors.New("smtp unavailable")
+
+	tests := []struct {
+		name         string
+		template     string
+		attempts     int
+		sendErr      error
+		wantStatus   string
+		wantAttempts int
+		wantSent     bool
+		wantRedacted bool
+	}{
+		{"sent", TemplateGradingFinished, 0, nil, OutboxSent, 1, true, false},
+		{"first failure retried", TemplateGradingFinished, 0, errSMTP, OutboxPending, 1, false, false},
+		{"failure before last attempt retried", TemplateGradingFinished, MaxAttempts - 2, errSMTP, OutboxPending, MaxAttempts - 1, false, false},
+		{"last attempt fails", TemplateGradingFinished, MaxAttempts - 1, errSMTP, OutboxFailed, MaxAttempts, false, false},
+		{"sent code redacted", TemplateVerificationCode, 0, nil, OutboxSent, 1, true, true},
+		{"retried code kept for next attempt", TemplateVerificationCode, 0, errSMTP, OutboxPending, 1, false, false},
+		{"failed reset link redacted", TemplatePasswordReset, MaxAttempts - 1, errSMTP, OutboxFailed, MaxAttempts, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := &MemoryTransport{Err: tt.sendErr}
+			fields, err := deliver(context.Background(), transport, outboxRow(tt.template, tt.attempts))
+			if !errors.Is(err, tt.sendErr) {
+				t.Fatalf("deliver() error = %v, want %v", err, tt.sendErr)
+			}
+			if fields["status"] != tt.wantStatus || fields["attempts"] != tt.wantAttempts {
+				t.Errorf("deliver() status, attempts = %v, %v, want %v, %v", fields["status"], fields["attempts"], tt.wantStatus, tt.wantAttempts)
+			}
+			if _, ok := fields["sent_at"]; ok != tt.wantSent {
+				t.Errorf("deliver() sets sent_at = %v, want %v", ok, tt.wantSent)
+			}
+			if tt.sendErr != nil && fields["last_error"] != tt.sendErr.Error() {
+				t.Errorf("deliver() last_error = %v, want %q", fields["last_error"], tt.sendErr.Error())
+			}
+			redacted := fields["html_body"] == redactedBody && fields["text_body"] == redactedBody
+			if redacted != tt.wantRedacted {
+				t.Errorf("deliver() redacted body = %v, want %v", redacted, tt.wantRedacted)
+			}
+
+			messages := transport.Messages()
+			if !tt.wantSent {
+				if len(messages) != 0 {
+					t.Errorf("deliver() recorded %d messages on failure, want 0", len(messages))
+				}
+				return
+			}
+			want := Message{From: "noreply@example.com", To: "student@example.com", Subject: "subject", HTML: "<p>body</p>", Text: "body"}
+			if len(messages) != 1 || messages[0] != want {
+				t.Errorf("deliver() sent %+v, want [%+v]", messages, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"strings"
+	texttemplate "text/template"
+)
+
+// 邮件模板，每个模板按语言提供 .subject（标题）、.txt（纯文本）和 .html 三个文件
+const (
+	TemplateVerificationCode = "verification_code" // 验证码：Code、Minutes
+	TemplateGradingFinished  = "grading_finished"  // 评分完成：Skill、Band
+	TemplateAssignmentDue    = "assignment_due"    // 评分任务即将到期：Skill、QueueID、DueAt
+	TemplatePasswordReset    = "password_reset"    // 重置密码：Token、Link、Minutes
+)
+
+// 支持的语言，缺少某个语言的模板时使用默认语言
+const (
+	LocaleZH      = "zh"
+	LocaleEN      = "en"
+	DefaultLocale = LocaleZH
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+var skillNames = map[string]map[string]string{
+	LocaleZH: {"listening": "听力", "reading": "阅读", "writing": "写作", "speaking": "口语"},
+	LocaleEN: {"listening": "listening", "reading": "reading", "writing": "writing", "speaking": "speaking"},
+}
+
+// ResolveLocale 根据 Accept-Language 等语言标识选择模板语言
+func ResolveLocale(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if strings.HasPrefix(language, LocaleEN) {
+		return LocaleEN
+	}
+	return DefaultLocale
+}
+
+func templateFuncs(locale string) map[string]interface{} {
+	return map[string]interface{}{
+		"skill": func(skill string) string {
+			if name, ok := skillNames[locale][skill]; ok {
+				return name
+			}
+			return skill
+		},
+	}
+}
+
+// Render 渲染模板，返回标题、HTML 和纯文本内容
+func Render(name, locale string, data interface{}) (Message, error) {
+	locale = ResolveLocale(locale)
+	if _, err := fs.Stat(templateFS, templatePath(name, locale, "subject")); err != nil {
+		locale = DefaultLocale
+	}
+
+	var msg Message
+	var err error
+	if msg.Subject, err = renderText(name, locale, "subject", data); err != nil {
+		return msg, err
+	}
+	msg.Subject = strings.TrimSpace(msg.Subject)
+	if msg.Text, err = renderText(name, locale, "txt", data); err != nil {
+		return msg, err
+	}
+
+	path := templatePath(name, locale, "html")
+	content, err := templateFS.ReadFile(path)
+	if err != nil {
+		return msg, fmt.Errorf("email template %s not found", path)
+	}
+	t, err := htmltemplate.New(path).Funcs(templateFuncs(locale)).Parse(string(content))
+	if err != nil {
+		return msg, err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return msg, err
+	}
+	msg.HTML = buf.String()
+	return msg, nil
+}
+
+func renderText(name, locale, ext string, data interface{}) (string, error) {
+	path := templatePath(name, locale, ext)
+	content, err := templateFS.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("email template %s not found", path)
+	}
+	t, err := texttemplate.New(path).Funcs(templateFuncs(locale)).Parse(string(content))
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func templatePath(name, locale, ext string) string {
+	return fmt.Sprintf("templates/%s.%s.%s", name, locale, ext)
+}
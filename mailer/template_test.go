@@ -0,0 +1,159 @@
+package mailer
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestResolveLocale(t *testing.T) {
+	tests := []struct {
+		language string
+		want     string
+	}{
+		{"", DefaultLocale},
+		{"zh", LocaleZH},
+		{"zh-CN,zh;q=0.9,en;q=0.8", LocaleZH},
+		{"en", LocaleEN},
+		{"en-US,en;q=0.9", LocaleEN},
+		{" EN-gb ", LocaleEN},
+		{"fr-FR", DefaultLocale},
+	}
+	for _, tt := range tests {
+		if got := ResolveLocale(tt.language); got != tt.want {
+			t.Errorf("ResolveLocale(%q) = %q, want %q", tt.language, got, tt.want)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name        string
+		template    string
+		locale      string
+		data        map[string]interface{}
+		wantSubject string
+		wantText    []string
+		wantHTML    []string
+	}{
+		{
+			name:        "verification code in chinese",
+			template:    TemplateVerificationCode,
+			locale:      "zh-CN",
+			data:        map[string]interface{}{"Code": "123456", "Minutes": 15},
+			wantSubject: "邮箱登录验证",
+			wantText:    []string{"123456", "15分钟"},
+			wantHTML:    []string{"123456", "15 分钟"},
+		},
+		{
+			name:        "verification code in english",
+			template:    TemplateVerificationCode,
+			locale:      "en-US",
+			data:        map[string]interface{}{"Code": "654321", "Minutes": 15},
+			wantSubject: "Your verification code",
+			wantText:    []string{"654321", "15 minutes"},
+			wantHTML:    []string{"654321"},
+		},
+		{
+			name:        "unsupported locale falls back to chinese",
+			template:    TemplateVerificationCode,
+			locale:      "fr-FR",
+			data:        map[string]interface{}{"Code": "111111", "Minutes": 15},
+			wantSubject: "邮箱登录验证",
+			wantText:    []string{"111111"},
+		},
+		{
+			name:        "skill names are localized",
+			template:    TemplateGradingFinished,
+			locale:      "en",
+			data:        map[string]interface{}{"Skill": "writing", "Band": 6.5},
+			wantSubject: "Your writing has been graded",
+			wantText:    []string{"Band 6.5"},
+			wantHTML:    []string{"Band 6.5"},
+		},
+		{
+			name:     "skill names in chinese",
+			template: TemplateGradingFinished,
+			locale:   "zh",
+			data:     map[string]interface{}{"Skill": "speaking", "Band": 7.0},
+			wantText: []string{"口语", "7.0"},
+		},
+		{
+			name:     "html escapes data",
+			template: TemplatePasswordReset,
+			locale:   "en",
+			data:     map[string]interface{}{"Token": "<script>", "Link": "", "Minutes": 30},
+			wantText: []string{"<script>"},
+			wantHTML: []string{"&lt;script&gt;"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := Render(tt.template, tt.locale, tt.data)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if tt.wantSubject != "" && msg.Subject != tt.wantSubject {
+				t.Errorf("Render() subject = %q, want %q", msg.Subject, tt.wantSubject)
+			}
+			if strings.Contains(msg.Subject, "\n") {
+				t.Errorf("Render() subject %q contains a newline", msg.Subject)
+			}
+			for _, want := range tt.wantText {
+				if !strings.Contains(msg.Text, want) {
+					t.Errorf("Render() text = %q, want it to contain %q", msg.Text, want)
+				}
+			}
+			for _, want := range tt.wantHTML {
+				if !strings.Contains(msg.HTML, want) {
+					t.Errorf("Render() html = %q, want it to contain %q", msg.HTML, want)
+				}
+			}
+			if strings.Contains(msg.HTML, "<script>") {
+				t.Errorf("Render() html contains unescaped data")
+			}
+		})
+	}
+}
+
+func TestRenderUnknownTemplate(t *testing.T) {
+	if _, err := Render("missing_template", LocaleEN, nil); err == nil {
+		t.Errorf("Render(missing_template) error = nil, want error")
+	}
+}
+
+func TestRenderAllTemplates(t *testing.T) {
+	data := map[string]interface{}{
+		"Code": "123456", "Minutes": 15, "Skill": "reading", "Band": 6.0,
+		"QueueID": 7, "DueAt": "2026-10-20 12:00:00", "Token": "token", "Link": "https://example.com/reset",
+	}
+	for _, name := range []string{TemplateVerificationCode, TemplateGradingFinished, TemplateAssignmentDue, TemplatePasswordReset} {
+		for _, locale := range []string{LocaleZH, LocaleEN} {
+			msg, err := Render(name, locale, data)
+			if err != nil {
+				t.Errorf("Render(%s, %s) error = %v", name, locale, err)
+				continue
+			}
+			if msg.Subject == "" || msg.Text == "" || msg.HTML == "" {
+				t.Errorf("Render(%s, %s) = %+v, want subject, text and html", name, locale, msg)
+			}
+		}
+	}
+}
+
+func TestMemoryTransport(t *testing.T) {
+	transport := &MemoryTransport{}
+	msg := Message{From: "a@example.com", To: "b@example.com", Subject: "s", Text: "t"}
+	if err := transport.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	messages := transport.Messages()
+	if len(messages) != 1 || messages[0] != msg {
+		t.Fatalf("Messages() = %+v, want [%+v]", messages, msg)
+	}
+	// 返回的是副本，修改不影响已记录的邮件
+	messages[0].Subject = "changed"
+	if transport.Messages()[0].Subject != "s" {
+		t.Errorf("Messages() returned the internal slice")
+	}
+}
@@ -0,0 +1,116 @@
+package mailer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message 渲染后的邮件
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// Transport 邮件发送方式
+type Transport interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SMTPTransport 通过 SMTP 发送
+type SMTPTransport struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+}
+
+func (t *SMTPTransport) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	auth := smtp.PlainAuth("", t.Username, t.Password, t.Host)
+	return smtp.SendMail(fmt.Sprintf("%s:%d", t.Host, t.Port), auth, msg.From, []string{msg.To}, buildMIME(msg))
+}
+
+// FileTransport 把邮件写入 maildir 格式的目录（Dir/new/*.eml），用于本地开发
+type FileTransport struct {
+	Dir string
+}
+
+func (t *FileTransport) Send(ctx context.Context, msg Message) error {
+	dir := filepath.Join(t.Dir, "new")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%d.%s.eml", time.Now().UnixNano(), randomToken())
+	return os.WriteFile(filepath.Join(dir, name), buildMIME(msg), 0644)
+}
+
+// MemoryTransport 把邮件保存在内存中，用于测试
+type MemoryTransport struct {
+	mu       sync.Mutex
+	messages []Message
+	Err      error // 不为空时每次发送都返回该错误
+}
+
+func (t *MemoryTransport) Send(ctx context.Context, msg Message) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.Err != nil {
+		return t.Err
+	}
+	t.messages = append(t.messages, msg)
+	return nil
+}
+
+// Messages 已发送的邮件
+func (t *MemoryTransport) Messages() []Message {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Message(nil), t.messages...)
+}
+
+// buildMIME 生成 multipart/alternative 邮件，同时包含纯文本和 HTML
+func buildMIME(msg Message) []byte {
+	boundary := "=_" + randomToken()
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", msg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&b, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", msg.Text},
+		{"text/html", msg.HTML},
+	} {
+		if part.body == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "--%s\r\n", boundary)
+		fmt.Fprintf(&b, "Content-Type: %s; charset=UTF-8\r\n", part.contentType)
+		b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+		b.WriteString(strings.ReplaceAll(part.body, "\n", "\r\n"))
+		b.WriteString("\r\n")
+	}
+	fmt.Fprintf(&b, "--%s--\r\n", boundary)
+	return []byte(b.String())
+}
+
+func randomToken() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
@@ -1,10 +1,20 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Queen2333/ielts_test_backend/auth"
 	"github.com/Queen2333/ielts_test_backend/database"
 	_ "github.com/Queen2333/ielts_test_backend/docs" // 导入自动生成的文档
+	"github.com/Queen2333/ielts_test_backend/mailer"
 	"github.com/Queen2333/ielts_test_backend/routes"
+	"github.com/Queen2333/ielts_test_backend/services"
 	"github.com/Queen2333/ielts_test_backend/utils"
+	"github.com/Queen2333/ielts_test_backend/workers"
 	"github.com/gin-gonic/gin"
 )
 
@@ -31,6 +41,20 @@ func main() {
 	//})
 
 
+	// 检查必需的密钥配置，缺少时直接退出，不使用默认值
+	if err := utils.CheckOTPConfig(); err != nil {
+		panic(err)
+	}
+	if err := auth.Init(); err != nil {
+		panic(err)
+	}
+	if err := mailer.Init(); err != nil {
+		panic(err)
+	}
+	if err := services.Init(); err != nil {
+		panic(err)
+	}
+
 	str := utils.GenerateRandomString(32)
 
 	println(str)
@@ -48,6 +72,39 @@ func main() {
 	}
 	defer database.GetDB().Close()
 
+	// 注册后台任务
+	workers.Register()
+
+	// ./app worker 只运行后台任务，不启动 HTTP 服务
+	if len(os.Args) > 1 && os.Args[1] == "worker" {
+		runWorker()
+		return
+	}
+
+	// HTTP 服务中同时运行后台任务，JOBS_WORKERS=0 时由单独的 worker 进程执行
+	if concurrency := workers.Concurrency(); concurrency > 0 {
+		go func() {
+			if err := workers.Run(context.Background(), concurrency); err != nil {
+				fmt.Println("后台任务启动失败:", err)
+			}
+		}()
+	}
+
 	// 启动Gin服务
 	r.Run(":8081")
 }
+
+// runWorker 运行后台任务，收到退出信号后等待执行中的任务完成
+func runWorker() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	concurrency := workers.Concurrency()
+	if concurrency == 0 {
+		concurrency = workers.DefaultConcurrency
+	}
+	fmt.Printf("worker started with %d workers\n", concurrency)
+	if err := workers.Run(ctx, concurrency); err != nil {
+		panic(err)
+	}
+}
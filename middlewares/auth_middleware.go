@@ -1,24 +1,26 @@
 package middlewares
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/Queen2333/ielts_test_backend/auth"
+	"github.com/Queen2333/ielts_test_backend/database"
 	"github.com/Queen2333/ielts_test_backend/utils"
 )
 
-var jwtSecret = []byte("qC2dACu+zgx94ALrfmCTESkxoqfCG4ItCWknbz+XmfTfWNDvFeuYKOGXgAKqSq+7Bdu8jXrxZfpWwE0K0jPLHw==") // Replace with the same secret key used for token creation.
-
-// JWTAuthMiddleware is a middleware to validate the JWT token.
+// JWTAuthMiddleware 校验 token 的签名、exp、iss 和 aud，确认会话未被注销，
+// 加载用户并把 auth.Principal 保存到 gin.Context 中供后续处理函数使用
 func JWTAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 
 		// Check if the Authorization header is provided and starts with "Bearer ".
 		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header invalid"})
+			utils.HandleResponse(c, http.StatusUnauthorized, "", "Authorization header invalid")
 			c.Abort()
 			return
 		}
@@ -26,23 +28,48 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 		// Extract the token from the Authorization header.
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 
+		claims, err := auth.Parse(tokenString)
+		if err != nil {
+			utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+			c.Abort()
+			return
+		}
+
 		// 初始化 Redis
-		err := utils.InitRedis()
+		err = utils.InitRedis()
 		if err != nil {
 			utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to connect to Redis")
 			c.Abort()
 			return
 		}
 
-		// 从 Redis 获取 token
-		_, err = utils.Get(tokenString)
+		// 每个请求只加载一次用户，角色以数据库为准
+		principal := &auth.Principal{SessionID: claims.SessionID, TokenID: claims.Id, Token: tokenString, Claims: claims}
+		err = database.GetDB().QueryRow("SELECT id, email, role_id FROM user_list WHERE id = ?", claims.Subject).
+			Scan(&principal.ID, &principal.Email, &principal.RoleID)
+		if err != nil {
+			if database.IsNoRowsError(err) {
+				utils.HandleResponse(c, http.StatusUnauthorized, "", "User not found")
+			} else {
+				utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to load user")
+			}
+			c.Abort()
+			return
+		}
+
+		// 退出登录、修改密码等操作会注销会话，使会话下的 access token 提前失效
+		err = utils.TouchSession(claims.SessionID, principal.ID, c.ClientIP())
 		if err != nil {
-			utils.HandleResponse(c, http.StatusUnauthorized, "", "Invalid or expired token")
+			if errors.Is(err, utils.ErrSessionNotFound) {
+				utils.HandleResponse(c, http.StatusUnauthorized, "", "Session expired or revoked")
+			} else {
+				utils.HandleResponse(c, http.StatusInternalServerError, "", "Failed to check session")
+			}
 			c.Abort()
 			return
 		}
+		auth.SetPrincipal(c, principal)
 
-		// Token is valid, continue to the next middleware or route handler.
 		c.Next()
 	}
 }
@@ -0,0 +1,312 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Queen2333/ielts_test_backend/utils"
+)
+
+// RateLimitResult 一次计数的结果
+type RateLimitResult struct {
+	Allowed   bool
+	Remaining int
+	Reset     time.Duration // 窗口内最早的请求过期（可以再次请求）的剩余时间
+}
+
+// RateLimitStore 滑动窗口计数存储
+type RateLimitStore interface {
+	Hit(ctx context.Context, key string, limit int, window time.Duration) (RateLimitResult, error)
+}
+
+// RateLimitKeyFunc 从请求中取限流的键，返回空字符串时不限流
+type RateLimitKeyFunc func(c *gin.Context) string
+
+// RateLimitPolicy 路由的限流策略：每个键在 Window 内最多 Limit 次请求
+// 可以用环境变量 RATE_LIMIT_<NAME>（如 RATE_LIMIT_SEND_CODE_EMAIL=5/1h）覆盖，RATE_LIMIT_DISABLED=1 关闭所有限流
+type RateLimitPolicy struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+	Key    RateLimitKeyFunc
+}
+
+// KeyByIP 按客户端 IP 限流；只有来自 TRUSTED_PROXIES 的请求才使用 X-Forwarded-For，见 routes.SetupRouter
+func KeyByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// KeyByUser 按登录用户限流，未登录时按 IP
+func KeyByUser(c *gin.Context) string {
+	if userID, err := utils.GetUserIDFromToken(c); err == nil && userID != "" {
+		return "user:" + userID
+	}
+	return KeyByIP(c)
+}
+
+// KeyByEmail 按请求体中的 email 字段限流，读取后恢复请求体
+func KeyByEmail(c *gin.Context) string {
+	if c.Request.Body == nil {
+		return ""
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
+	if err != nil {
+		return ""
+	}
+	var request struct {
+		Email string `json:"email"`
+	}
+	if json.Unmarshal(body, &request) != nil {
+		return ""
+	}
+	email := utils.NormalizeEmail(request.Email)
+	if email == "" {
+		return ""
+	}
+	return "email:" + email
+}
+
+var (
+	storeMu     sync.RWMutex
+	customStore RateLimitStore
+	memoryStore = NewMemoryRateLimitStore()
+	redisStore  = &RedisRateLimitStore{}
+)
+
+// SetRateLimitStore 替换限流存储（测试中使用 NewMemoryRateLimitStore），传 nil 恢复默认的 Redis 存储
+func SetRateLimitStore(store RateLimitStore) {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	customStore = store
+}
+
+// redisDown Redis 不可用、正在使用内存计数
+var redisDown atomic.Bool
+
+// hit 优先使用 Redis，Redis 不可用时退回到进程内存计数
+func hit(ctx context.Context, key string, limit int, window time.Duration) (RateLimitResult, error) {
+	storeMu.RLock()
+	store := customStore
+	storeMu.RUnlock()
+	if store != nil {
+		return store.Hit(ctx, key, limit, window)
+	}
+
+	result, err := redisStore.Hit(ctx, key, limit, window)
+	if err != nil {
+		// 只在 Redis 刚变为不可用时打印一次，恢复后再打印一次
+		if redisDown.CompareAndSwap(false, true) {
+			fmt.Println("限流 Redis 不可用，使用内存计数:", err)
+		}
+		return memoryStore.Hit(ctx, key, limit, window)
+	}
+	if redisDown.CompareAndSwap(true, false) {
+		fmt.Println("限流 Redis 已恢复")
+	}
+	return result, nil
+}
+
+// RateLimit 按策略限流的中间件，返回 RateLimit-* 响应头，超过限制时返回 429
+func RateLimit(policy RateLimitPolicy) gin.HandlerFunc {
+	policy = policyFromEnv(policy)
+	disabled := os.Getenv("RATE_LIMIT_DISABLED") == "1"
+
+	return func(c *gin.Context) {
+		if disabled || policy.Limit <= 0 {
+			c.Next()
+			return
+		}
+		key := policy.Key(c)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		result, err := hit(c.Request.Context(), "ratelimit:"+policy.Name+":"+key, policy.Limit, policy.Window)
+		if err != nil {
+			// 计数失败时不拦截请求
+			fmt.Println("限流计数失败:", policy.Name, err)
+			c.Next()
+			return
+		}
+
+		reset := int(math.Ceil(result.Reset.Seconds()))
+		setRateLimitHeaders(c, policy, result.Remaining, reset)
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(reset))
+			utils.HandleResponse(c, http.StatusTooManyRequests, gin.H{"retry_after": reset}, "Too many requests, please try again later")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// setRateLimitHeaders 一个路由有多个策略时，响应头使用剩余次数最少的策略
+func setRateLimitHeaders(c *gin.Context, policy RateLimitPolicy, remaining, reset int) {
+	if existing := c.Writer.Header().Get("RateLimit-Remaining"); existing != "" {
+		if n, err := strconv.Atoi(existing); err == nil && n <= remaining {
+			return
+		}
+	}
+	c.Header("RateLimit-Limit", strconv.Itoa(policy.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(remaining))
+	c.Header("RateLimit-Reset", strconv.Itoa(reset))
+	c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limit, int(policy.Window.Seconds())))
+}
+
+// policyFromEnv 读取 RATE_LIMIT_<NAME>=<limit>/<window> 覆盖默认策略
+func policyFromEnv(policy RateLimitPolicy) RateLimitPolicy {
+	name := "RATE_LIMIT_" + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(policy.Name))
+	value := os.Getenv(name)
+	if value == "" {
+		return policy
+	}
+	parts := strings.SplitN(value, "/", 2)
+	limit, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		fmt.Println("限流配置无效:", name, value)
+		return policy
+	}
+	policy.Limit = limit
+	if len(parts) == 2 {
+		window, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+		if err != nil || window <= 0 {
+			fmt.Println("限流配置无效:", name, value)
+			return policy
+		}
+		policy.Window = window
+	}
+	return policy
+}
+
+// slidingWindowScript 删除窗口外的请求，未超过限制时记录本次请求
+// 返回 {是否允许, 窗口内请求数, 最早请求过期的毫秒数}
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call('ZREMRANGEBYSCORE', key, 0, now - window)
+local count = redis.call('ZCARD', key)
+local allowed = 0
+if count < limit then
+  redis.call('ZADD', key, now, ARGV[4])
+  count = count + 1
+  allowed = 1
+end
+redis.call('PEXPIRE', key, window)
+local reset = window
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+if oldest[2] then
+  reset = tonumber(oldest[2]) + window - now
+end
+return {allowed, count, reset}
+`)
+
+// RedisRateLimitStore 基于 Redis 有序集合的滑动窗口，多个实例共享计数
+type RedisRateLimitStore struct{}
+
+// Hit 记录一次请求
+func (s *RedisRateLimitStore) Hit(ctx context.Context, key string, limit int, window time.Duration) (RateLimitResult, error) {
+	client, err := utils.RedisClient()
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	now := time.Now().UnixMilli()
+	values, err := slidingWindowScript.Run(ctx, client, []string{key}, now, window.Milliseconds(), limit, uuid.NewString()).Int64Slice()
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	if len(values) != 3 {
+		return RateLimitResult{}, fmt.Errorf("unexpected rate limit script result: %v", values)
+	}
+	return RateLimitResult{
+		Allowed:   values[0] == 1,
+		Remaining: remainingOf(limit, int(values[1])),
+		Reset:     time.Duration(values[2]) * time.Millisecond,
+	}, nil
+}
+
+// memoryRateLimitMaxKeys 键数量超过该值时清理已过期的键
+const memoryRateLimitMaxKeys = 10000
+
+// MemoryRateLimitStore 进程内存中的滑动窗口，用于测试和 Redis 不可用时
+type MemoryRateLimitStore struct {
+	mu      sync.Mutex
+	windows map[string]*memoryWindow
+	now     func() time.Time
+}
+
+type memoryWindow struct {
+	hits   []time.Time
+	window time.Duration
+}
+
+// NewMemoryRateLimitStore 创建内存限流存储
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{windows: map[string]*memoryWindow{}, now: time.Now}
+}
+
+// Hit 记录一次请求
+func (s *MemoryRateLimitStore) Hit(ctx context.Context, key string, limit int, window time.Duration) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if len(s.windows) > memoryRateLimitMaxKeys {
+		for k, w := range s.windows {
+			if len(w.hits) == 0 || now.Sub(w.hits[len(w.hits)-1]) >= w.window {
+				delete(s.windows, k)
+			}
+		}
+	}
+
+	w, ok := s.windows[key]
+	if !ok {
+		w = &memoryWindow{}
+		s.windows[key] = w
+	}
+	w.window = window
+	kept := w.hits[:0]
+	for _, t := range w.hits {
+		if now.Sub(t) < window {
+			kept = append(kept, t)
+		}
+	}
+
+	allowed := len(kept) < limit
+	if allowed {
+		kept = append(kept, now)
+	}
+	w.hits = kept
+
+	reset := window
+	if len(kept) > 0 {
+		reset = kept[0].Add(window).Sub(now)
+	}
+	return RateLimitResult{Allowed: allowed, Remaining: remainingOf(limit, len(kept)), Reset: reset}, nil
+}
+
+func remainingOf(limit, count int) int {
+	if count >= limit {
+		return 0
+	}
+	return limit - count
+}
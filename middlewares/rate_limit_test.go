@@ -0,0 +1,254 @@
+package middlewares
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeClock 可以手动推进的时间，用于测试滑动窗口
+type fakeClock struct{ now time.Time }
+
+func (f *fakeClock) Now() time.Time          { return f.now }
+func (f *fakeClock) Advance(d time.Duration) { f.now = f.now.Add(d) }
+
+func newTestMemoryStore() (*MemoryRateLimitStore, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)}
+	store := NewMemoryRateLimitStore()
+	store.now = clock.Now
+	return store, clock
+}
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	store, clock := newTestMemoryStore()
+	ctx := context.Background()
+	window := time.Minute
+
+	type step struct {
+		advance       time.Duration
+		key           string
+		wantAllowed   bool
+		wantRemaining int
+		wantReset     time.Duration
+	}
+	steps := []step{
+		{0, "a", true, 2, time.Minute},
+		{10 * time.Second, "a", true, 1, 50 * time.Second},
+		{10 * time.Second, "a", true, 0, 40 * time.Second},
+		{10 * time.Second, "a", false, 0, 30 * time.Second},
+		{0, "b", true, 2, time.Minute}, // 不同的键分别计数
+		// 第一次请求过期后可以再次请求
+		{30 * time.Second, "a", true, 0, 10 * time.Second},
+		{0, "a", false, 0, 10 * time.Second},
+		// 整个窗口过去后计数清零
+		{2 * time.Minute, "a", true, 2, time.Minute},
+	}
+	for i, s := range steps {
+		clock.Advance(s.advance)
+		got, err := store.Hit(ctx, s.key, 3, window)
+		if err != nil {
+			t.Fatalf("step %d: Hit() error = %v", i, err)
+		}
+		if got.Allowed != s.wantAllowed || got.Remaining != s.wantRemaining || got.Reset != s.wantReset {
+			t.Errorf("step %d: Hit(%q) = %+v, want {Allowed:%v Remaining:%d Reset:%v}", i, s.key, got, s.wantAllowed, s.wantRemaining, s.wantReset)
+		}
+	}
+}
+
+func TestMemoryRateLimitStoreEvictsExpiredKeys(t *testing.T) {
+	store, clock := newTestMemoryStore()
+	ctx := context.Background()
+	for i := 0; i <= memoryRateLimitMaxKeys; i++ {
+		store.Hit(ctx, "key:"+strconv.Itoa(i), 1, time.Second)
+	}
+	clock.Advance(2 * time.Second)
+	store.Hit(ctx, "fresh", 1, time.Second)
+	if len(store.windows) != 1 {
+		t.Errorf("windows after eviction = %d, want 1", len(store.windows))
+	}
+}
+
+func newRateLimitRouter(policies ...RateLimitPolicy) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handlers := []gin.HandlerFunc{}
+	for _, policy := range policies {
+		handlers = append(handlers, RateLimit(policy))
+	}
+	handlers = append(handlers, func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	router.POST("/limited", handlers...)
+	return router
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	store, _ := newTestMemoryStore()
+	SetRateLimitStore(store)
+	t.Cleanup(func() { SetRateLimitStore(nil) })
+
+	router := newRateLimitRouter(RateLimitPolicy{Name: "test-basic", Limit: 2, Window: time.Minute, Key: KeyByIP})
+
+	tests := []struct {
+		wantStatus     int
+		wantRemaining  string
+		wantRetryAfter string
+	}{
+		{http.StatusOK, "1", ""},
+		{http.StatusOK, "0", ""},
+		{http.StatusTooManyRequests, "0", "60"},
+	}
+	for i, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/limited", nil)
+		req.RemoteAddr = "203.0.113.7:1234"
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("request %d: status = %d, want %d", i+1, w.Code, tt.wantStatus)
+		}
+		if got := w.Header().Get("RateLimit-Limit"); got != "2" {
+			t.Errorf("request %d: RateLimit-Limit = %q, want 2", i+1, got)
+		}
+		if got := w.Header().Get("RateLimit-Remaining"); got != tt.wantRemaining {
+			t.Errorf("request %d: RateLimit-Remaining = %q, want %q", i+1, got, tt.wantRemaining)
+		}
+		if got := w.Header().Get("RateLimit-Reset"); got != "60" {
+			t.Errorf("request %d: RateLimit-Reset = %q, want 60", i+1, got)
+		}
+		if got := w.Header().Get("RateLimit-Policy"); got != "2;w=60" {
+			t.Errorf("request %d: RateLimit-Policy = %q, want 2;w=60", i+1, got)
+		}
+		if got := w.Header().Get("Retry-After"); got != tt.wantRetryAfter {
+			t.Errorf("request %d: Retry-After = %q, want %q", i+1, got, tt.wantRetryAfter)
+		}
+		if tt.wantStatus != http.StatusTooManyRequests {
+			continue
+		}
+		var body struct {
+			Code int `json:"code"`
+			Data struct {
+				RetryAfter int `json:"retry_after"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("429 body %q: %v", w.Body.String(), err)
+		}
+		if body.Code != http.StatusTooManyRequests || body.Data.RetryAfter != 60 {
+			t.Errorf("429 body = %+v, want code 429 and retry_after 60", body)
+		}
+	}
+
+	// 其他 IP 不受影响
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/limited", nil)
+	req.RemoteAddr = "198.51.100.1:1234"
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("other IP status = %d, want 200", w.Code)
+	}
+}
+
+func TestRateLimitMiddlewareSkipsEmptyKeyAndKeepsBody(t *testing.T) {
+	store, _ := newTestMemoryStore()
+	SetRateLimitStore(store)
+	t.Cleanup(func() { SetRateLimitStore(nil) })
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/limited", RateLimit(RateLimitPolicy{Name: "test-email", Limit: 1, Window: time.Minute, Key: KeyByEmail}), func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, string(body))
+	})
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"first request for email", `{"email":"User@Example.com"}`, http.StatusOK},
+		{"same email normalized", `{"email":" user@example.com "}`, http.StatusTooManyRequests},
+		{"different email", `{"email":"other@example.com"}`, http.StatusOK},
+		{"no email is not limited", `{}`, http.StatusOK},
+		{"invalid json is not limited", `not json`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/limited", strings.NewReader(tt.body)))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			// 读取 email 后请求体仍可被处理函数读取
+			if tt.wantStatus == http.StatusOK && w.Body.String() != tt.body {
+				t.Errorf("handler body = %q, want %q", w.Body.String(), tt.body)
+			}
+		})
+	}
+}
+
+func TestRateLimitHeadersUseStrictestPolicy(t *testing.T) {
+	store, _ := newTestMemoryStore()
+	SetRateLimitStore(store)
+	t.Cleanup(func() { SetRateLimitStore(nil) })
+
+	router := newRateLimitRouter(
+		RateLimitPolicy{Name: "test-loose", Limit: 10, Window: time.Hour, Key: KeyByIP},
+		RateLimitPolicy{Name: "test-strict", Limit: 3, Window: time.Minute, Key: KeyByIP},
+	)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/limited", nil))
+	if got := w.Header().Get("RateLimit-Limit"); got != "3" {
+		t.Errorf("RateLimit-Limit = %q, want 3", got)
+	}
+	if got := w.Header().Get("RateLimit-Remaining"); got != "2" {
+		t.Errorf("RateLimit-Remaining = %q, want 2", got)
+	}
+}
+
+func TestPolicyFromEnv(t *testing.T) {
+	base := RateLimitPolicy{Name: "send-code.email", Limit: 5, Window: time.Hour}
+	tests := []struct {
+		name       string
+		value      string
+		wantLimit  int
+		wantWindow time.Duration
+	}{
+		{"not set", "", 5, time.Hour},
+		{"limit only", "20", 20, time.Hour},
+		{"limit and window", "3/10m", 3, 10 * time.Minute},
+		{"invalid limit", "many", 5, time.Hour},
+		{"invalid window", "3/soon", 3, time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("RATE_LIMIT_SEND_CODE_EMAIL", tt.value)
+			got := policyFromEnv(base)
+			if got.Limit != tt.wantLimit || got.Window != tt.wantWindow {
+				t.Errorf("policyFromEnv(%q) = %d/%v, want %d/%v", tt.value, got.Limit, got.Window, tt.wantLimit, tt.wantWindow)
+			}
+		})
+	}
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	store, _ := newTestMemoryStore()
+	SetRateLimitStore(store)
+	t.Cleanup(func() { SetRateLimitStore(nil) })
+	t.Setenv("RATE_LIMIT_DISABLED", "1")
+
+	router := newRateLimitRouter(RateLimitPolicy{Name: "test-disabled", Limit: 1, Window: time.Minute, Key: KeyByIP})
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/limited", nil))
+		if w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "" {
+			t.Errorf("request %d: status = %d, RateLimit-Limit = %q, want 200 without headers", i+1, w.Code, w.Header().Get("RateLimit-Limit"))
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Queen2333/ielts_test_backend/auth"
+	"github.com/Queen2333/ielts_test_backend/utils"
+)
+
+// RequirePermission 要求当前用户拥有所有指定权限，未登录返回 401，没有权限返回 403
+// 需要放在 JWTAuthMiddleware 之后
+func RequirePermission(perms ...auth.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := auth.PrincipalFrom(c)
+		if err != nil {
+			utils.HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+			c.Abort()
+			return
+		}
+		for _, perm := range perms {
+			if !principal.Can(perm) {
+				utils.HandleResponse(c, http.StatusForbidden, "", "Permission denied")
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,133 @@
+// Migration 007: 规范化已存储的题目答案与做题记录答案
+//
+// 运行方式（数据库连接字符串通过 -dsn 或环境变量 DATABASE_DSN 传入）：
+//
+//	DATABASE_DSN='user:password@tcp(host:3306)/ielts_database' go run ./migrations/007_normalize_question_answers -dry-run
+//	DATABASE_DSN='user:password@tcp(host:3306)/ielts_database' go run ./migrations/007_normalize_question_answers
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Queen2333/ielts_test_backend/models"
+	_ "github.com/go-sql-driver/mysql"
+)
+
+func main() {
+	dsn := flag.String("dsn", os.Getenv("DATABASE_DSN"), "数据库连接字符串，默认读取环境变量 DATABASE_DSN")
+	dryRun := flag.Bool("dry-run", false, "只输出需要修改的数据，不写入数据库")
+	flag.Parse()
+	if *dsn == "" {
+		log.Fatal("Database DSN is required: pass -dsn or set DATABASE_DSN")
+	}
+
+	db, err := sql.Open("mysql", *dsn)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Ping(); err != nil {
+		log.Fatalf("Failed to ping database: %v", err)
+	}
+	fmt.Println("Connected to database successfully")
+
+	// 题目配置：type_list 中的正确答案
+	for _, table := range []string{"listening_part_list", "reading_part_list"} {
+		migrateColumn(db, table, "type_list", *dryRun, func(value interface{}) (bool, []string) {
+			typeList, ok := value.([]interface{})
+			if !ok {
+				return false, []string{"type_list is not an array"}
+			}
+			return models.NormalizeTypeList(typeList)
+		})
+	}
+
+	// 做题记录：answers 中的用户答案
+	for _, table := range []string{"listening_records", "reading_records", "testing_records"} {
+		migrateColumn(db, table, "answers", *dryRun, normalizeRecordAnswers)
+	}
+
+	fmt.Println("\n✅ Migration 007 completed!")
+}
+
+// migrateColumn 逐行读取 JSON 字段，规范化后写回
+func migrateColumn(db *sql.DB, table, column string, dryRun bool, normalize func(interface{}) (bool, []string)) {
+	rows, err := db.Query(fmt.Sprintf("SELECT id, %s FROM %s", column, table))
+	if err != nil {
+		log.Fatalf("Failed to query %s: %v", table, err)
+	}
+
+	updates := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var raw sql.NullString
+		if err := rows.Scan(&id, &raw); err != nil {
+			log.Fatalf("Failed to scan %s: %v", table, err)
+		}
+		if !raw.Valid || raw.String == "" {
+			continue
+		}
+
+		var value interface{}
+		if err := json.Unmarshal([]byte(raw.String), &value); err != nil {
+			fmt.Printf("  ⚠️  %s #%d: invalid JSON, skipping: %v\n", table, id, err)
+			continue
+		}
+
+		changed, problems := normalize(value)
+		for _, problem := range problems {
+			fmt.Printf("  ⚠️  %s #%d: %s\n", table, id, problem)
+		}
+		if !changed {
+			continue
+		}
+
+		normalized, err := json.Marshal(value)
+		if err != nil {
+			log.Fatalf("Failed to marshal %s #%d: %v", table, id, err)
+		}
+		updates[id] = string(normalized)
+	}
+	rows.Close()
+
+	fmt.Printf("%s.%s: %d rows to update\n", table, column, len(updates))
+	if dryRun {
+		return
+	}
+	for id, value := range updates {
+		if _, err := db.Exec(fmt.Sprintf("UPDATE %s SET %s = ? WHERE id = ?", table, column), value, id); err != nil {
+			log.Fatalf("Failed to update %s #%d: %v", table, id, err)
+		}
+	}
+	fmt.Printf("  ✓ %s updated\n", table)
+}
+
+// normalizeRecordAnswers 把 [{"no": "1", "answer": 10}] 中的答案统一转换为字符串
+func normalizeRecordAnswers(value interface{}) (bool, []string) {
+	answers, ok := value.([]interface{})
+	if !ok {
+		return false, []string{"answers is not an array"}
+	}
+
+	changed := false
+	for _, answerInterface := range answers {
+		answer, ok := answerInterface.(map[string]interface{})
+		if !ok || answer["answer"] == nil {
+			continue
+		}
+		normalized := models.NewAnswerValue(answer["answer"]).Interface()
+		before, _ := json.Marshal(answer["answer"])
+		after, _ := json.Marshal(normalized)
+		if string(before) != string(after) {
+			answer["answer"] = normalized
+			changed = true
+		}
+	}
+	return changed, nil
+}
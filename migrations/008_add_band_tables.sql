@@ -0,0 +1,34 @@
+-- Migration: Band conversion tables, reading module and raw/band scores on records
+-- Created: 2026-10-19
+-- Purpose:
+--   1. Store editable raw-score-to-band conversion tables per skill and module
+--   2. Distinguish Academic / General Training reading on sets
+--   3. Store both raw score and band on submitted records
+
+CREATE TABLE IF NOT EXISTS band_tables (
+    id INT NOT NULL PRIMARY KEY,
+    skill VARCHAR(32) NOT NULL COMMENT '科目：listening / reading',
+    module VARCHAR(32) NOT NULL DEFAULT '' COMMENT '考试类型：academic / general，听力为空',
+    conversion JSON NOT NULL COMMENT '换算表：[{"min_raw": 39, "band": 9}]',
+    UNIQUE KEY uk_band_tables_skill_module (skill, module)
+);
+
+-- 阅读考试类型
+ALTER TABLE reading_list
+ADD COLUMN module VARCHAR(32) NOT NULL DEFAULT 'academic' COMMENT '考试类型：academic / general';
+
+ALTER TABLE testing_list
+ADD COLUMN module VARCHAR(32) NOT NULL DEFAULT 'academic' COMMENT '考试类型：academic / general';
+
+-- 原始分与 Band 分
+ALTER TABLE listening_records
+ADD COLUMN raw_score INT NULL COMMENT '答对题数',
+ADD COLUMN band DECIMAL(2,1) NULL COMMENT 'Band 分';
+
+ALTER TABLE reading_records
+ADD COLUMN raw_score INT NULL COMMENT '答对题数',
+ADD COLUMN band DECIMAL(2,1) NULL COMMENT 'Band 分';
+
+ALTER TABLE testing_records
+ADD COLUMN raw_scores TEXT NULL COMMENT '[听力, 阅读] 答对题数，JSON 格式',
+ADD COLUMN bands TEXT NULL COMMENT '[听力, 阅读] Band 分，JSON 格式';
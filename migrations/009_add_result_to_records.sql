@@ -0,0 +1,8 @@
+-- Migration: Store per-question result breakdown on submitted records
+-- Created: 2026-10-19
+
+ALTER TABLE listening_records
+ADD COLUMN result JSON NULL COMMENT '逐题评分结果：原始分、Band 分、每道题的作答与正确答案';
+
+ALTER TABLE reading_records
+ADD COLUMN result JSON NULL COMMENT '逐题评分结果：原始分、Band 分、每道题的作答与正确答案';
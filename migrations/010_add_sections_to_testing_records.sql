@@ -0,0 +1,6 @@
+-- Migration: Store per-section scores on testing records
+-- Created: 2026-10-19
+
+-- answers 改为按科目和 part 分组：[{skill, part_id, part_name, answer_list, text}]
+ALTER TABLE testing_records
+ADD COLUMN sections JSON NULL COMMENT '各科目成绩：原始分、Band 分、未作答题号、逐题评分结果';
@@ -0,0 +1,10 @@
+-- Migration: Store overall band on testing records
+-- Created: 2026-10-19
+
+ALTER TABLE testing_records
+ADD COLUMN overall_band DECIMAL(2,1) NULL COMMENT '综合分，写作/口语未评分时为暂定分',
+ADD COLUMN overall JSON NULL COMMENT '综合分详情：各科目 Band 分、状态（provisional/final）、待评分科目';
+
+-- 综合分根据套题是否包含口语决定是否等待口语评分
+ALTER TABLE testing_list
+ADD COLUMN speaking_ids JSON NULL COMMENT '口语 part ID，为空表示套题不含口语';
@@ -0,0 +1,39 @@
+-- Migration: Add speaking parts, sets and records
+-- Created: 2026-10-19
+
+CREATE TABLE IF NOT EXISTS speaking_part_list (
+    id INT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(10) NULL COMMENT '数据来源：1=系统，2=官方，3=用户',
+    part_type VARCHAR(10) NOT NULL COMMENT '口语 part：1 / 2 / 3',
+    topic VARCHAR(255) NULL COMMENT '话题',
+    question_list JSON NULL COMMENT 'Part 1、Part 3 问题：[{no, question}]',
+    cue_card JSON NULL COMMENT 'Part 2 话题卡：{topic, bullet_points, follow_up, prep_seconds, speak_seconds}',
+    user_id VARCHAR(255) NULL COMMENT '创建者ID',
+    INDEX idx_user_id (user_id)
+);
+
+CREATE TABLE IF NOT EXISTS speaking_list (
+    id INT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    status INT NULL,
+    type INT NULL,
+    part_list JSON NULL COMMENT 'Part 1、Part 2、Part 3 的 part ID',
+    user_id VARCHAR(255) NULL COMMENT '创建者ID',
+    INDEX idx_user_id (user_id)
+);
+
+CREATE TABLE IF NOT EXISTS speaking_records (
+    id INT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    status VARCHAR(10) NULL,
+    type VARCHAR(10) NULL,
+    speaking_id INT NULL COMMENT '口语套题ID',
+    answers JSON NULL COMMENT '录音：[{part_id, no, audio_url, duration}]',
+    band DECIMAL(2,1) NULL COMMENT '口语 Band 分，评分完成后填入',
+    user_id VARCHAR(255) NULL,
+    rest_seconds INT NULL,
+    INDEX idx_user_id (user_id)
+);
+
+-- testing_list.speaking_ids 由 011 添加（综合分需要读取）
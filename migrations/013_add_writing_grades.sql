@@ -0,0 +1,19 @@
+-- Migration: Examiner grading for writing
+-- Created: 2026-10-19
+
+CREATE TABLE IF NOT EXISTS writing_grades (
+    id INT NOT NULL PRIMARY KEY,
+    record_type VARCHAR(32) NOT NULL COMMENT '记录类型：writing / testing',
+    record_id INT NOT NULL,
+    grader_id VARCHAR(255) NOT NULL COMMENT '评分老师ID',
+    tasks JSON NOT NULL COMMENT '每篇作文的四项评分、Band 分和批注',
+    band DOUBLE NOT NULL DEFAULT 0 COMMENT '写作总分，Task 2 权重为 Task 1 的两倍',
+    status VARCHAR(32) NOT NULL DEFAULT 'draft' COMMENT 'draft / final',
+    updated_at DATETIME NULL,
+    UNIQUE KEY uk_writing_grades_record_grader (record_type, record_id, grader_id)
+);
+
+-- 写作记录：作文改为 [{part_id, text}]，记录所属套题和评分后的 Band 分
+ALTER TABLE writing_records
+ADD COLUMN test_id INT NULL COMMENT '写作套题ID',
+ADD COLUMN band DECIMAL(2,1) NULL COMMENT '写作 Band 分，老师评分后填入';
@@ -0,0 +1,19 @@
+-- Migration: Grading queue for writing and speaking
+-- Created: 2026-10-19
+
+CREATE TABLE IF NOT EXISTS grading_queue (
+    id INT NOT NULL PRIMARY KEY,
+    record_type VARCHAR(32) NOT NULL COMMENT '记录类型：writing / speaking / testing',
+    record_id INT NOT NULL,
+    skill VARCHAR(32) NOT NULL COMMENT '评分科目：writing / speaking',
+    user_id VARCHAR(255) NOT NULL COMMENT '学生ID',
+    grader_id VARCHAR(255) NULL COMMENT '评分老师ID',
+    status VARCHAR(32) NOT NULL DEFAULT 'pending' COMMENT 'pending / assigned / completed',
+    submitted_at DATETIME NOT NULL,
+    assigned_at DATETIME NULL,
+    due_at DATETIME NOT NULL COMMENT '评分截止时间（提交后 48 小时）',
+    completed_at DATETIME NULL,
+    INDEX idx_grading_queue_record (record_type, record_id, skill),
+    INDEX idx_grading_queue_status (status, due_at),
+    INDEX idx_grading_queue_grader (grader_id, status)
+);
@@ -0,0 +1,28 @@
+-- Migration: Grader calibration and double marking
+-- Created: 2026-10-19
+
+CREATE TABLE IF NOT EXISTS calibration_essays (
+    id INT NOT NULL PRIMARY KEY,
+    part_id INT NOT NULL COMMENT '写作题目ID',
+    task_type VARCHAR(10) NOT NULL COMMENT '1=Task1，2=Task2',
+    text TEXT NOT NULL COMMENT '作文内容',
+    reference JSON NOT NULL COMMENT '四项参考评分',
+    reference_band DOUBLE NOT NULL COMMENT '参考分数',
+    created_by VARCHAR(255) NULL
+);
+
+CREATE TABLE IF NOT EXISTS calibration_assignments (
+    id INT NOT NULL PRIMARY KEY,
+    essay_id INT NOT NULL,
+    grader_id VARCHAR(255) NOT NULL,
+    scores JSON NULL COMMENT '老师的四项评分',
+    band DOUBLE NULL,
+    status VARCHAR(32) NOT NULL DEFAULT 'pending' COMMENT 'pending / completed',
+    assigned_at DATETIME NOT NULL,
+    completed_at DATETIME NULL,
+    UNIQUE KEY uk_calibration_essay_grader (essay_id, grader_id)
+);
+
+-- 评分人序号：1=第一评分人，2=双评的第二评分人，3=分歧仲裁
+ALTER TABLE grading_queue
+ADD COLUMN marker_no INT NOT NULL DEFAULT 1 COMMENT '评分人序号' AFTER skill;
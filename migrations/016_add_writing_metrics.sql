@@ -0,0 +1,9 @@
+-- Migration: Word count and time spent per writing task
+-- Created: 2026-10-19
+
+-- [{part_id, task_type, word_count, min_words, under_length, seconds_spent}]
+ALTER TABLE writing_records
+ADD COLUMN metrics JSON NULL COMMENT '每篇作文的字数、是否字数不足和用时';
+
+ALTER TABLE testing_records
+ADD COLUMN writing_metrics JSON NULL COMMENT '写作每篇的字数、是否字数不足和用时';
@@ -0,0 +1,24 @@
+-- Migration: AI feedback for writing submissions
+-- Created: 2026-10-19
+
+-- Task 1 图表的文字描述，AI 无法直接查看图片
+ALTER TABLE writing_part_list
+ADD COLUMN img_description TEXT NULL COMMENT 'Task 1 图表的文字描述';
+
+CREATE TABLE IF NOT EXISTS ai_writing_feedback (
+    id INT NOT NULL PRIMARY KEY,
+    record_type VARCHAR(32) NOT NULL COMMENT '记录类型：writing / testing',
+    record_id INT NOT NULL,
+    user_id VARCHAR(255) NOT NULL COMMENT '记录所属用户',
+    source VARCHAR(16) NOT NULL DEFAULT 'ai' COMMENT '固定为 ai，与老师评分区分',
+    status VARCHAR(32) NOT NULL DEFAULT 'pending' COMMENT 'pending / processing / done / failed',
+    provider VARCHAR(64) NULL,
+    model VARCHAR(128) NULL,
+    tasks JSON NULL COMMENT '每篇作文的四项评分和说明、改错、词汇替换建议',
+    band DOUBLE NULL COMMENT '预估写作分数',
+    error TEXT NULL,
+    total_tokens INT NOT NULL DEFAULT 0,
+    created_at DATETIME NULL,
+    updated_at DATETIME NULL,
+    UNIQUE KEY uk_ai_writing_feedback_record (record_type, record_id)
+);
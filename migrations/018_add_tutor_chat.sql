@@ -0,0 +1,27 @@
+-- Migration: AI tutor conversations
+-- Created: 2026-10-19
+
+CREATE TABLE IF NOT EXISTS tutor_conversations (
+    id INT NOT NULL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    skill VARCHAR(32) NOT NULL COMMENT 'listening / reading / writing / speaking',
+    part_id INT NOT NULL,
+    record_type VARCHAR(32) NULL COMMENT '关联的做题记录类型',
+    record_id INT NULL,
+    question_no VARCHAR(32) NULL,
+    context MEDIUMTEXT NULL COMMENT '题目、原文和作答内容',
+    created_at DATETIME NULL,
+    updated_at DATETIME NULL,
+    INDEX idx_tutor_conversations_user (user_id, updated_at)
+);
+
+CREATE TABLE IF NOT EXISTS tutor_messages (
+    id INT NOT NULL PRIMARY KEY,
+    conversation_id INT NOT NULL,
+    role VARCHAR(16) NOT NULL COMMENT 'user / assistant',
+    content MEDIUMTEXT NOT NULL,
+    total_tokens INT NOT NULL DEFAULT 0,
+    created_at DATETIME NULL,
+    INDEX idx_tutor_messages_conversation (conversation_id, created_at)
+);
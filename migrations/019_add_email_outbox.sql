@@ -0,0 +1,19 @@
+-- Migration: Email outbox
+-- Created: 2026-10-19
+
+CREATE TABLE IF NOT EXISTS email_outbox (
+    id INT NOT NULL PRIMARY KEY,
+    template VARCHAR(64) NOT NULL COMMENT '模板名称：verification_code / grading_finished / assignment_due',
+    locale VARCHAR(16) NOT NULL DEFAULT 'zh',
+    to_email VARCHAR(255) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    html_body MEDIUMTEXT NOT NULL,
+    text_body MEDIUMTEXT NOT NULL,
+    status VARCHAR(32) NOT NULL DEFAULT 'pending' COMMENT 'pending / sent / failed',
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NULL,
+    created_at DATETIME NOT NULL,
+    enqueued_at DATETIME NULL COMMENT '加入任务队列的时间，为空时由 worker 重新加入',
+    sent_at DATETIME NULL,
+    INDEX idx_email_outbox_status (status, enqueued_at)
+);
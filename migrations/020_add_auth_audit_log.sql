@@ -0,0 +1,14 @@
+-- Migration: Auth audit log
+-- Created: 2026-10-19
+
+CREATE TABLE IF NOT EXISTS auth_audit_log (
+    id INT NOT NULL PRIMARY KEY,
+    event VARCHAR(64) NOT NULL COMMENT 'otp_sent / otp_throttled / otp_verified / otp_failed / otp_locked',
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    user_id VARCHAR(36) NOT NULL DEFAULT '',
+    detail VARCHAR(255) NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL,
+    INDEX idx_auth_audit_email (email, created_at),
+    INDEX idx_auth_audit_event (event, created_at)
+);
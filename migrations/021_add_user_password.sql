@@ -0,0 +1,6 @@
+-- Migration: Optional password accounts on user_list
+-- Created: 2026-10-19
+
+ALTER TABLE user_list
+    ADD COLUMN password_hash VARCHAR(255) NULL COMMENT 'bcrypt 哈希，为空表示只使用邮箱验证码登录',
+    ADD COLUMN password_updated_at DATETIME NULL;
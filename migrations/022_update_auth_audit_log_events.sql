@@ -0,0 +1,5 @@
+-- Migration: Auth audit log records password and session events as well as OTP events
+-- Created: 2026-10-19
+
+ALTER TABLE auth_audit_log
+    MODIFY COLUMN event VARCHAR(64) NOT NULL COMMENT 'otp_* / password_* / session 事件';
@@ -0,0 +1,5 @@
+-- Migration: Preferred email locale on user_list
+-- Created: 2026-10-19
+
+ALTER TABLE user_list
+    ADD COLUMN locale VARCHAR(16) NULL COMMENT '通知邮件语言 zh / en，登录时根据 Accept-Language 记录';
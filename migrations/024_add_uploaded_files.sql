@@ -0,0 +1,12 @@
+-- Migration: Uploaded files and their uploaders
+-- Created: 2026-10-19
+
+-- 记录 /upload/file 上传的文件，只有上传者和有 content:manage 权限的用户可以删除
+-- 本表之前上传的文件没有记录，只能由有 content:manage 权限的用户删除
+CREATE TABLE IF NOT EXISTS uploaded_files (
+    id INT NOT NULL PRIMARY KEY,
+    url VARCHAR(512) NOT NULL COMMENT '文件访问 URL',
+    user_id VARCHAR(255) NOT NULL COMMENT '上传者',
+    created_at DATETIME NOT NULL,
+    UNIQUE KEY uk_uploaded_files_url (url)
+);
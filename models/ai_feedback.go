@@ -0,0 +1,69 @@
+package models
+
+// FeedbackSourceAI AI 生成的批改
+const FeedbackSourceAI = "ai"
+
+// AI 批改状态
+const (
+	AIFeedbackPending    = "pending"    // 等待批改
+	AIFeedbackProcessing = "processing" // 批改中
+	AIFeedbackDone       = "done"       // 已完成
+	AIFeedbackFailed     = "failed"     // 失败，可重新批改
+)
+
+// AICriterionFeedback 单项评分标准的 AI 评分和说明
+type AICriterionFeedback struct {
+	Band        float64 `json:"band"`
+	Explanation string  `json:"explanation"`
+}
+
+// AICriteriaFeedback 四项评分标准的 AI 评分
+type AICriteriaFeedback struct {
+	TaskAchievement   AICriterionFeedback `json:"task_achievement"`
+	CoherenceCohesion AICriterionFeedback `json:"coherence_cohesion"`
+	LexicalResource   AICriterionFeedback `json:"lexical_resource"`
+	GrammaticalRange  AICriterionFeedback `json:"grammatical_range"`
+}
+
+// AICorrection 改错：原句必须出现在作文中
+type AICorrection struct {
+	Original    string `json:"original"`
+	Corrected   string `json:"corrected"`
+	Explanation string `json:"explanation"`
+}
+
+// AIVocabularyUpgrade 词汇替换建议
+type AIVocabularyUpgrade struct {
+	Original   string `json:"original"`
+	Suggestion string `json:"suggestion"`
+	Example    string `json:"example,omitempty"`
+}
+
+// AITaskFeedback 单篇作文的 AI 批改
+type AITaskFeedback struct {
+	PartID      int                   `json:"part_id"`
+	TaskType    string                `json:"task_type"`
+	Criteria    AICriteriaFeedback    `json:"criteria"`
+	Band        float64               `json:"band"` // 与老师评分相同的算法：四项平均后向下取半分
+	Summary     string                `json:"summary"`
+	Corrections []AICorrection        `json:"corrections"`
+	Vocabulary  []AIVocabularyUpgrade `json:"vocabulary"`
+}
+
+// AIWritingFeedbackItem 一条写作记录的 AI 批改，与老师评分分开保存，不影响记录分数
+type AIWritingFeedbackItem struct {
+	ID          int              `json:"id,omitempty"`
+	RecordType  string           `json:"record_type"` // writing / testing
+	RecordID    int              `json:"record_id"`
+	UserID      string           `json:"user_id,omitempty"`
+	Source      string           `json:"source"` // 固定为 ai，用于和老师评分区分
+	Status      string           `json:"status"`
+	Provider    string           `json:"provider,omitempty"`
+	Model       string           `json:"model,omitempty"`
+	Tasks       []AITaskFeedback `json:"tasks"`
+	Band        *float64         `json:"band"` // 预估写作分数
+	Error       string           `json:"error,omitempty"`
+	TotalTokens int              `json:"total_tokens"`
+	CreatedAt   string           `json:"created_at,omitempty"`
+	UpdatedAt   string           `json:"updated_at,omitempty"`
+}
@@ -0,0 +1,33 @@
+package models
+
+// 认证审计事件
+const (
+	AuditOTPSent      = "otp_sent"      // 验证码已发送
+	AuditOTPThrottled = "otp_throttled" // 发送过于频繁被拒绝
+	AuditOTPVerified  = "otp_verified"  // 验证码校验通过
+	AuditOTPFailed    = "otp_failed"    // 验证码错误或已失效
+	AuditOTPLocked    = "otp_locked"    // 错误次数过多被锁定
+
+	AuditPasswordRegistered     = "password_registered"      // 注册（设置）密码
+	AuditPasswordLogin          = "password_login"           // 密码登录成功
+	AuditPasswordFailed         = "password_failed"          // 密码错误
+	AuditPasswordLocked         = "password_locked"          // 密码错误次数过多被锁定
+	AuditPasswordResetRequested = "password_reset_requested" // 申请重置密码
+	AuditPasswordReset          = "password_reset"           // 通过邮件重置密码
+	AuditPasswordChanged        = "password_changed"         // 登录后修改密码
+
+	AuditLogout         = "logout"          // 退出登录
+	AuditSessionRevoked = "session_revoked" // 在会话列表中注销其他会话
+	AuditRefreshReused  = "refresh_reused"  // 已使用过的 refresh token 被再次使用，会话被注销
+)
+
+// AuthAuditItem 认证审计日志
+type AuthAuditItem struct {
+	ID        int    `json:"id,omitempty"`
+	Event     string `json:"event"`
+	Email     string `json:"email"`
+	IP        string `json:"ip"`
+	UserID    string `json:"user_id"`
+	Detail    string `json:"detail"`
+	CreatedAt string `json:"created_at"`
+}
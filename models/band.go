@@ -0,0 +1,46 @@
+package models
+
+// 科目
+const (
+	SkillListening = "listening"
+	SkillReading   = "reading"
+	SkillWriting   = "writing"
+	SkillSpeaking  = "speaking"
+)
+
+// 阅读考试类型（存储在阅读套题和测试套题的 module 字段）
+const (
+	ModuleAcademic = "academic" // 学术类
+	ModuleGeneral  = "general"  // 培训类（General Training）
+)
+
+// BandRow 分数换算表的一行：答对题数 >= MinRaw 时对应 Band 分
+type BandRow struct {
+	MinRaw int     `json:"min_raw"`
+	Band   float64 `json:"band"`
+}
+
+// BandTableItem 分数换算表，听力不区分 module
+type BandTableItem struct {
+	ID         int       `json:"id,omitempty"`
+	Skill      string    `json:"skill"`
+	Module     string    `json:"module"`
+	Conversion []BandRow `json:"conversion"`
+}
+
+// 综合分状态
+const (
+	OverallProvisional = "provisional" // 仍有科目未评分，综合分为暂定
+	OverallFinal       = "final"       // 所有科目均已评分
+)
+
+// OverallBandResult 模考综合分：各科目 Band 分的平均值，按官方规则取最接近的半分
+type OverallBandResult struct {
+	Listening *float64 `json:"listening"`
+	Reading   *float64 `json:"reading"`
+	Writing   *float64 `json:"writing"`  // 老师或 AI 评分后填入
+	Speaking  *float64 `json:"speaking"` // 口语为可选科目
+	Band      *float64 `json:"band"`     // 暂定时按已评分的科目计算
+	Status    string   `json:"status"`
+	Pending   []string `json:"pending,omitempty"` // 尚未评分的科目
+}
@@ -0,0 +1,9 @@
+package models
+
+// UploadedFileItem 上传文件的记录，用于删除时检查上传者
+type UploadedFileItem struct {
+	ID        int    `json:"id,omitempty"`
+	URL       string `json:"url"`
+	UserID    string `json:"user_id"`
+	CreatedAt string `json:"created_at"`
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 )
 
 // FlexInt is a custom type that can unmarshal both int and string values from JSON
@@ -42,3 +43,107 @@ func (f FlexInt) MarshalJSON() ([]byte, error) {
 func (f FlexInt) Int() int {
 	return int(f)
 }
+
+// AnswerValue 答案的原始值，兼容字符串、数字、布尔值以及它们组成的数组
+// 所有值统一转换为字符串，避免 1 和 "1" 比较不相等
+type AnswerValue struct {
+	Values []string
+	IsList bool
+}
+
+// NewAnswerValue 从 JSON 解析出的任意值构造 AnswerValue
+func NewAnswerValue(raw interface{}) AnswerValue {
+	switch v := raw.(type) {
+	case nil:
+		return AnswerValue{}
+	case AnswerValue:
+		return v
+	case []interface{}:
+		value := AnswerValue{Values: []string{}, IsList: true}
+		for _, item := range v {
+			if item != nil {
+				value.Values = append(value.Values, scalarToString(item))
+			}
+		}
+		return value
+	case []string:
+		return AnswerValue{Values: v, IsList: true}
+	default:
+		return AnswerValue{Values: []string{scalarToString(v)}}
+	}
+}
+
+func scalarToString(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(val), 'f', -1, 32)
+	case int:
+		return strconv.Itoa(val)
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case bool:
+		return strconv.FormatBool(val)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (a *AnswerValue) UnmarshalJSON(data []byte) error {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw.(map[string]interface{}); ok {
+		// 带题型标识的答案 {"type": "...", "payload": {...}}
+		var typed TypedAnswer
+		if err := json.Unmarshal(data, &typed); err != nil {
+			return fmt.Errorf("cannot unmarshal object to AnswerValue: %w", err)
+		}
+		*a = EncodeAnswer(typed.Answer)
+		return nil
+	}
+	*a = NewAnswerValue(raw)
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface
+// 数组保持数组格式，单个值输出为字符串
+func (a AnswerValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.Interface())
+}
+
+// Interface 返回用于 JSON 序列化的值
+func (a AnswerValue) Interface() interface{} {
+	if a.IsList {
+		values := a.Values
+		if values == nil {
+			values = []string{}
+		}
+		return values
+	}
+	if len(a.Values) == 0 {
+		return nil
+	}
+	return a.Values[0]
+}
+
+// Trimmed 返回去掉首尾空白且非空的答案值
+func (a AnswerValue) Trimmed() []string {
+	var values []string
+	for _, v := range a.Values {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// IsEmpty 判断是否未作答
+func (a AnswerValue) IsEmpty() bool {
+	return len(a.Trimmed()) == 0
+}
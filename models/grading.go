@@ -0,0 +1,153 @@
+package models
+
+// 写作评分标准
+const (
+	CriterionTaskAchievement   = "task_achievement"   // Task 1 为 Task Achievement，Task 2 为 Task Response
+	CriterionCoherenceCohesion = "coherence_cohesion" // Coherence and Cohesion
+	CriterionLexicalResource   = "lexical_resource"   // Lexical Resource
+	CriterionGrammaticalRange  = "grammatical_range"  // Grammatical Range and Accuracy
+)
+
+// 评分对应的记录类型
+const (
+	GradingRecordWriting  = "writing"  // writing_records
+	GradingRecordTesting  = "testing"  // testing_records 中的写作、口语部分
+	GradingRecordSpeaking = "speaking" // speaking_records
+)
+
+// 评分状态
+const (
+	GradeDraft = "draft" // 草稿，不影响记录分数
+	GradeFinal = "final" // 已完成，分数写回记录
+)
+
+// CriteriaScores 四项评分标准的分数（0-9 整数分）
+type CriteriaScores struct {
+	TaskAchievement   float64 `json:"task_achievement"`
+	CoherenceCohesion float64 `json:"coherence_cohesion"`
+	LexicalResource   float64 `json:"lexical_resource"`
+	GrammaticalRange  float64 `json:"grammatical_range"`
+}
+
+// InlineComment 作文中的批注，Start/End 为字符（rune）下标，左闭右开
+type InlineComment struct {
+	Start     int    `json:"start"`
+	End       int    `json:"end"`
+	Criterion string `json:"criterion,omitempty"` // 批注对应的评分标准
+	Text      string `json:"text"`
+}
+
+// TaskGrade 单篇作文的评分
+type TaskGrade struct {
+	PartID   int             `json:"part_id"`
+	TaskType string          `json:"task_type"` // 1=Task1，2=Task2
+	Scores   CriteriaScores  `json:"scores"`
+	Band     float64         `json:"band"` // 四项平均后向下取半分
+	Comments []InlineComment `json:"comments"`
+	Feedback string          `json:"feedback,omitempty"`
+}
+
+// WritingGradeItem 老师对一条写作记录的评分
+type WritingGradeItem struct {
+	ID         int         `json:"id,omitempty"`
+	RecordType string      `json:"record_type"` // writing / testing
+	RecordID   int         `json:"record_id"`
+	GraderID   string      `json:"grader_id,omitempty"`
+	Tasks      []TaskGrade `json:"tasks"`
+	Band       float64     `json:"band"` // Task 2 权重为 Task 1 的两倍
+	Status     string      `json:"status"`
+	UpdatedAt  string      `json:"updated_at,omitempty"`
+}
+
+// WritingEssay 待评分的作文
+type WritingEssay struct {
+	PartID    int    `json:"part_id"`
+	TaskType  string `json:"task_type"`
+	Title     string `json:"title"`
+	SubTitle  string `json:"sub_title,omitempty"`
+	ImgDesc   string `json:"img_description,omitempty"` // Task 1 图表的文字描述
+	Text      string `json:"text"`
+	WordCount int    `json:"word_count"`
+}
+
+// 评分人序号
+const (
+	FirstMarker      = 1
+	SecondMarker     = 2
+	ResolutionMarker = 3
+)
+
+// 评分队列状态
+const (
+	QueuePending   = "pending"   // 待分配
+	QueueAssigned  = "assigned"  // 已分配给老师
+	QueueCompleted = "completed" // 评分完成
+)
+
+// GradingQueueItem 评分队列中的一项：一条记录中需要老师评分的一个科目
+type GradingQueueItem struct {
+	ID          int     `json:"id,omitempty"`
+	RecordType  string  `json:"record_type"` // writing / speaking / testing
+	RecordID    int     `json:"record_id"`
+	Skill       string  `json:"skill"`     // writing / speaking
+	MarkerNo    int     `json:"marker_no"` // 1=第一评分人，2=双评的第二评分人，3=分歧仲裁
+	UserID      string  `json:"user_id"`   // 学生ID
+	GraderID    *string `json:"grader_id"`
+	Status      string  `json:"status"`
+	SubmittedAt string  `json:"submitted_at"`
+	AssignedAt  *string `json:"assigned_at"`
+	DueAt       string  `json:"due_at"`
+	CompletedAt *string `json:"completed_at"`
+}
+
+// SpeakingGradeItem 口语评分
+type SpeakingGradeItem struct {
+	RecordType string  `json:"record_type"` // speaking / testing
+	RecordID   int     `json:"record_id"`
+	Band       float64 `json:"band"`
+}
+
+// GradingQueueView 评分队列列表项，附带等待时长和是否超时
+type GradingQueueView struct {
+	GradingQueueItem
+	AgeHours float64 `json:"age_hours"` // 提交至今的小时数
+	Overdue  bool    `json:"overdue"`
+}
+
+// CalibrationEssayItem 校准作文：管理员给出的参考评分
+type CalibrationEssayItem struct {
+	ID            int            `json:"id,omitempty"`
+	PartID        int            `json:"part_id"` // 写作题目
+	TaskType      string         `json:"task_type"`
+	Text          string         `json:"text"`
+	Reference     CriteriaScores `json:"reference"`      // 参考评分
+	ReferenceBand float64        `json:"reference_band"` // 参考分数，由四项评分计算
+	CreatedBy     string         `json:"created_by,omitempty"`
+}
+
+// CalibrationAssignmentItem 分配给老师的校准任务，老师评分时看不到参考评分
+type CalibrationAssignmentItem struct {
+	ID          int             `json:"id,omitempty"`
+	EssayID     int             `json:"essay_id"`
+	GraderID    string          `json:"grader_id"`
+	Scores      *CriteriaScores `json:"scores"`
+	Band        *float64        `json:"band"`
+	Status      string          `json:"status"` // pending / completed
+	AssignedAt  string          `json:"assigned_at"`
+	CompletedAt *string         `json:"completed_at"`
+}
+
+// CriterionStats 老师某项标准与参考评分的偏差
+type CriterionStats struct {
+	MeanDeviation    float64 `json:"mean_deviation"`     // 平均偏差，正数表示偏高
+	MeanAbsDeviation float64 `json:"mean_abs_deviation"` // 平均绝对偏差
+	AgreementRate    float64 `json:"agreement_rate"`     // 与参考评分一致（相差不超过半分）的比例
+}
+
+// GraderCalibrationReport 老师的校准报告
+type GraderCalibrationReport struct {
+	GraderID  string                    `json:"grader_id"`
+	Completed int                       `json:"completed"`
+	Criteria  map[string]CriterionStats `json:"criteria"`
+	Band      CriterionStats            `json:"band"`
+}
@@ -1,5 +1,7 @@
 package models
 
+import "encoding/json"
+
 type ListeningItem struct {
 	ID 					int					`json:"id,omitempty"`
 	Name				string				`json:"name"`
@@ -37,11 +39,29 @@ type ListeningTypeItem struct {
 	QuestionList		[]ListeningQuestionItem	`json:"question_list"`
 }
 
+// UnmarshalJSON 按题组题型解析每道题的答案，答案不符合题型时返回错误，并把答案规范化为存储格式
+func (t *ListeningTypeItem) UnmarshalJSON(data []byte) error {
+	type plain ListeningTypeItem
+	if err := json.Unmarshal(data, (*plain)(t)); err != nil {
+		return err
+	}
+	for i := range t.QuestionList {
+		question := &t.QuestionList[i]
+		answer, typed, err := typeAnswer(t.Type, question.No, question.Answer)
+		if err != nil {
+			return err
+		}
+		question.Answer, question.Typed = answer, typed
+	}
+	return nil
+}
+
 type ListeningQuestionItem struct {
 	No                  string			`json:"no"`
 	Question			string			`json:"question"`
 	Options				[]OptionsItem	`json:"options,omitempty"`
-	Answer				interface{}		`json:"answer"`
+	Answer				AnswerValue		`json:"answer"`
+	Typed				TypedAnswer		`json:"-"`			// 按题组题型解析后的答案
 }
 
 type OptionsItem struct {
@@ -65,6 +85,9 @@ type ListeningRecordsItem struct {
 	Status				string						`json:"status,omitempty"`
 	Type				string						`json:"type,omitempty"`
 	Score				int							`json:"score,omitempty"`
+	RawScore			int							`json:"raw_score"`
+	Band				float64						`json:"band"`
+	Result				*ScoreResult				`json:"result,omitempty"`	// 提交后的逐题评分结果
 	Answers				[]AnswerItem				`json:"answers"`
 	UserID				string						`json:"user_id,omitempty"`
 	RestSeconds			int							`json:"rest_seconds,omitempty"`
@@ -73,5 +96,5 @@ type ListeningRecordsItem struct {
 
 type AnswerItem struct {
 	No					string				`json:"no"`
-	Answer				interface{}			`json:"answer"`
+	Answer				AnswerValue			`json:"answer"`
 }
\ No newline at end of file
@@ -0,0 +1,368 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// QuestionType 题型
+type QuestionType string
+
+const (
+	QuestionGapFill      QuestionType = "gap_fill"      // 填空
+	QuestionSingleChoice QuestionType = "single_choice" // 单选
+	QuestionMultiChoice  QuestionType = "multi_choice"  // 多选（选 N 项）
+	QuestionMatching     QuestionType = "matching"      // 匹配
+	QuestionMapLabelling QuestionType = "map_labelling" // 地图/平面图标注
+	QuestionTFNG         QuestionType = "tfng"          // TRUE / FALSE / NOT GIVEN
+	QuestionYNNG         QuestionType = "ynng"          // YES / NO / NOT GIVEN
+	QuestionHeadings     QuestionType = "headings"      // 段落标题匹配
+)
+
+// 历史数据中出现过的题型名称
+var questionTypeAliases = map[string]QuestionType{
+	"gap_fill":             QuestionGapFill,
+	"gap_filling":          QuestionGapFill,
+	"fill":                 QuestionGapFill,
+	"fill_blank":           QuestionGapFill,
+	"fill_in_blank":        QuestionGapFill,
+	"fill_in_blanks":       QuestionGapFill,
+	"blank":                QuestionGapFill,
+	"completion":           QuestionGapFill,
+	"single_choice":        QuestionSingleChoice,
+	"single":               QuestionSingleChoice,
+	"choice":               QuestionSingleChoice,
+	"multi_choice":         QuestionMultiChoice,
+	"multiple_choice":      QuestionMultiChoice,
+	"multi":                QuestionMultiChoice,
+	"matching":             QuestionMatching,
+	"match":                QuestionMatching,
+	"map_labelling":        QuestionMapLabelling,
+	"map_labeling":         QuestionMapLabelling,
+	"map":                  QuestionMapLabelling,
+	"plan":                 QuestionMapLabelling,
+	"diagram":              QuestionMapLabelling,
+	"tfng":                 QuestionTFNG,
+	"true_false_not_given": QuestionTFNG,
+	"true_false":           QuestionTFNG,
+	"ynng":                 QuestionYNNG,
+	"yes_no_not_given":     QuestionYNNG,
+	"yes_no":               QuestionYNNG,
+	"headings":             QuestionHeadings,
+	"heading":              QuestionHeadings,
+	"matching_headings":    QuestionHeadings,
+	"list_of_headings":     QuestionHeadings,
+}
+
+// ParseQuestionType 将题型名称（包括历史别名）解析为标准题型
+func ParseQuestionType(name string) (QuestionType, bool) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	key = strings.NewReplacer("-", "_", " ", "_", "/", "_").Replace(key)
+	questionType, ok := questionTypeAliases[key]
+	return questionType, ok
+}
+
+// QuestionAnswer 题目答案，正确答案与用户答案使用相同的结构
+type QuestionAnswer interface {
+	QuestionType() QuestionType
+	// Values 返回用于计分比较的答案值
+	Values() []string
+}
+
+// GapFillAnswer 填空题答案，正确答案中可用 "/" 分隔多个可接受的写法
+type GapFillAnswer struct {
+	Text string `json:"text"`
+}
+
+// SingleChoiceAnswer 单选题答案
+type SingleChoiceAnswer struct {
+	Option string `json:"option"`
+}
+
+// MultiChoiceAnswer 多选题答案，正确答案的选项个数即为需要选择的个数
+type MultiChoiceAnswer struct {
+	Options []string `json:"options"`
+}
+
+// MatchingAnswer 匹配题答案
+type MatchingAnswer struct {
+	Option string `json:"option"`
+}
+
+// MapLabellingAnswer 地图/平面图标注题答案
+type MapLabellingAnswer struct {
+	Label string `json:"label"`
+}
+
+// TFNGAnswer TRUE / FALSE / NOT GIVEN 判断题答案
+type TFNGAnswer struct {
+	Value string `json:"value"`
+}
+
+// YNNGAnswer YES / NO / NOT GIVEN 判断题答案
+type YNNGAnswer struct {
+	Value string `json:"value"`
+}
+
+// HeadingAnswer 段落标题匹配题答案（如 i、iv）
+type HeadingAnswer struct {
+	Heading string `json:"heading"`
+}
+
+func (a GapFillAnswer) QuestionType() QuestionType      { return QuestionGapFill }
+func (a SingleChoiceAnswer) QuestionType() QuestionType { return QuestionSingleChoice }
+func (a MultiChoiceAnswer) QuestionType() QuestionType  { return QuestionMultiChoice }
+func (a MatchingAnswer) QuestionType() QuestionType     { return QuestionMatching }
+func (a MapLabellingAnswer) QuestionType() QuestionType { return QuestionMapLabelling }
+func (a TFNGAnswer) QuestionType() QuestionType         { return QuestionTFNG }
+func (a YNNGAnswer) QuestionType() QuestionType         { return QuestionYNNG }
+func (a HeadingAnswer) QuestionType() QuestionType      { return QuestionHeadings }
+
+func (a GapFillAnswer) Values() []string      { return []string{a.Text} }
+func (a SingleChoiceAnswer) Values() []string { return []string{a.Option} }
+func (a MultiChoiceAnswer) Values() []string  { return a.Options }
+func (a MatchingAnswer) Values() []string     { return []string{a.Option} }
+func (a MapLabellingAnswer) Values() []string { return []string{a.Label} }
+func (a TFNGAnswer) Values() []string         { return []string{a.Value} }
+func (a YNNGAnswer) Values() []string         { return []string{a.Value} }
+func (a HeadingAnswer) Values() []string      { return []string{a.Heading} }
+
+var judgementValues = map[QuestionType]map[string]bool{
+	QuestionTFNG: {"TRUE": true, "FALSE": true, "NOT GIVEN": true},
+	QuestionYNNG: {"YES": true, "NO": true, "NOT GIVEN": true},
+}
+
+// 判断题答案的常见缩写
+var judgementAliases = map[QuestionType]map[string]string{
+	QuestionTFNG: {"T": "TRUE", "F": "FALSE", "NG": "NOT GIVEN", "N/G": "NOT GIVEN", "N.G.": "NOT GIVEN", "NOTGIVEN": "NOT GIVEN"},
+	QuestionYNNG: {"Y": "YES", "N": "NO", "NG": "NOT GIVEN", "N/G": "NOT GIVEN", "N.G.": "NOT GIVEN", "NOTGIVEN": "NOT GIVEN"},
+}
+
+// DecodeAnswer 根据题型把存储中的原始答案转换为对应的答案结构
+func DecodeAnswer(questionType QuestionType, value AnswerValue) (QuestionAnswer, error) {
+	values := value.Trimmed()
+
+	single := func() (string, error) {
+		if len(values) > 1 {
+			return "", fmt.Errorf("%s answer expects a single value, got %d", questionType, len(values))
+		}
+		if len(values) == 0 {
+			return "", nil
+		}
+		return values[0], nil
+	}
+
+	switch questionType {
+	case QuestionGapFill:
+		return GapFillAnswer{Text: strings.Join(values, "/")}, nil
+	case QuestionMultiChoice:
+		seen := make(map[string]bool)
+		options := []string{}
+		if len(values) == 1 && strings.Contains(values[0], ",") {
+			values = strings.Split(values[0], ",")
+		}
+		for _, v := range values {
+			v = strings.ToUpper(strings.TrimSpace(v))
+			if v != "" && !seen[v] {
+				seen[v] = true
+				options = append(options, v)
+			}
+		}
+		return MultiChoiceAnswer{Options: options}, nil
+	case QuestionSingleChoice, QuestionMatching, QuestionMapLabelling:
+		v, err := single()
+		if err != nil {
+			return nil, err
+		}
+		v = strings.ToUpper(v)
+		switch questionType {
+		case QuestionSingleChoice:
+			return SingleChoiceAnswer{Option: v}, nil
+		case QuestionMatching:
+			return MatchingAnswer{Option: v}, nil
+		default:
+			return MapLabellingAnswer{Label: v}, nil
+		}
+	case QuestionTFNG, QuestionYNNG:
+		v, err := single()
+		if err != nil {
+			return nil, err
+		}
+		v = strings.Join(strings.Fields(strings.ToUpper(v)), " ")
+		if alias, ok := judgementAliases[questionType][v]; ok {
+			v = alias
+		}
+		if v != "" && !judgementValues[questionType][v] {
+			return nil, fmt.Errorf("invalid %s answer %q", questionType, v)
+		}
+		if questionType == QuestionTFNG {
+			return TFNGAnswer{Value: v}, nil
+		}
+		return YNNGAnswer{Value: v}, nil
+	case QuestionHeadings:
+		v, err := single()
+		if err != nil {
+			return nil, err
+		}
+		return HeadingAnswer{Heading: strings.ToLower(v)}, nil
+	}
+	return nil, fmt.Errorf("unknown question type %q", questionType)
+}
+
+// EncodeAnswer 将答案结构转换回存储使用的原始答案格式
+func EncodeAnswer(answer QuestionAnswer) AnswerValue {
+	if answer.QuestionType() == QuestionMultiChoice {
+		return AnswerValue{Values: answer.Values(), IsList: true}
+	}
+	return AnswerValue{Values: answer.Values()}
+}
+
+// TypedAnswer 带题型标识的答案，JSON 格式为 {"type": "...", "payload": {...}}
+// 接口中的答案（AnswerValue）也接受这种格式，解析后按题型转换为存储格式
+type TypedAnswer struct {
+	Answer QuestionAnswer
+}
+
+type typedAnswerJSON struct {
+	Type    QuestionType    `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (t TypedAnswer) MarshalJSON() ([]byte, error) {
+	if t.Answer == nil {
+		return []byte("null"), nil
+	}
+	payload, err := json.Marshal(t.Answer)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(typedAnswerJSON{Type: t.Answer.QuestionType(), Payload: payload})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (t *TypedAnswer) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		t.Answer = nil
+		return nil
+	}
+	var raw typedAnswerJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	var answer QuestionAnswer
+	switch raw.Type {
+	case QuestionGapFill:
+		answer = &GapFillAnswer{}
+	case QuestionSingleChoice:
+		answer = &SingleChoiceAnswer{}
+	case QuestionMultiChoice:
+		answer = &MultiChoiceAnswer{}
+	case QuestionMatching:
+		answer = &MatchingAnswer{}
+	case QuestionMapLabelling:
+		answer = &MapLabellingAnswer{}
+	case QuestionTFNG:
+		answer = &TFNGAnswer{}
+	case QuestionYNNG:
+		answer = &YNNGAnswer{}
+	case QuestionHeadings:
+		answer = &HeadingAnswer{}
+	default:
+		return fmt.Errorf("unknown question type %q", raw.Type)
+	}
+	if err := json.Unmarshal(raw.Payload, answer); err != nil {
+		return err
+	}
+
+	// 统一保存为值类型，并按题型规则重新校验
+	decoded, err := DecodeAnswer(raw.Type, EncodeAnswer(derefAnswer(answer)))
+	if err != nil {
+		return err
+	}
+	t.Answer = decoded
+	return nil
+}
+
+func derefAnswer(answer QuestionAnswer) QuestionAnswer {
+	switch a := answer.(type) {
+	case *GapFillAnswer:
+		return *a
+	case *SingleChoiceAnswer:
+		return *a
+	case *MultiChoiceAnswer:
+		return *a
+	case *MatchingAnswer:
+		return *a
+	case *MapLabellingAnswer:
+		return *a
+	case *TFNGAnswer:
+		return *a
+	case *YNNGAnswer:
+		return *a
+	case *HeadingAnswer:
+		return *a
+	}
+	return answer
+}
+
+// typeAnswer 按题组题型解析一道题的答案，返回规范化后的存储格式和答案结构
+// 题型未知（历史数据）或未填写答案时原样返回
+func typeAnswer(typeName, no string, value AnswerValue) (AnswerValue, TypedAnswer, error) {
+	questionType, known := ParseQuestionType(typeName)
+	if !known || value.IsEmpty() {
+		return value, TypedAnswer{}, nil
+	}
+	decoded, err := DecodeAnswer(questionType, value)
+	if err != nil {
+		return value, TypedAnswer{}, fmt.Errorf("question %s: %w", no, err)
+	}
+	return EncodeAnswer(decoded), TypedAnswer{Answer: decoded}, nil
+}
+
+// NormalizeTypeList 规范化存储的 type_list 中的答案：数字转为字符串，多选题答案统一为数组
+// 返回是否有改动以及无法识别的题型/答案
+func NormalizeTypeList(typeList []interface{}) (bool, []string) {
+	changed := false
+	var problems []string
+	for _, typeItemInterface := range typeList {
+		typeItem, ok := typeItemInterface.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		typeName, _ := typeItem["type"].(string)
+		questionType, known := ParseQuestionType(typeName)
+		if !known {
+			problems = append(problems, fmt.Sprintf("unknown question type %q", typeName))
+			continue
+		}
+		questionList, ok := typeItem["question_list"].([]interface{})
+		if !ok {
+			continue
+		}
+		for _, questionInterface := range questionList {
+			question, ok := questionInterface.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if question["answer"] == nil {
+				continue
+			}
+			decoded, err := DecodeAnswer(questionType, NewAnswerValue(question["answer"]))
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("question %v: %v", question["no"], err))
+				continue
+			}
+			normalized := EncodeAnswer(decoded).Interface()
+			before, _ := json.Marshal(question["answer"])
+			after, _ := json.Marshal(normalized)
+			if string(before) != string(after) {
+				question["answer"] = normalized
+				changed = true
+			}
+		}
+	}
+	return changed, problems
+}
@@ -0,0 +1,31 @@
+package models
+
+// QuestionDraftStatus AI 生成的题目需要人工审核后才能保存为 part
+const QuestionDraftStatus = "draft"
+
+// QuestionTypeCount 需要生成的题型和数量
+type QuestionTypeCount struct {
+	Type  string `json:"type"`  // 题型，如 tfng、gap_fill、single_choice
+	Count int    `json:"count"` // 题目数量
+}
+
+// QuestionDraftRequest AI 出题请求
+type QuestionDraftRequest struct {
+	Skill   string              `json:"skill"`             // reading / listening
+	PartID  int                 `json:"part_id,omitempty"` // 使用已有阅读 part 的文章
+	Article string              `json:"article,omitempty"` // 阅读文章或听力原文，part_id 为空时必填
+	Types   []QuestionTypeCount `json:"types"`             // 按顺序生成的题组
+	StartNo int                 `json:"start_no"`          // 起始题号，默认为 1
+}
+
+// QuestionDraft AI 生成的题组草稿
+// TypeList 为 []ReadingTypeItem 或 []ListeningTypeItem，与 part 的 type_list 结构一致
+type QuestionDraft struct {
+	Skill       string      `json:"skill"`
+	Status      string      `json:"status"` // 固定为 draft
+	Source      string      `json:"source"` // 固定为 ai
+	TypeList    interface{} `json:"type_list"`
+	Problems    []string    `json:"problems"` // 校验发现的问题，审核时需要修改
+	Model       string      `json:"model,omitempty"`
+	TotalTokens int         `json:"total_tokens"`
+}
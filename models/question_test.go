@@ -0,0 +1,149 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestTypedAnswerJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    QuestionAnswer
+		wantErr bool
+	}{
+		{"gap fill", `{"type":"gap_fill","payload":{"text":"station"}}`, GapFillAnswer{Text: "station"}, false},
+		{"single choice is upper-cased", `{"type":"single_choice","payload":{"option":"b"}}`, SingleChoiceAnswer{Option: "B"}, false},
+		{"multi choice deduplicated", `{"type":"multi_choice","payload":{"options":["a","C","A"]}}`, MultiChoiceAnswer{Options: []string{"A", "C"}}, false},
+		{"matching", `{"type":"matching","payload":{"option":"d"}}`, MatchingAnswer{Option: "D"}, false},
+		{"map labelling", `{"type":"map_labelling","payload":{"label":"f"}}`, MapLabellingAnswer{Label: "F"}, false},
+		{"tfng alias", `{"type":"tfng","payload":{"value":"ng"}}`, TFNGAnswer{Value: "NOT GIVEN"}, false},
+		{"ynng", `{"type":"ynng","payload":{"value":"yes"}}`, YNNGAnswer{Value: "YES"}, false},
+		{"headings", `{"type":"headings","payload":{"heading":"IV"}}`, HeadingAnswer{Heading: "iv"}, false},
+		{"invalid tfng value", `{"type":"tfng","payload":{"value":"maybe"}}`, nil, true},
+		{"unknown type", `{"type":"essay","payload":{"text":"x"}}`, nil, true},
+		{"null", `null`, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got TypedAnswer
+			err := json.Unmarshal([]byte(tt.data), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got.Answer, tt.want) {
+				t.Fatalf("Unmarshal() = %#v, want %#v", got.Answer, tt.want)
+			}
+
+			// 序列化后再解析应得到相同的答案
+			data, err := json.Marshal(got)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			var again TypedAnswer
+			if err := json.Unmarshal(data, &again); err != nil {
+				t.Fatalf("Unmarshal(%s) error = %v", data, err)
+			}
+			if !reflect.DeepEqual(again.Answer, got.Answer) {
+				t.Errorf("round trip = %#v, want %#v", again.Answer, got.Answer)
+			}
+		})
+	}
+}
+
+func TestAnswerValueAcceptsTypedAnswer(t *testing.T) {
+	tests := []struct {
+		data string
+		want AnswerValue
+	}{
+		{`"A"`, AnswerValue{Values: []string{"A"}}},
+		{`3`, AnswerValue{Values: []string{"3"}}},
+		{`["A","B"]`, AnswerValue{Values: []string{"A", "B"}, IsList: true}},
+		{`{"type":"multi_choice","payload":{"options":["b","a"]}}`, AnswerValue{Values: []string{"B", "A"}, IsList: true}},
+		{`{"type":"tfng","payload":{"value":"t"}}`, AnswerValue{Values: []string{"TRUE"}}},
+	}
+	for _, tt := range tests {
+		var got AnswerValue
+		if err := json.Unmarshal([]byte(tt.data), &got); err != nil {
+			t.Errorf("Unmarshal(%s) error = %v", tt.data, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Unmarshal(%s) = %#v, want %#v", tt.data, got, tt.want)
+		}
+	}
+
+	var value AnswerValue
+	if err := json.Unmarshal([]byte(`{"option":"A"}`), &value); err == nil {
+		t.Errorf("Unmarshal(object without type) error = nil, want error")
+	}
+}
+
+func TestTypeItemTypesAnswers(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		wantTyped []QuestionAnswer
+		wantJSON  string
+		wantErr   bool
+	}{
+		{
+			name:      "multi choice normalized to list",
+			data:      `{"type":"multiple_choice","question_list":[{"no":"1-2","answer":"c, a"}]}`,
+			wantTyped: []QuestionAnswer{MultiChoiceAnswer{Options: []string{"C", "A"}}},
+			wantJSON:  `["C","A"]`,
+		},
+		{
+			name:      "numeric answer becomes string",
+			data:      `{"type":"gap_fill","question_list":[{"no":"1","answer":25}]}`,
+			wantTyped: []QuestionAnswer{GapFillAnswer{Text: "25"}},
+			wantJSON:  `"25"`,
+		},
+		{
+			name:      "empty answer left untyped",
+			data:      `{"type":"tfng","question_list":[{"no":"1","answer":null}]}`,
+			wantTyped: []QuestionAnswer{nil},
+			wantJSON:  `null`,
+		},
+		{
+			name:      "unknown type left untyped",
+			data:      `{"type":"drawing","question_list":[{"no":"1","answer":"x"}]}`,
+			wantTyped: []QuestionAnswer{nil},
+			wantJSON:  `"x"`,
+		},
+		{
+			name:    "invalid judgement answer",
+			data:    `{"type":"tfng","question_list":[{"no":"1","answer":"maybe"}]}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var listening ListeningTypeItem
+			var reading ReadingTypeItem
+			listeningErr := json.Unmarshal([]byte(tt.data), &listening)
+			readingErr := json.Unmarshal([]byte(tt.data), &reading)
+			if (listeningErr != nil) != tt.wantErr || (readingErr != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal() errors = %v, %v, wantErr %v", listeningErr, readingErr, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			for i, want := range tt.wantTyped {
+				if got := listening.QuestionList[i].Typed.Answer; !reflect.DeepEqual(got, want) {
+					t.Errorf("listening question %d typed = %#v, want %#v", i, got, want)
+				}
+				if got := reading.QuestionList[i].Typed.Answer; !reflect.DeepEqual(got, want) {
+					t.Errorf("reading question %d typed = %#v, want %#v", i, got, want)
+				}
+				if data, _ := json.Marshal(listening.QuestionList[i].Answer); string(data) != tt.wantJSON {
+					t.Errorf("listening question %d answer = %s, want %s", i, data, tt.wantJSON)
+				}
+			}
+		})
+	}
+}
@@ -1,10 +1,13 @@
 package models
 
+import "encoding/json"
+
 type ReadingItem struct {
 	ID 					int					`json:"id,omitempty"`
 	Name				string				`json:"name"`
 	Status				string				`json:"status,omitempty"`
 	Type				string				`json:"type,omitempty"`
+	Module				string				`json:"module,omitempty"`	// academic / general
 	PartList			[]ReadingPartItem	`json:"part_list"`
 }
 
@@ -13,6 +16,7 @@ type BasicReadingItem struct {
 	Name				string				`json:"name"`
 	Status    			FlexInt    			`json:"status"`
 	Type      			FlexInt    			`json:"type"`
+	Module				string				`json:"module"`			// academic / general
 	PartList			[]int				`json:"part_list"`
 	UserID				string				`json:"user_id,omitempty"`
 }
@@ -37,12 +41,30 @@ type ReadingTypeItem struct {
 	MatchingOptions     []MatchingOptionsItem		`json:"matching_options,omitempty"`
 }
 
+// UnmarshalJSON 按题组题型解析每道题的答案，答案不符合题型时返回错误，并把答案规范化为存储格式
+func (t *ReadingTypeItem) UnmarshalJSON(data []byte) error {
+	type plain ReadingTypeItem
+	if err := json.Unmarshal(data, (*plain)(t)); err != nil {
+		return err
+	}
+	for i := range t.QuestionList {
+		question := &t.QuestionList[i]
+		answer, typed, err := typeAnswer(t.Type, question.No, question.Answer)
+		if err != nil {
+			return err
+		}
+		question.Answer, question.Typed = answer, typed
+	}
+	return nil
+}
+
 type ReadingQuestionItem struct {
 	ID					int							`json:"id"`
 	No                  string						`json:"no"`
 	Question			string						`json:"question"`
 	Options				[]QuestionOptionsItem		`json:"options,omitempty"`
-	Answer				interface{}					`json:"answer"`
+	Answer				AnswerValue					`json:"answer"`
+	Typed				TypedAnswer					`json:"-"`				// 按题组题型解析后的答案
 	AnswerCount			int							`json:"answer_count,omitempty"`
 	Content				string						`json:"content,omitempty"`
 	MatchedOption		*MatchingOptionsItem		`json:"matchedOption,omitempty"`
@@ -66,6 +88,9 @@ type ReadingRecordsItem struct {
 	Status				string				`json:"status"`
 	Type				string				`json:"type"`
 	Score				int					`json:"score,omitempty"`
+	RawScore			int					`json:"raw_score"`
+	Band				float64				`json:"band"`
+	Result				*ScoreResult		`json:"result,omitempty"`	// 提交后的逐题评分结果
 	Answers				[]AnswerItem		`json:"answers"`
 	UserID				string				`json:"user_id,omitempty"`
 	RestSeconds			int					`json:"rest_seconds,omitempty"`
@@ -71,4 +71,21 @@ type WritingRecordsResponse struct {
 type TestingRecordsResponse struct {
     Items []TestingRecordsItem 	    `json:"items"`
     Total int                       `json:"total"`
-}
\ No newline at end of file
+}
+
+//口语套题列表返回体
+type SpeakingListResponse struct {
+    Items []SpeakingItem    `json:"items"`
+    Total int               `json:"total"`
+}
+
+//口语part列表返回体
+type SpeakingPartListResponse struct {
+    Items []SpeakingPartItem `json:"items"`
+    Total int                `json:"total"`
+}
+
+type SpeakingRecordsResponse struct {
+    Items []SpeakingRecordsItem 	`json:"items"`
+    Total int                       `json:"total"`
+}
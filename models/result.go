@@ -0,0 +1,23 @@
+package models
+
+// QuestionResult 单道题的评分详情
+type QuestionResult struct {
+	No            string      `json:"no"`
+	PartID        int         `json:"part_id"`
+	PartName      string      `json:"part_name"`
+	PartIndex     int         `json:"part_index"` // 第几个 part，从 1 开始
+	QuestionType  string      `json:"question_type"`
+	UserAnswer    AnswerValue `json:"user_answer"`
+	CorrectAnswer AnswerValue `json:"correct_answer"`
+	Correct       bool        `json:"correct"`
+	Points        int         `json:"points"`
+	MaxPoints     int         `json:"max_points"`
+}
+
+// ScoreResult 提交后的评分结果
+type ScoreResult struct {
+	RawScore  int              `json:"raw_score"` // 答对题数
+	Total     int              `json:"total"`     // 满分
+	Band      float64          `json:"band"`
+	Questions []QuestionResult `json:"questions"`
+}
@@ -0,0 +1,21 @@
+package models
+
+// SessionItem 登录会话（一次登录对应一个会话，刷新 token 时沿用）
+type SessionItem struct {
+	ID        string `json:"id"`
+	UserID    string `json:"user_id"`
+	Device    string `json:"device"` // User-Agent
+	IP        string `json:"ip"`
+	CreatedAt string `json:"created_at"`
+	LastSeen  string `json:"last_seen"`
+	ExpiresAt string `json:"expires_at"`
+	Current   bool   `json:"current"` // 是否为当前请求使用的会话（只在列表中返回）
+}
+
+// TokenPair 登录或刷新后返回的 token
+type TokenPair struct {
+	Token        string    `json:"token"`         // access token，放在 Authorization: Bearer 中
+	RefreshToken string    `json:"refresh_token"` // 只能使用一次，刷新后返回新的 refresh token
+	ExpiresIn    int       `json:"expires_in"`    // access token 有效秒数
+	User         UserQuery `json:"user"`
+}
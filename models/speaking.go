@@ -0,0 +1,70 @@
+package models
+
+// 口语 part 类型
+const (
+	SpeakingPart1 = "1" // Part 1：日常话题问答
+	SpeakingPart2 = "2" // Part 2：话题卡（准备 1 分钟，陈述 1-2 分钟）
+	SpeakingPart3 = "3" // Part 3：围绕 Part 2 话题的深入讨论
+)
+
+type SpeakingItem struct {
+	ID       int                `json:"id,omitempty"`
+	Name     string             `json:"name"`
+	Status   string             `json:"status,omitempty"`
+	Type     string             `json:"type,omitempty"`
+	PartList []SpeakingPartItem `json:"part_list"`
+}
+
+type BasicSpeakingItem struct {
+	ID       int     `json:"id,omitempty"`
+	Name     string  `json:"name"`
+	Status   FlexInt `json:"status"`
+	Type     FlexInt `json:"type"`
+	PartList []int   `json:"part_list"`
+	UserID   string  `json:"user_id,omitempty"`
+}
+
+type SpeakingPartItem struct {
+	ID           int                    `json:"id,omitempty"`
+	Name         string                 `json:"name"`
+	Type         string                 `json:"type,omitempty"`     // 数据来源：1=系统，2=官方，3=用户（与其他表统一）
+	PartType     string                 `json:"part_type"`          // 口语 part：1 / 2 / 3
+	Topic        string                 `json:"topic"`              // 话题
+	QuestionList []SpeakingQuestionItem `json:"question_list"`      // Part 1、Part 3 问题
+	CueCard      *SpeakingCueCard       `json:"cue_card,omitempty"` // Part 2 话题卡
+	UserID       string                 `json:"user_id,omitempty"`
+}
+
+type SpeakingQuestionItem struct {
+	No       string `json:"no"`
+	Question string `json:"question"`
+}
+
+// SpeakingCueCard Part 2 话题卡
+type SpeakingCueCard struct {
+	Topic        string   `json:"topic"`               // 如 "Describe a book you recently read"
+	BulletPoints []string `json:"bullet_points"`       // You should say: ...
+	FollowUp     string   `json:"follow_up,omitempty"` // and explain ...
+	PrepSeconds  int      `json:"prep_seconds"`        // 准备时间，默认 60 秒
+	SpeakSeconds int      `json:"speak_seconds"`       // 陈述时间，默认 120 秒
+}
+
+type SpeakingRecordsItem struct {
+	ID          int                  `json:"id,omitempty"`
+	Name        string               `json:"name"`
+	Status      string               `json:"status"`
+	Type        string               `json:"type"`
+	SpeakingID  int                  `json:"speaking_id"`
+	Answers     []SpeakingAnswerItem `json:"answers"`
+	Band        *float64             `json:"band"` // 评分完成后填入
+	UserID      string               `json:"user_id,omitempty"`
+	RestSeconds int                  `json:"rest_seconds,omitempty"`
+}
+
+// SpeakingAnswerItem 单道口语题的录音，音频通过 /upload/file 上传后提交 URL
+type SpeakingAnswerItem struct {
+	PartID   int    `json:"part_id"`
+	No       string `json:"no"` // 题号，Part 2 为话题卡
+	AudioURL string `json:"audio_url"`
+	Duration int    `json:"duration,omitempty"` // 录音时长（秒）
+}
@@ -1,5 +1,10 @@
 package models
 
+import (
+	"encoding/json"
+	"fmt"
+)
+
 type TestingItem struct {
 	ID 					int					`json:"id,omitempty"`
 	Name				string				`json:"name"`
@@ -7,7 +12,9 @@ type TestingItem struct {
 	ReadingParts		[]ReadingPartItem	`json:"reading_parts"`
 	ListeningParts		[]ListeningPartItem `json:"listening_parts"`
 	WritingParts		[]WritingPartItem	`json:"writing_parts"`
+	SpeakingParts		[]SpeakingPartItem	`json:"speaking_parts,omitempty"`	// 口语为可选科目
 	Type				string				`json:"type"`
+	Module				string				`json:"module,omitempty"`	// academic / general
 }
 
 type BasicTestingItem struct {
@@ -15,9 +22,11 @@ type BasicTestingItem struct {
 	Name				string				`json:"name"`
 	Status    			FlexInt    			`json:"status"`
 	Type      			FlexInt    			`json:"type"`
+	Module				string				`json:"module"`			// academic / general
 	ListeningIDs		[]int				`json:"listening_ids"`
 	ReadingIDs			[]int				`json:"reading_ids"`
 	WritingIDs			[]int				`json:"writing_ids"`
+	SpeakingIDs			[]int				`json:"speaking_ids"`	// 为空表示套题不含口语
 	UserID				string				`json:"user_id,omitempty"`
 }
 type TestingRecordsItem struct {
@@ -26,13 +35,67 @@ type TestingRecordsItem struct {
 	Status				string				`json:"status"`
 	Type				string				`json:"type"`
 	Score				[]int				`json:"score,omitempty"`
-	Answers				[]AnswerItem		`json:"answers"`
+	RawScores			[]int				`json:"raw_scores"`	// [听力, 阅读] 答对题数
+	Bands				[]float64			`json:"bands"`		// [听力, 阅读] Band 分
+	Answers				[]TestingAnswerItem	`json:"answers"`
+	Sections			[]SectionScore		`json:"sections"`		// 各科目成绩
+	WritingMetrics		[]WritingTaskMetrics	`json:"writing_metrics"`	// 写作字数和用时
+	OverallBand			*float64			`json:"overall_band"`	// 综合分
+	Overall				*OverallBandResult	`json:"overall,omitempty"`	// 综合分及各科目 Band 分
 	UserID				string				`json:"user_id,omitempty"`
 	RestSeconds			[]int				`json:"rest_seconds,omitempty"`
 	TestID 				int					`json:"test_id"`
 }
 
+// TestingAnswerItem 套题作答，按科目和 part 分组
 type TestingAnswerItem struct {
-	PartName			string				`json:"part_name"`
-	AnswerList			[]interface{}		`json:"answer_list"`
+	Skill				string				`json:"skill"`					// listening / reading / writing / speaking
+	PartID				int					`json:"part_id"`
+	PartName			string				`json:"part_name,omitempty"`
+	AnswerList			[]AnswerItem		`json:"answer_list,omitempty"`	// 听力、阅读答案
+	Text				string				`json:"text,omitempty"`			// 写作作答内容
+	Recordings			[]SpeakingAnswerItem	`json:"recordings,omitempty"`	// 口语录音
+	SecondsSpent		int					`json:"seconds_spent,omitempty"`	// 写作该篇用时（秒）
+}
+
+// UnmarshalJSON 兼容旧的作答格式：旧记录的 answers 是不分科目的 {no, answer} 列表，
+// 或 answer_list 中是任意值的 {part_name, answer_list}；无法识别的答案跳过，不影响读取记录
+func (t *TestingAnswerItem) UnmarshalJSON(data []byte) error {
+	type plain TestingAnswerItem
+	var raw struct {
+		plain
+		AnswerList []json.RawMessage `json:"answer_list"`
+		No         interface{}       `json:"no"`
+		Answer     json.RawMessage   `json:"answer"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*t = TestingAnswerItem(raw.plain)
+	t.AnswerList = nil
+	for _, itemData := range raw.AnswerList {
+		var item AnswerItem
+		if err := json.Unmarshal(itemData, &item); err == nil {
+			t.AnswerList = append(t.AnswerList, item)
+		}
+	}
+
+	// 旧格式：单个 {no, answer}，没有科目和 part
+	if raw.No != nil {
+		item := AnswerItem{No: fmt.Sprint(raw.No)}
+		if len(raw.Answer) == 0 || json.Unmarshal(raw.Answer, &item.Answer) == nil {
+			t.AnswerList = append(t.AnswerList, item)
+		}
+	}
+	return nil
+}
+
+// SectionScore 套题中单个科目的成绩
+type SectionScore struct {
+	Skill				string				`json:"skill"`
+	RawScore			int					`json:"raw_score"`
+	Total				int					`json:"total"`
+	Band				*float64			`json:"band"`					// 写作、口语在评分完成前为空
+	Missing				[]string			`json:"missing,omitempty"`		// 未作答的题号（写作为未作答的 part ID，口语为 part ID:题号）
+	Result				*ScoreResult		`json:"result,omitempty"`		// 逐题评分结果
 }
\ No newline at end of file
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestTestingAnswerItemUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []TestingAnswerItem
+	}{
+		{
+			name: "current format",
+			data: `[{"skill":"listening","part_id":3,"answer_list":[{"no":"1","answer":"A"}]},{"skill":"writing","part_id":7,"text":"essay"}]`,
+			want: []TestingAnswerItem{
+				{Skill: "listening", PartID: 3, AnswerList: []AnswerItem{{No: "1", Answer: AnswerValue{Values: []string{"A"}}}}},
+				{Skill: "writing", PartID: 7, Text: "essay"},
+			},
+		},
+		{
+			name: "legacy flat answers",
+			data: `[{"no":"1","answer":"A"},{"no":2,"answer":["B","C"]}]`,
+			want: []TestingAnswerItem{
+				{AnswerList: []AnswerItem{{No: "1", Answer: AnswerValue{Values: []string{"A"}}}}},
+				{AnswerList: []AnswerItem{{No: "2", Answer: AnswerValue{Values: []string{"B", "C"}, IsList: true}}}},
+			},
+		},
+		{
+			name: "legacy part_name with untyped answer_list",
+			data: `[{"part_name":"Part 1","answer_list":["x",{"no":"3","answer":"TRUE"}]}]`,
+			want: []TestingAnswerItem{
+				{PartName: "Part 1", AnswerList: []AnswerItem{{No: "3", Answer: AnswerValue{Values: []string{"TRUE"}}}}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []TestingAnswerItem
+			if err := json.Unmarshal([]byte(tt.data), &got); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unmarshal() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
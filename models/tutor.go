@@ -0,0 +1,33 @@
+package models
+
+// TutorConversationItem AI 辅导对话，可关联某个 part、做题记录和题号
+type TutorConversationItem struct {
+	ID         int    `json:"id,omitempty"`
+	UserID     string `json:"user_id,omitempty"`
+	Title      string `json:"title"`
+	Skill      string `json:"skill"` // listening / reading / writing / speaking
+	PartID     int    `json:"part_id"`
+	RecordType string `json:"record_type,omitempty"` // listening / reading / writing / speaking / testing，为空表示只关联 part
+	RecordID   int    `json:"record_id,omitempty"`
+	QuestionNo string `json:"question_no,omitempty"`
+	Context    string `json:"context,omitempty"` // 创建时生成的题目、原文和作答内容，作为系统提示发送给模型
+	CreatedAt  string `json:"created_at,omitempty"`
+	UpdatedAt  string `json:"updated_at,omitempty"`
+}
+
+// TutorMessageItem 对话中的一条消息
+type TutorMessageItem struct {
+	ID             int    `json:"id,omitempty"`
+	ConversationID int    `json:"conversation_id"`
+	Role           string `json:"role"` // user / assistant
+	Content        string `json:"content"`
+	TotalTokens    int    `json:"total_tokens"`
+	CreatedAt      string `json:"created_at,omitempty"`
+}
+
+// TutorQuota 当天的提问次数
+type TutorQuota struct {
+	Used      int `json:"used"`
+	Limit     int `json:"limit"`
+	Remaining int `json:"remaining"`
+}
@@ -1,4 +1,13 @@
 package models
+
+// 用户角色（user_list.role_id）
+const (
+	RoleStudent = 0 // 普通用户
+	RoleAdmin   = 1 // 管理员
+	RoleTeacher = 2 // 老师（考官），负责写作、口语评分
+	RoleEditor  = 3 // 内容编辑，负责维护系统和官方题目（type 1/2）
+)
+
 type UserQuery struct {
 	ID       string    `json:"id"`
 	Email    string `json:"email"`
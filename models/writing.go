@@ -25,6 +25,7 @@ type WritingPartItem struct {
 	Title				string				`json:"title"`
 	SubTitle			string				`json:"sub_title,omitempty"`
 	Img					string				`json:"img,omitempty"`
+	ImgDescription		string				`json:"img_description,omitempty"`	// Task 1 图表的文字描述，供 AI 批改使用
 	UserID				string				`json:"user_id,omitempty"`
 }
 type WritingRecordsItem struct {
@@ -32,7 +33,27 @@ type WritingRecordsItem struct {
 	Name				string				`json:"name"`
 	Status				string				`json:"status"`
 	Type				string				`json:"type"`
-	Answers				[]interface{}		`json:"answers"`
+	Answers				[]WritingAnswerItem	`json:"answers"`
+	Band				*float64			`json:"band"`				// 老师评分完成后填入
+	Metrics				[]WritingTaskMetrics	`json:"metrics"`		// 每篇作文的字数和用时，提交时计算
 	UserID				string				`json:"user_id,omitempty"`
 	RestSeconds			int					`json:"rest_seconds,omitempty"`
+	TestID 				int					`json:"test_id"`			// 写作套题ID
+}
+
+// WritingAnswerItem 单篇作文
+type WritingAnswerItem struct {
+	PartID				int					`json:"part_id"`
+	Text				string				`json:"text"`
+	SecondsSpent		int					`json:"seconds_spent,omitempty"`	// 该篇用时（秒）
+}
+
+// WritingTaskMetrics 单篇作文的字数和用时
+type WritingTaskMetrics struct {
+	PartID				int					`json:"part_id"`
+	TaskType			string				`json:"task_type"`
+	WordCount			int					`json:"word_count"`
+	MinWords			int					`json:"min_words"`		// Task 1 为 150，Task 2 为 250
+	UnderLength			bool				`json:"under_length"`	// 字数不足
+	SecondsSpent		int					`json:"seconds_spent"`
 }
\ No newline at end of file
@@ -2,7 +2,11 @@ package routes
 
 import (
 	"net/http"
+	"os"
+	"strings"
+	"time"
 
+	"github.com/Queen2333/ielts_test_backend/auth"
 	"github.com/Queen2333/ielts_test_backend/controllers"
 	"github.com/Queen2333/ielts_test_backend/middlewares"
 
@@ -11,9 +15,24 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
+// publicPaths 不需要登录的接口
+var publicPaths = map[string]bool{
+	"/login":           true,
+	"/login/password":  true,
+	"/send-code":       true,
+	"/register":        true,
+	"/password/forgot": true,
+	"/password/reset":  true,
+	"/token/refresh":   true,
+}
+
 // SetupRouter configures the application's routes.
 func SetupRouter() *gin.Engine {
 	r := gin.Default()
+	// c.ClientIP() 只信任这些代理转发的 X-Forwarded-For，否则客户端可以伪造 IP 绕过按 IP 的限流和验证码冷却
+	if err := r.SetTrustedProxies(trustedProxies()); err != nil {
+		panic(err)
+	}
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	// 静态文件服务
 	r.Static("/uploads", "./uploads")
@@ -27,67 +46,119 @@ func SetupRouter() *gin.Engine {
 
 	// JWT 认证中间件在 CORS 之后
 	r.Use(func(c *gin.Context) {
-		if c.Request.URL.Path != "/login" && c.Request.URL.Path != "/send-code" {
+		if !publicPaths[c.Request.URL.Path] {
 			middlewares.JWTAuthMiddleware()(c)
 		}
 	})
 
-	r.POST("/login", controllers.LoginHandler)
-	r.POST("/send-code", controllers.SendCodeHandler)
-	// r.POST("/register", controllers.RegisterUser)
+	// 限流策略（可用环境变量 RATE_LIMIT_<NAME> 覆盖）
+	sendCodeByIP := middlewares.RateLimit(middlewares.RateLimitPolicy{Name: "send-code-ip", Limit: 20, Window: time.Hour, Key: middlewares.KeyByIP})
+	sendCodeByEmail := middlewares.RateLimit(middlewares.RateLimitPolicy{Name: "send-code-email", Limit: 5, Window: time.Hour, Key: middlewares.KeyByEmail})
+	loginByIP := middlewares.RateLimit(middlewares.RateLimitPolicy{Name: "login-ip", Limit: 30, Window: 10 * time.Minute, Key: middlewares.KeyByIP})
+	passwordResetByIP := middlewares.RateLimit(middlewares.RateLimitPolicy{Name: "password-reset-ip", Limit: 20, Window: time.Hour, Key: middlewares.KeyByIP})
+	passwordResetByEmail := middlewares.RateLimit(middlewares.RateLimitPolicy{Name: "password-reset-email", Limit: 3, Window: time.Hour, Key: middlewares.KeyByEmail})
+	submitByUser := middlewares.RateLimit(middlewares.RateLimitPolicy{Name: "submit", Limit: 10, Window: time.Minute, Key: middlewares.KeyByUser})
+	aiByUser := middlewares.RateLimit(middlewares.RateLimitPolicy{Name: "ai", Limit: 20, Window: time.Hour, Key: middlewares.KeyByUser})
+
+	// 权限矩阵（见 auth.rolePermissions）：题库查看 content:read；新增、修改题目 content:own，
+	// 官方题目（type 1/2）和题目的所有者在处理函数中检查
+	contentRead := middlewares.RequirePermission(auth.PermContentRead)
+	contentWrite := middlewares.RequirePermission(auth.PermContentOwn)
+	settingsManage := middlewares.RequirePermission(auth.PermSettingsManage)
+	grade := middlewares.RequirePermission(auth.PermGrade)
+	gradingManage := middlewares.RequirePermission(auth.PermGradingManage)
+	questionGen := middlewares.RequirePermission(auth.PermQuestionGen)
+	jobsManage := middlewares.RequirePermission(auth.PermJobsManage)
+	recordsReadAll := middlewares.RequirePermission(auth.PermRecordsReadAll)
+	recordsManage := middlewares.RequirePermission(auth.PermRecordsManage)
+	contentModerate := middlewares.RequirePermission(auth.PermContentModerate)
+
+	r.POST("/login", loginByIP, controllers.LoginHandler)
+	r.POST("/send-code", sendCodeByIP, sendCodeByEmail, controllers.SendCodeHandler)
+	r.POST("/register", loginByIP, controllers.RegisterHandler)
+	r.POST("/login/password", loginByIP, controllers.PasswordLoginHandler)
+	r.POST("/password/forgot", passwordResetByIP, passwordResetByEmail, controllers.PasswordForgotHandler)
+	r.POST("/password/reset", loginByIP, controllers.PasswordResetHandler)
+	r.PUT("/password/change", controllers.PasswordChangeHandler)
+	r.POST("/token/refresh", loginByIP, controllers.RefreshTokenHandler)
+	r.POST("/logout", controllers.LogoutHandler)
+	r.GET("/sessions", controllers.SessionList)
+	r.DELETE("/sessions", controllers.RevokeOtherSessions)
+	r.DELETE("/sessions/:id", controllers.RevokeSessionHandler)
 	r.GET("/user-info", controllers.GetUserInfo)
 
 	/**配置**/
 	// 听力
-	r.GET("/config/listening/list", controllers.ListeningList)
-	r.GET("/config/listening/detail/:id", controllers.ListeningDetail)
-	r.POST("/config/listening/add", controllers.AddListening)
-	r.PUT("/config/listening/update", controllers.UpdateListening)
-	r.DELETE("/config/listening/delete/:id", controllers.DeleteListening)
-
-	r.GET("/config/listening-part/list", controllers.ListeningPartList)
-	r.GET("/config/listening-part/detail/:id", controllers.ListeningPartDetail)
-	r.POST("/config/listening-part/add", controllers.AddListeningPart)
-	r.PUT("/config/listening-part/update", controllers.UpdateListeningPart)
-	r.DELETE("/config/listening-part/delete/:id", controllers.DeleteListeningPart)
+	r.GET("/config/listening/list", contentRead, controllers.ListeningList)
+	r.GET("/config/listening/detail/:id", contentRead, controllers.ListeningDetail)
+	r.POST("/config/listening/add", contentWrite, controllers.AddListening)
+	r.PUT("/config/listening/update", contentWrite, controllers.UpdateListening)
+	r.DELETE("/config/listening/delete/:id", contentWrite, controllers.DeleteListening)
+	r.PUT("/config/listening/renumber/:id", contentWrite, controllers.RenumberListening)
+
+	r.GET("/config/listening-part/list", contentRead, controllers.ListeningPartList)
+	r.GET("/config/listening-part/detail/:id", contentRead, controllers.ListeningPartDetail)
+	r.POST("/config/listening-part/add", contentWrite, controllers.AddListeningPart)
+	r.PUT("/config/listening-part/update", contentWrite, controllers.UpdateListeningPart)
+	r.DELETE("/config/listening-part/delete/:id", contentWrite, controllers.DeleteListeningPart)
 
 	// 文件上传和删除
-	r.POST("/upload", controllers.UploadFile)                   // Python转发方式（保留旧逻辑）
-	r.POST("/upload/file", controllers.UploadFileNative)      // Go原生处理（新方式，推荐）
-	r.DELETE("/upload/delete", controllers.DeleteFile)
+	// 上传需要 content:own；删除时在处理函数中检查上传者
+	r.POST("/upload", contentWrite, controllers.UploadFile)            // Python转发方式（保留旧逻辑）
+	r.POST("/upload/file", contentWrite, controllers.UploadFileNative) // Go原生处理（新方式，推荐）
+	r.DELETE("/upload/delete", contentWrite, controllers.DeleteFile)
 
 	// 阅读
-	r.GET("/config/reading/list", controllers.ReadingList)
-	r.GET("/config/reading/detail/:id", controllers.ReadingDetail)
-	r.POST("/config/reading/add", controllers.AddReading)
-	r.PUT("/config/reading/update", controllers.UpdateReading)
-	r.DELETE("/config/reading/delete/:id", controllers.DeleteReading)
-
-	r.GET("/config/reading-part/list", controllers.ReadingPartList)
-	r.GET("/config/reading-part/detail/:id", controllers.ReadingPartDetail)
-	r.POST("/config/reading-part/add", controllers.AddReadingPart)
-	r.PUT("/config/reading-part/update", controllers.UpdateReadingPart)
-	r.DELETE("/config/reading-part/delete/:id", controllers.DeleteReadingPart)
+	r.GET("/config/reading/list", contentRead, controllers.ReadingList)
+	r.GET("/config/reading/detail/:id", contentRead, controllers.ReadingDetail)
+	r.POST("/config/reading/add", contentWrite, controllers.AddReading)
+	r.PUT("/config/reading/update", contentWrite, controllers.UpdateReading)
+	r.DELETE("/config/reading/delete/:id", contentWrite, controllers.DeleteReading)
+	r.PUT("/config/reading/renumber/:id", contentWrite, controllers.RenumberReading)
+
+	r.GET("/config/reading-part/list", contentRead, controllers.ReadingPartList)
+	r.GET("/config/reading-part/detail/:id", contentRead, controllers.ReadingPartDetail)
+	r.POST("/config/reading-part/add", contentWrite, controllers.AddReadingPart)
+	r.PUT("/config/reading-part/update", contentWrite, controllers.UpdateReadingPart)
+	r.DELETE("/config/reading-part/delete/:id", contentWrite, controllers.DeleteReadingPart)
 
 	// 写作
-	r.GET("/config/writing/list", controllers.WritingList)
-	r.GET("/config/writing/detail/:id", controllers.WritingDetail)
-	r.POST("/config/writing/add", controllers.AddWriting)
-	r.PUT("/config/writing/update", controllers.UpdateWriting)
-	r.DELETE("/config/writing/delete/:id", controllers.DeleteWriting)
-
-	r.GET("/config/writing-part/list", controllers.WritingPartList)
-	r.GET("/config/writing-part/detail/:id", controllers.WritingPartDetail)
-	r.POST("/config/writing-part/add", controllers.AddWritingPart)
-	r.PUT("/config/writing-part/update", controllers.UpdateWritingPart)
-	r.DELETE("/config/writing-part/delete/:id", controllers.DeleteWritingPart)
+	r.GET("/config/writing/list", contentRead, controllers.WritingList)
+	r.GET("/config/writing/detail/:id", contentRead, controllers.WritingDetail)
+	r.POST("/config/writing/add", contentWrite, controllers.AddWriting)
+	r.PUT("/config/writing/update", contentWrite, controllers.UpdateWriting)
+	r.DELETE("/config/writing/delete/:id", contentWrite, controllers.DeleteWriting)
+
+	r.GET("/config/writing-part/list", contentRead, controllers.WritingPartList)
+	r.GET("/config/writing-part/detail/:id", contentRead, controllers.WritingPartDetail)
+	r.POST("/config/writing-part/add", contentWrite, controllers.AddWritingPart)
+	r.PUT("/config/writing-part/update", contentWrite, controllers.UpdateWritingPart)
+	r.DELETE("/config/writing-part/delete/:id", contentWrite, controllers.DeleteWritingPart)
+
+	// 口语
+	r.GET("/config/speaking/list", contentRead, controllers.SpeakingList)
+	r.GET("/config/speaking/detail/:id", contentRead, controllers.SpeakingDetail)
+	r.POST("/config/speaking/add", contentWrite, controllers.AddSpeaking)
+	r.PUT("/config/speaking/update", contentWrite, controllers.UpdateSpeaking)
+	r.DELETE("/config/speaking/delete/:id", contentWrite, controllers.DeleteSpeaking)
+
+	r.GET("/config/speaking-part/list", contentRead, controllers.SpeakingPartList)
+	r.GET("/config/speaking-part/detail/:id", contentRead, controllers.SpeakingPartDetail)
+	r.POST("/config/speaking-part/add", contentWrite, controllers.AddSpeakingPart)
+	r.PUT("/config/speaking-part/update", contentWrite, controllers.UpdateSpeakingPart)
+	r.DELETE("/config/speaking-part/delete/:id", contentWrite, controllers.DeleteSpeakingPart)
 
 	// 测试 套题
-	r.GET("/config/testing/list", controllers.TestingList)
-	r.GET("/config/testing/detail/:id", controllers.TestingDetail)
-	r.POST("/config/testing/add", controllers.AddTesting)
-	r.PUT("/config/testing/update", controllers.UpdateTesting)
-	r.DELETE("/config/testing/delete/:id", controllers.DeleteTesting)
+	r.GET("/config/testing/list", contentRead, controllers.TestingList)
+	r.GET("/config/testing/detail/:id", contentRead, controllers.TestingDetail)
+	r.POST("/config/testing/add", contentWrite, controllers.AddTesting)
+	r.PUT("/config/testing/update", contentWrite, controllers.UpdateTesting)
+	r.DELETE("/config/testing/delete/:id", contentWrite, controllers.DeleteTesting)
+	r.PUT("/config/testing/renumber/:id", contentWrite, controllers.RenumberTesting)
+
+	// 分数换算表
+	r.GET("/config/band-table/list", contentRead, controllers.BandTableList)
+	r.PUT("/config/band-table/update", settingsManage, controllers.UpdateBandTable)
 
 	/**做题记录**/
 	// 听力
@@ -96,7 +167,8 @@ func SetupRouter() *gin.Engine {
 	r.POST("/record/listening/add", controllers.AddListeningRecord)
 	r.PUT("/record/listening/update", controllers.UpdateListeningRecord)
 	r.DELETE("/record/listening/delete/:id", controllers.DeleteListeningRecord)
-	r.POST("/record/listening/submit", controllers.SubmitListeningRecord)
+	r.POST("/record/listening/submit", submitByUser, controllers.SubmitListeningRecord)
+	r.GET("/record/listening/review/:id", controllers.ListeningRecordReview)
 
 	// 阅读
 	r.GET("/record/reading/list", controllers.ReadingRecords)
@@ -104,7 +176,8 @@ func SetupRouter() *gin.Engine {
 	r.POST("/record/reading/add", controllers.AddReadingRecord)
 	r.PUT("/record/reading/update", controllers.UpdateReadingRecord)
 	r.DELETE("/record/reading/delete/:id", controllers.DeleteReadingRecord)
-	r.POST("/record/reading/submit", controllers.SubmitReadingRecord)
+	r.POST("/record/reading/submit", submitByUser, controllers.SubmitReadingRecord)
+	r.GET("/record/reading/review/:id", controllers.ReadingRecordReview)
 
 	// 写作
 	r.GET("/record/writing/list", controllers.WritingRecords)
@@ -112,6 +185,15 @@ func SetupRouter() *gin.Engine {
 	r.POST("/record/writing/add", controllers.AddWritingRecord)
 	r.PUT("/record/writing/update", controllers.UpdateWritingRecord)
 	r.DELETE("/record/writing/delete/:id", controllers.DeleteWritingRecord)
+	r.POST("/record/writing/submit", submitByUser, controllers.SubmitWritingRecord)
+
+	// 口语
+	r.GET("/record/speaking/list", controllers.SpeakingRecords)
+	r.GET("/record/speaking/detail/:id", controllers.SpeakingRecordDetail)
+	r.POST("/record/speaking/add", controllers.AddSpeakingRecord)
+	r.PUT("/record/speaking/update", controllers.UpdateSpeakingRecord)
+	r.DELETE("/record/speaking/delete/:id", controllers.DeleteSpeakingRecord)
+	r.POST("/record/speaking/submit", submitByUser, controllers.SubmitSpeakingRecord)
 
 	// 套题
 	r.GET("/record/testing/list", controllers.TestingRecords)
@@ -119,17 +201,77 @@ func SetupRouter() *gin.Engine {
 	r.POST("/record/testing/add", controllers.AddTestingRecord)
 	r.PUT("/record/testing/update", controllers.UpdateTestingRecord)
 	r.DELETE("/record/testing/delete/:id", controllers.DeleteTestingRecord)
-	r.POST("/record/testing/submit", controllers.SubmitTestingRecord)
+	r.POST("/record/testing/submit", submitByUser, controllers.SubmitTestingRecord)
+
+	/**评分**/
+	// 写作
+	// 学生可以查看自己记录的评分，权限在处理函数中检查
+	r.GET("/grading/writing/detail", controllers.WritingGradeDetail)
+	r.POST("/grading/writing/submit", grade, submitByUser, controllers.SubmitWritingGrade)
+	// 口语
+	r.POST("/grading/speaking/submit", grade, submitByUser, controllers.SubmitSpeakingGrade)
+	// 评分队列
+	r.GET("/grading/queue/list", grade, controllers.GradingQueueList)
+	r.PUT("/grading/queue/claim/:id", grade, controllers.ClaimGradingItem)
+	r.PUT("/grading/queue/assign", gradingManage, controllers.AssignGradingItem)
+	r.POST("/grading/queue/auto-assign", gradingManage, controllers.AutoAssignGrading)
+	// 评分校准
+	r.GET("/grading/calibration/essay/list", gradingManage, controllers.CalibrationEssayList)
+	r.POST("/grading/calibration/essay/add", gradingManage, controllers.AddCalibrationEssay)
+	r.POST("/grading/calibration/assign", gradingManage, controllers.AssignCalibration)
+	r.GET("/grading/calibration/pending", grade, controllers.PendingCalibration)
+	r.POST("/grading/calibration/submit", grade, controllers.SubmitCalibration)
+	r.GET("/grading/calibration/report", gradingManage, controllers.CalibrationReport)
+	r.GET("/grading/calibration/settings", gradingManage, controllers.DoubleMarkingSettings)
+	r.PUT("/grading/calibration/settings", gradingManage, controllers.UpdateDoubleMarkingSettings)
+
+	/**AI**/
+	r.GET("/ai/writing/feedback", controllers.AIWritingFeedbackDetail)
+	r.POST("/ai/writing/feedback/request", aiByUser, controllers.RequestAIWritingFeedback)
+	// AI 辅导
+	r.GET("/ai/tutor/conversation/list", controllers.TutorConversationList)
+	r.GET("/ai/tutor/conversation/detail/:id", controllers.TutorConversationDetail)
+	r.POST("/ai/tutor/conversation/add", controllers.AddTutorConversation)
+	r.DELETE("/ai/tutor/conversation/delete/:id", controllers.DeleteTutorConversation)
+	r.POST("/ai/tutor/conversation/chat/:id", controllers.TutorChat)
+	r.GET("/ai/tutor/quota", controllers.TutorQuota)
+	// AI 出题
+	r.POST("/ai/question/generate", questionGen, aiByUser, controllers.GenerateQuestionDraft)
+
+	/**后台任务**/
+	r.GET("/jobs/dead", jobsManage, controllers.DeadJobList)
+	r.GET("/jobs/:id", controllers.JobDetail)
+
+	/**管理员**/
+	// 普通接口只能访问自己的记录和题目，管理员通过以下接口查看、删除其他用户的数据
+	r.GET("/admin/record/:skill/list", recordsReadAll, controllers.AdminRecordList)
+	r.GET("/admin/record/:skill/detail/:id", recordsReadAll, controllers.AdminRecordDetail)
+	r.DELETE("/admin/record/:skill/delete/:id", recordsManage, controllers.AdminDeleteRecord)
+	r.GET("/admin/content/:kind/list", contentModerate, controllers.AdminContentList)
+	r.GET("/admin/content/:kind/detail/:id", contentModerate, controllers.AdminContentDetail)
+	r.DELETE("/admin/content/:kind/delete/:id", contentModerate, controllers.AdminDeleteContent)
 
 	// 使用 Swagger UI 中间件
 	return r
 }
 
+// trustedProxies 读取 TRUSTED_PROXIES（逗号分隔的 IP 或 CIDR），未配置时不信任任何代理，直接使用连接的 IP
+func trustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
 // enableCORS is a middleware function to enable CORS headers.
 func enableCORS(c *gin.Context) {
 	c.Header("Access-Control-Allow-Origin", "*")
 	c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 	c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+	c.Header("Access-Control-Expose-Headers", "RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, RateLimit-Policy, Retry-After")
 
 	// Handle the OPTIONS preflight request
 	if c.Request.Method == "OPTIONS" {
@@ -138,4 +280,4 @@ func enableCORS(c *gin.Context) {
 	}
 
 	c.Next()
-}
\ No newline at end of file
+}
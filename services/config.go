@@ -0,0 +1,95 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Config 大模型服务配置，通过环境变量设置
+//
+//	LLM_PROVIDER         grok / openai / ollama / fake，默认 grok
+//	LLM_BASE_URL         OpenAI 兼容接口地址，默认取 provider 的官方地址
+//	LLM_API_KEY          API Key，grok / openai 必须配置
+//	LLM_MODEL            模型名称
+//	LLM_TEMPERATURE      默认温度
+//	LLM_TIMEOUT_SECONDS  单次请求超时，默认 60 秒
+//	LLM_MAX_RETRIES      失败重试次数，默认 2 次
+type Config struct {
+	Provider    string
+	BaseURL     string
+	APIKey      string
+	Model       string
+	Temperature float32
+	Timeout     time.Duration
+	MaxRetries  int
+}
+
+// 各 provider 的默认地址和模型
+var providerPresets = map[string]struct {
+	baseURL string
+	model   string
+}{
+	"grok":   {"https://api.x.ai/v1", "grok-2-latest"},
+	"openai": {"https://api.openai.com/v1", "gpt-4o-mini"},
+	"ollama": {"http://localhost:11434/v1", "llama3"},
+}
+
+// requiresAPIKey 需要 API Key 的 provider，没有配置 LLM_API_KEY 时不能启动
+var requiresAPIKey = map[string]bool{"grok": true, "openai": true}
+
+func defaultConfig() Config {
+	return Config{
+		Provider:   "grok",
+		BaseURL:    providerPresets["grok"].baseURL,
+		Model:      providerPresets["grok"].model,
+		Timeout:    60 * time.Second,
+		MaxRetries: 2,
+	}
+}
+
+// LoadConfig 读取环境变量，未设置的项使用默认值；grok / openai 没有配置 LLM_API_KEY 时返回错误
+func LoadConfig() (Config, error) {
+	cfg := defaultConfig()
+	if provider := os.Getenv("LLM_PROVIDER"); provider != "" {
+		cfg.Provider = provider
+		if preset, ok := providerPresets[provider]; ok {
+			cfg.BaseURL = preset.baseURL
+			cfg.Model = preset.model
+		}
+	}
+	if baseURL := os.Getenv("LLM_BASE_URL"); baseURL != "" {
+		cfg.BaseURL = baseURL
+	}
+	if apiKey := os.Getenv("LLM_API_KEY"); apiKey != "" {
+		cfg.APIKey = apiKey
+	}
+	if model := os.Getenv("LLM_MODEL"); model != "" {
+		cfg.Model = model
+	}
+	if value, err := strconv.ParseFloat(os.Getenv("LLM_TEMPERATURE"), 32); err == nil {
+		cfg.Temperature = float32(value)
+	}
+	if value, err := strconv.Atoi(os.Getenv("LLM_TIMEOUT_SECONDS")); err == nil && value > 0 {
+		cfg.Timeout = time.Duration(value) * time.Second
+	}
+	if value, err := strconv.Atoi(os.Getenv("LLM_MAX_RETRIES")); err == nil && value >= 0 {
+		cfg.MaxRetries = value
+	}
+	if requiresAPIKey[cfg.Provider] && cfg.APIKey == "" {
+		return cfg, fmt.Errorf("LLM_API_KEY must be set for LLM provider %q", cfg.Provider)
+	}
+	return cfg, nil
+}
+
+// NewProviderFromConfig 根据配置创建 provider
+func NewProviderFromConfig(cfg Config) (Provider, error) {
+	switch cfg.Provider {
+	case "fake":
+		return NewFakeProvider(), nil
+	case "grok", "openai", "ollama":
+		return NewOpenAICompatible(cfg), nil
+	}
+	return nil, fmt.Errorf("unknown LLM provider %q", cfg.Provider)
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// FakeProvider 确定性的 provider，用于测试和本地开发
+// 按顺序返回预设的回复；没有预设时原样返回最后一条用户消息
+type FakeProvider struct {
+	mu        sync.Mutex
+	responses []string
+	Requests  []ChatRequest // 收到的所有请求
+	Err       error         // 不为空时每次调用都返回该错误
+}
+
+// NewFakeProvider 创建 FakeProvider，responses 为依次返回的内容
+func NewFakeProvider(responses ...string) *FakeProvider {
+	return &FakeProvider{responses: responses}
+}
+
+func (p *FakeProvider) Name() string {
+	return "fake"
+}
+
+func (p *FakeProvider) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.Requests = append(p.Requests, req)
+	if p.Err != nil {
+		return nil, p.Err
+	}
+
+	var content string
+	if len(p.responses) > 0 {
+		content = p.responses[0]
+		p.responses = p.responses[1:]
+	} else {
+		for i := len(req.Messages) - 1; i >= 0; i-- {
+			if req.Messages[i].Role == "user" {
+				content = req.Messages[i].Content
+				break
+			}
+		}
+	}
+
+	usage := Usage{PromptTokens: countTokens(req.Messages), CompletionTokens: len(strings.Fields(content))}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	recordUsage(p.Name(), "fake", usage)
+	return &ChatResponse{Content: content, Model: "fake", Usage: usage}, nil
+}
+
+// ChatStream 按单词依次输出 Chat 的结果
+func (p *FakeProvider) ChatStream(ctx context.Context, req ChatRequest, onDelta DeltaFunc) (*ChatResponse, error) {
+	resp, err := p.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	for i, word := range strings.Fields(resp.Content) {
+		if i > 0 {
+			word = " " + word
+		}
+		if err := onDelta(word); err != nil {
+			return resp, err
+		}
+	}
+	return resp, nil
+}
+
+// countTokens 按单词数粗略估算 token
+func countTokens(messages []Message) int {
+	count := 0
+	for _, message := range messages {
+		count += len(strings.Fields(message.Content))
+	}
+	return count
+}
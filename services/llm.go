@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Message 对话消息，Role 为 system / user / assistant
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatRequest 对话请求；Model、Temperature 为空时使用 provider 的默认配置
+type ChatRequest struct {
+	Messages    []Message
+	Model       string
+	Temperature *float32
+	MaxTokens   int
+	JSONOutput  bool // 要求模型只输出 JSON 对象
+}
+
+// Usage token 用量
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// ChatResponse 对话结果
+type ChatResponse struct {
+	Content string `json:"content"`
+	Model   string `json:"model"`
+	Usage   Usage  `json:"usage"`
+}
+
+// DeltaFunc 流式输出时每收到一段内容调用一次，返回错误时停止输出
+type DeltaFunc func(delta string) error
+
+// Provider 大模型服务
+type Provider interface {
+	Name() string
+	Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error)
+	// ChatStream 流式输出，返回完整内容和用量
+	ChatStream(ctx context.Context, req ChatRequest, onDelta DeltaFunc) (*ChatResponse, error)
+}
+
+var (
+	// ErrEmptyResponse 模型没有返回内容
+	ErrEmptyResponse = errors.New("llm returned no choices")
+	// ErrNotConfigured 启动时没有调用 Init
+	ErrNotConfigured = errors.New("llm provider is not configured")
+)
+
+var (
+	defaultProvider Provider = unconfiguredProvider{}
+	providerMu      sync.RWMutex
+)
+
+// Init 根据环境变量创建默认 provider，启动时调用，配置错误时返回错误
+func Init() error {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	provider, err := NewProviderFromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	SetDefaultProvider(provider)
+	return nil
+}
+
+// DefaultProvider 返回 Init 创建的 provider；没有调用 Init 时每次请求都返回 ErrNotConfigured
+func DefaultProvider() Provider {
+	providerMu.RLock()
+	defer providerMu.RUnlock()
+	return defaultProvider
+}
+
+// SetDefaultProvider 替换默认 provider（测试时可替换为 FakeProvider）
+func SetDefaultProvider(provider Provider) {
+	providerMu.Lock()
+	defaultProvider = provider
+	providerMu.Unlock()
+}
+
+// unconfiguredProvider 没有调用 Init 时使用
+type unconfiguredProvider struct{}
+
+func (unconfiguredProvider) Name() string { return "unconfigured" }
+
+func (unconfiguredProvider) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	return nil, ErrNotConfigured
+}
+
+func (unconfiguredProvider) ChatStream(ctx context.Context, req ChatRequest, onDelta DeltaFunc) (*ChatResponse, error) {
+	return nil, ErrNotConfigured
+}
+
+// ChatJSON 要求模型输出 JSON，并解析到 out 中
+func ChatJSON(ctx context.Context, provider Provider, req ChatRequest, out interface{}) (*ChatResponse, error) {
+	req.JSONOutput = true
+	resp, err := provider.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(ExtractJSON(resp.Content)), out); err != nil {
+		return resp, fmt.Errorf("invalid JSON output: %w", err)
+	}
+	return resp, nil
+}
+
+// ExtractJSON 去掉模型输出中包裹 JSON 的 ``` 代码块
+func ExtractJSON(content string) string {
+	content = strings.TrimSpace(content)
+	if strings.HasPrefix(content, "```") {
+		content = strings.TrimPrefix(content, "```json")
+		content = strings.TrimPrefix(content, "```")
+		content = strings.TrimSuffix(strings.TrimSpace(content), "```")
+	}
+	return strings.TrimSpace(content)
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestChatJSONWithFakeProvider(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     string
+		wantErr  bool
+	}{
+		{"plain JSON", `{"answer":"yes"}`, "yes", false},
+		{"fenced JSON", "```json\n{\"answer\":\"fenced\"}\n```", "fenced", false},
+		{"invalid JSON", "not json", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := NewFakeProvider(tt.response)
+			var out struct {
+				Answer string `json:"answer"`
+			}
+			_, err := ChatJSON(context.Background(), provider, ChatRequest{Messages: []Message{{Role: "user", Content: "q"}}}, &out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if out.Answer != tt.want {
+				t.Errorf("answer = %q, want %q", out.Answer, tt.want)
+			}
+			if len(provider.Requests) != 1 || !provider.Requests[0].JSONOutput {
+				t.Errorf("ChatJSON should send one request with JSONOutput set, got %+v", provider.Requests)
+			}
+		})
+	}
+}
+
+func TestFakeProviderStream(t *testing.T) {
+	provider := NewFakeProvider("one two three")
+	var deltas []string
+	resp, err := provider.ChatStream(context.Background(), ChatRequest{}, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content != "one two three" || len(deltas) != 3 || deltas[1] != " two" {
+		t.Errorf("content = %q, deltas = %q", resp.Content, deltas)
+	}
+}
+
+func TestDefaultProviderNotConfigured(t *testing.T) {
+	SetDefaultProvider(unconfiguredProvider{})
+	if _, err := DefaultProvider().Chat(context.Background(), ChatRequest{}); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("err = %v, want ErrNotConfigured", err)
+	}
+}
+
+func TestLoadConfigRequiresAPIKey(t *testing.T) {
+	tests := []struct {
+		provider string
+		apiKey   string
+		wantErr  bool
+	}{
+		{"", "", true},
+		{"grok", "", true},
+		{"openai", "", true},
+		{"openai", "sk-test", false},
+		{"ollama", "", false},
+		{"fake", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.provider+"/"+tt.apiKey, func(t *testing.T) {
+			t.Setenv("LLM_PROVIDER", tt.provider)
+			t.Setenv("LLM_API_KEY", tt.apiKey)
+			if _, err := LoadConfig(); (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAICompatible 兼容 OpenAI /chat/completions 接口的服务（Grok、OpenAI、Ollama 等）
+type OpenAICompatible struct {
+	cfg     Config
+	client  *http.Client
+	backoff time.Duration // 第一次重试前的等待时间，之后每次翻倍
+}
+
+// NewOpenAICompatible 创建 OpenAI 兼容客户端
+func NewOpenAICompatible(cfg Config) *OpenAICompatible {
+	return &OpenAICompatible{
+		cfg:     cfg,
+		client:  &http.Client{Timeout: cfg.Timeout},
+		backoff: 500 * time.Millisecond,
+	}
+}
+
+func (p *OpenAICompatible) Name() string {
+	return p.cfg.Provider
+}
+
+type chatCompletionRequest struct {
+	Model          string          `json:"model"`
+	Messages       []Message       `json:"messages"`
+	Temperature    float32         `json:"temperature"`
+	MaxTokens      int             `json:"max_tokens,omitempty"`
+	Stream         bool            `json:"stream"`
+	ResponseFormat *responseFormat `json:"response_format,omitempty"`
+	StreamOptions  *streamOptions  `json:"stream_options,omitempty"`
+}
+
+type streamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type chatCompletionChunk struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Delta Message `json:"delta"`
+	} `json:"choices"`
+	Usage *Usage `json:"usage"`
+}
+
+type responseFormat struct {
+	Type string `json:"type"`
+}
+
+type chatCompletionResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message Message `json:"message"`
+	} `json:"choices"`
+	Usage Usage `json:"usage"`
+}
+
+// statusError 接口返回的非 200 状态
+type statusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("llm request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// retryable 只有网络错误（连接失败、超时）、429 和 5xx 可以重试；
+// 调用方已取消、响应无法解析、没有返回内容等错误重试也不会成功
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.StatusCode == http.StatusTooManyRequests || se.StatusCode >= 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func (p *OpenAICompatible) buildRequest(req ChatRequest, stream bool) chatCompletionRequest {
+	body := chatCompletionRequest{
+		Model:       p.cfg.Model,
+		Messages:    req.Messages,
+		Temperature: p.cfg.Temperature,
+		MaxTokens:   req.MaxTokens,
+		Stream:      stream,
+	}
+	if req.Model != "" {
+		body.Model = req.Model
+	}
+	if req.Temperature != nil {
+		body.Temperature = *req.Temperature
+	}
+	if req.JSONOutput {
+		body.ResponseFormat = &responseFormat{Type: "json_object"}
+	}
+	if stream {
+		body.StreamOptions = &streamOptions{IncludeUsage: true}
+	}
+	return body
+}
+
+// Chat 发送对话请求，失败时按指数退避重试
+func (p *OpenAICompatible) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	payload, err := json.Marshal(p.buildRequest(req, false))
+	if err != nil {
+		return nil, err
+	}
+
+	var resp *ChatResponse
+	err = p.withRetry(ctx, func() error {
+		var err error
+		resp, err = p.do(ctx, payload)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	recordUsage(p.Name(), resp.Model, resp.Usage)
+	return resp, nil
+}
+
+// ChatStream 流式对话；只在收到第一段内容之前重试
+func (p *OpenAICompatible) ChatStream(ctx context.Context, req ChatRequest, onDelta DeltaFunc) (*ChatResponse, error) {
+	payload, err := json.Marshal(p.buildRequest(req, true))
+	if err != nil {
+		return nil, err
+	}
+
+	var httpResp *http.Response
+	err = p.withRetry(ctx, func() error {
+		var err error
+		httpResp, err = p.post(ctx, payload)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	resp := &ChatResponse{Model: p.cfg.Model}
+	var content strings.Builder
+	scanner := bufio.NewScanner(httpResp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk chatCompletionChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf("failed to decode llm stream: %w", err)
+		}
+		if chunk.Model != "" {
+			resp.Model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			resp.Usage = *chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			content.WriteString(choice.Delta.Content)
+			if err := onDelta(choice.Delta.Content); err != nil {
+				resp.Content = content.String()
+				recordUsage(p.Name(), resp.Model, resp.Usage)
+				return resp, err
+			}
+		}
+	}
+	resp.Content = content.String()
+	recordUsage(p.Name(), resp.Model, resp.Usage)
+	if err := scanner.Err(); err != nil {
+		return resp, err
+	}
+	return resp, nil
+}
+
+// withRetry 执行请求，可重试的错误按指数退避重试
+func (p *OpenAICompatible) withRetry(ctx context.Context, fn func() error) error {
+	var lastErr error
+	for attempt := 0; attempt <= p.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(p.backoff * time.Duration(1<<(attempt-1))):
+			}
+		}
+
+		lastErr = fn()
+		if lastErr == nil || !retryable(ctx, lastErr) {
+			return lastErr
+		}
+	}
+	return lastErr
+}
+
+// post 发送请求，非 200 时返回 statusError
+func (p *OpenAICompatible) post(ctx context.Context, payload []byte) (*http.Response, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(p.cfg.BaseURL, "/")+"/chat/completions", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if p.cfg.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.cfg.APIKey)
+	}
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+		return nil, &statusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	return resp, nil
+}
+
+func (p *OpenAICompatible) do(ctx context.Context, payload []byte) (*ChatResponse, error) {
+	resp, err := p.post(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var completion chatCompletionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&completion); err != nil {
+		return nil, fmt.Errorf("failed to decode llm response: %w", err)
+	}
+	if len(completion.Choices) == 0 {
+		return nil, ErrEmptyResponse
+	}
+	return &ChatResponse{
+		Content: completion.Choices[0].Message.Content,
+		Model:   completion.Model,
+		Usage:   completion.Usage,
+	}, nil
+}
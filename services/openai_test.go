@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestProvider 创建指向测试服务器的客户端，重试间隔缩短为 1ms
+func newTestProvider(url string, maxRetries int) *OpenAICompatible {
+	p := NewOpenAICompatible(Config{Provider: "openai", BaseURL: url, Model: "test", Timeout: time.Second, MaxRetries: maxRetries})
+	p.backoff = time.Millisecond
+	return p
+}
+
+func TestChatRetries(t *testing.T) {
+	const ok = `{"model":"test","choices":[{"message":{"role":"assistant","content":"hi"}}]}`
+	tests := []struct {
+		name      string
+		responses []func(w http.ResponseWriter)
+		wantCalls int32
+		wantErr   bool
+	}{
+		{
+			name:      "success",
+			responses: []func(w http.ResponseWriter){body(http.StatusOK, ok)},
+			wantCalls: 1,
+		},
+		{
+			name:      "retry 429 then success",
+			responses: []func(w http.ResponseWriter){body(http.StatusTooManyRequests, ""), body(http.StatusOK, ok)},
+			wantCalls: 2,
+		},
+		{
+			name:      "retry 5xx until attempts run out",
+			responses: []func(w http.ResponseWriter){body(http.StatusBadGateway, ""), body(http.StatusBadGateway, ""), body(http.StatusBadGateway, "")},
+			wantCalls: 3,
+			wantErr:   true,
+		},
+		{
+			name:      "no retry on 400",
+			responses: []func(w http.ResponseWriter){body(http.StatusBadRequest, "bad request")},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "no retry on invalid JSON",
+			responses: []func(w http.ResponseWriter){body(http.StatusOK, "not json")},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "no retry on empty choices",
+			responses: []func(w http.ResponseWriter){body(http.StatusOK, `{"choices":[]}`)},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&calls, 1)
+				tt.responses[int(n)-1](w)
+			}))
+			defer server.Close()
+
+			resp, err := newTestProvider(server.URL, 2).Chat(context.Background(), ChatRequest{Messages: []Message{{Role: "user", Content: "hello"}}})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if !tt.wantErr && resp.Content != "hi" {
+				t.Errorf("content = %q, want %q", resp.Content, "hi")
+			}
+		})
+	}
+}
+
+func TestChatRetriesNetworkErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	var netCalls int32
+	p := newTestProvider(url, 2)
+	err := p.withRetry(context.Background(), func() error {
+		atomic.AddInt32(&netCalls, 1)
+		_, err := p.post(context.Background(), []byte("{}"))
+		return err
+	})
+	if err == nil {
+		t.Fatal("expected connection error")
+	}
+	if netCalls != 3 {
+		t.Errorf("calls = %d, want 3", netCalls)
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want bool
+	}{
+		{"429", context.Background(), &statusError{StatusCode: http.StatusTooManyRequests}, true},
+		{"500", context.Background(), &statusError{StatusCode: http.StatusInternalServerError}, true},
+		{"401", context.Background(), &statusError{StatusCode: http.StatusUnauthorized}, false},
+		{"decode error", context.Background(), fmt.Errorf("failed to decode llm response: %w", errors.New("invalid character")), false},
+		{"empty response", context.Background(), ErrEmptyResponse, false},
+		{"caller canceled", canceled, &statusError{StatusCode: http.StatusBadGateway}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryable(tt.ctx, tt.err); got != tt.want {
+				t.Errorf("retryable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func body(status int, content string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.WriteHeader(status)
+		w.Write([]byte(content))
+	}
+}
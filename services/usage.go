@@ -0,0 +1,43 @@
+package services
+
+import "sync"
+
+// UsageStats 某个 provider / 模型的累计用量
+type UsageStats struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	Requests int    `json:"requests"`
+	Usage
+}
+
+var (
+	usageMu    sync.Mutex
+	usageStats = make(map[string]*UsageStats)
+)
+
+// recordUsage 累计 token 用量
+func recordUsage(provider, model string, usage Usage) {
+	usageMu.Lock()
+	defer usageMu.Unlock()
+	key := provider + "/" + model
+	stats, ok := usageStats[key]
+	if !ok {
+		stats = &UsageStats{Provider: provider, Model: model}
+		usageStats[key] = stats
+	}
+	stats.Requests++
+	stats.PromptTokens += usage.PromptTokens
+	stats.CompletionTokens += usage.CompletionTokens
+	stats.TotalTokens += usage.TotalTokens
+}
+
+// UsageSnapshot 返回进程启动以来的累计用量
+func UsageSnapshot() []UsageStats {
+	usageMu.Lock()
+	defer usageMu.Unlock()
+	snapshot := make([]UsageStats, 0, len(usageStats))
+	for _, stats := range usageStats {
+		snapshot = append(snapshot, *stats)
+	}
+	return snapshot
+}
@@ -0,0 +1,312 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Queen2333/ielts_test_backend/database"
+	"github.com/Queen2333/ielts_test_backend/jobs"
+	"github.com/Queen2333/ielts_test_backend/models"
+	"github.com/Queen2333/ielts_test_backend/services"
+)
+
+// aiFeedbackAttempts 模型输出不合法时最多请求的次数
+const aiFeedbackAttempts = 2
+
+// aiFeedbackJobAttempts 批改任务失败（如模型服务不可用）时最多执行的次数
+const aiFeedbackJobAttempts = 3
+
+// aiFeedbackTimeout 一条记录的批改总超时
+const aiFeedbackTimeout = 5 * time.Minute
+
+// aiFeedbackStaleAfter 批改保持 pending / processing 超过该时间未更新时视为任务已丢失（如 worker 崩溃），允许重新提交
+// 大于 aiFeedbackJobAttempts 次执行的超时和退避时间之和
+const aiFeedbackStaleAfter = 30 * time.Minute
+
+var aiFeedbackTemperature float32 = 0.2
+
+const aiWritingSystemPrompt = `You are an experienced IELTS writing examiner. Assess the essay against the official IELTS writing band descriptors.
+Reply with a single JSON object and nothing else, using exactly this shape:
+{
+  "criteria": {
+    "task_achievement": {"band": <whole number 0-9>, "explanation": "<why this band; Task Achievement for Task 1, Task Response for Task 2>"},
+    "coherence_cohesion": {"band": <whole number 0-9>, "explanation": "..."},
+    "lexical_resource": {"band": <whole number 0-9>, "explanation": "..."},
+    "grammatical_range": {"band": <whole number 0-9>, "explanation": "..."}
+  },
+  "summary": "<overall comment in two or three sentences>",
+  "corrections": [{"original": "<sentence copied exactly from the essay>", "corrected": "<corrected sentence>", "explanation": "..."}],
+  "vocabulary": [{"original": "<word or phrase from the essay>", "suggestion": "<more precise or advanced alternative>", "example": "<example sentence>"}]
+}`
+
+// ErrAIFeedbackInProgress 批改进行中，不能重复提交
+var ErrAIFeedbackInProgress = errors.New("ai feedback is already in progress")
+
+// FindAIWritingFeedback 获取记录的 AI 批改，不存在时返回 nil
+func FindAIWritingFeedback(recordType string, recordID int) (*models.AIWritingFeedbackItem, error) {
+	results, _, err := database.PaginationQuery("ai_writing_feedback", 1, -1, map[string]interface{}{
+		"record_type": recordType,
+		"record_id":   recordID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var items []models.AIWritingFeedbackItem
+	if err := DecodeJSONValue(results, &items); err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+	return &items[0], nil
+}
+
+// aiFeedbackInProgress 批改是否仍在进行中；长时间没有更新的 pending / processing 记录不算进行中
+func aiFeedbackInProgress(item *models.AIWritingFeedbackItem, now time.Time) bool {
+	if item.Status != models.AIFeedbackPending && item.Status != models.AIFeedbackProcessing {
+		return false
+	}
+	updatedAt, err := time.ParseInLocation(timeLayout, item.UpdatedAt, time.Local)
+	if err != nil {
+		return false
+	}
+	return now.Sub(updatedAt) < aiFeedbackStaleAfter
+}
+
+// RequestAIWritingFeedback 创建（或重新开始）AI 批改，加入后台任务队列
+// 批改进行中时返回 ErrAIFeedbackInProgress；超过 aiFeedbackStaleAfter 未更新的批改可以重新开始
+func RequestAIWritingFeedback(recordType string, recordID int, userID string) (*models.AIWritingFeedbackItem, error) {
+	existing, err := FindAIWritingFeedback(recordType, recordID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Format(timeLayout)
+
+	var feedback *models.AIWritingFeedbackItem
+	if existing != nil {
+		if aiFeedbackInProgress(existing, time.Now()) {
+			return existing, ErrAIFeedbackInProgress
+		}
+		if err := database.UpdateColumns("ai_writing_feedback", existing.ID, map[string]interface{}{
+			"status":     models.AIFeedbackPending,
+			"error":      "",
+			"updated_at": now,
+		}); err != nil {
+			return nil, err
+		}
+		existing.Status = models.AIFeedbackPending
+		existing.Error = ""
+		existing.UpdatedAt = now
+		feedback = existing
+	} else {
+		feedback = &models.AIWritingFeedbackItem{
+			RecordType: recordType,
+			RecordID:   recordID,
+			UserID:     userID,
+			Source:     models.FeedbackSourceAI,
+			Status:     models.AIFeedbackPending,
+			Tasks:      []models.AITaskFeedback{},
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		id, err := database.InsertData("ai_writing_feedback", feedback, "create")
+		if err != nil {
+			return nil, err
+		}
+		feedback.ID = id
+	}
+
+	if _, err := jobs.Enqueue(jobs.TypeAIWritingFeedback, jobs.AIWritingFeedbackPayload{
+		FeedbackID: feedback.ID,
+		RecordType: recordType,
+		RecordID:   recordID,
+	}, jobs.WithMaxAttempts(aiFeedbackJobAttempts), jobs.WithUserID(userID)); err != nil {
+		database.UpdateColumns("ai_writing_feedback", feedback.ID, map[string]interface{}{
+			"status": models.AIFeedbackFailed,
+			"error":  "failed to enqueue: " + err.Error(),
+		})
+		return nil, err
+	}
+	return feedback, nil
+}
+
+// GenerateAIWritingFeedback 调用大模型批改记录中的每篇作文并保存结果
+// lastAttempt 为 false 时失败会由任务队列重试，状态保持为 pending
+func GenerateAIWritingFeedback(feedbackID int, recordType string, recordID int, lastAttempt bool) error {
+	if err := database.UpdateColumns("ai_writing_feedback", feedbackID, map[string]interface{}{
+		"status":     models.AIFeedbackProcessing,
+		"updated_at": time.Now().Format(timeLayout),
+	}); err != nil {
+		return err
+	}
+
+	tasks, band, usage, err := generateAIWritingFeedback(recordType, recordID)
+	if err != nil {
+		status := models.AIFeedbackPending
+		if lastAttempt {
+			status = models.AIFeedbackFailed
+		}
+		database.UpdateColumns("ai_writing_feedback", feedbackID, map[string]interface{}{
+			"status":     status,
+			"error":      err.Error(),
+			"updated_at": time.Now().Format(timeLayout),
+		})
+		return err
+	}
+
+	return database.UpdateColumns("ai_writing_feedback", feedbackID, map[string]interface{}{
+		"status":       models.AIFeedbackDone,
+		"provider":     usage.Provider,
+		"model":        usage.Model,
+		"tasks":        tasks,
+		"band":         band,
+		"error":        "",
+		"total_tokens": usage.TotalTokens,
+		"updated_at":   time.Now().Format(timeLayout),
+	})
+}
+
+func generateAIWritingFeedback(recordType string, recordID int) ([]models.AITaskFeedback, float64, services.UsageStats, error) {
+	var usage services.UsageStats
+	essays, _, err := LoadWritingEssays(recordType, recordID)
+	if err != nil {
+		return nil, 0, usage, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), aiFeedbackTimeout)
+	defer cancel()
+
+	provider := services.DefaultProvider()
+	usage.Provider = provider.Name()
+	tasks := []models.AITaskFeedback{}
+	var grades []models.TaskGrade
+	for _, essay := range essays {
+		if strings.TrimSpace(essay.Text) == "" {
+			continue
+		}
+		task, resp, err := requestAITaskFeedback(ctx, provider, essay)
+		if resp != nil {
+			usage.Model = resp.Model
+			usage.TotalTokens += resp.Usage.TotalTokens
+		}
+		if err != nil {
+			return nil, 0, usage, fmt.Errorf("part %d: %w", essay.PartID, err)
+		}
+		tasks = append(tasks, task)
+		grades = append(grades, models.TaskGrade{PartID: task.PartID, TaskType: task.TaskType, Band: task.Band})
+	}
+
+	band, err := WritingBand(grades)
+	if err != nil {
+		return nil, 0, usage, err
+	}
+	return tasks, band, usage, nil
+}
+
+// requestAITaskFeedback 批改单篇作文，输出不合法时重新请求
+func requestAITaskFeedback(ctx context.Context, provider services.Provider, essay models.WritingEssay) (models.AITaskFeedback, *services.ChatResponse, error) {
+	req := services.ChatRequest{
+		Messages: []services.Message{
+			{Role: "system", Content: aiWritingSystemPrompt},
+			{Role: "user", Content: aiWritingPrompt(essay)},
+		},
+		Temperature: &aiFeedbackTemperature,
+	}
+
+	var lastErr error
+	var total services.ChatResponse
+	for attempt := 0; attempt < aiFeedbackAttempts; attempt++ {
+		var task models.AITaskFeedback
+		resp, err := services.ChatJSON(ctx, provider, req, &task)
+		if resp != nil {
+			total.Model = resp.Model
+			total.Usage.TotalTokens += resp.Usage.TotalTokens
+		}
+		if err != nil {
+			lastErr = err
+			if resp == nil {
+				// 请求本身失败（已在 provider 中重试），不再重复请求
+				break
+			}
+			continue
+		}
+		if problems := ValidateAITaskFeedback(&task, essay); len(problems) > 0 {
+			lastErr = fmt.Errorf("invalid feedback: %s", strings.Join(problems, "; "))
+			continue
+		}
+		return task, &total, nil
+	}
+	return models.AITaskFeedback{}, &total, lastErr
+}
+
+// aiWritingPrompt 题目（标题、副标题、Task 1 图表描述）和学生作文
+func aiWritingPrompt(essay models.WritingEssay) string {
+	var b strings.Builder
+	taskName := "Task 2"
+	if essay.TaskType == "1" {
+		taskName = "Task 1"
+	}
+	fmt.Fprintf(&b, "IELTS Writing %s\n\nPrompt:\n%s\n", taskName, essay.Title)
+	if essay.SubTitle != "" {
+		fmt.Fprintf(&b, "%s\n", essay.SubTitle)
+	}
+	if essay.ImgDesc != "" {
+		fmt.Fprintf(&b, "\nDescription of the visual:\n%s\n", essay.ImgDesc)
+	}
+	fmt.Fprintf(&b, "\nMinimum words: %d. Candidate's word count: %d.\n", MinWordsForTask(essay.TaskType), essay.WordCount)
+	fmt.Fprintf(&b, "\nCandidate's answer:\n%s\n", essay.Text)
+	return b.String()
+}
+
+// ValidateAITaskFeedback 校验模型输出：四项为 0-9 整数分且有说明
+// 原句不在作文中的改错和不完整的词汇建议会被丢弃；PartID、TaskType、Band 由服务端填入
+func ValidateAITaskFeedback(task *models.AITaskFeedback, essay models.WritingEssay) []string {
+	criteria := map[string]models.AICriterionFeedback{
+		models.CriterionTaskAchievement:   task.Criteria.TaskAchievement,
+		models.CriterionCoherenceCohesion: task.Criteria.CoherenceCohesion,
+		models.CriterionLexicalResource:   task.Criteria.LexicalResource,
+		models.CriterionGrammaticalRange:  task.Criteria.GrammaticalRange,
+	}
+	scores := models.CriteriaScores{
+		TaskAchievement:   task.Criteria.TaskAchievement.Band,
+		CoherenceCohesion: task.Criteria.CoherenceCohesion.Band,
+		LexicalResource:   task.Criteria.LexicalResource.Band,
+		GrammaticalRange:  task.Criteria.GrammaticalRange.Band,
+	}
+	problems := validateCriteriaScores(scores)
+	for criterion, feedback := range criteria {
+		if strings.TrimSpace(feedback.Explanation) == "" {
+			problems = append(problems, fmt.Sprintf("%s explanation is required", criterion))
+		}
+	}
+	if len(problems) > 0 {
+		return problems
+	}
+
+	corrections := []models.AICorrection{}
+	for _, correction := range task.Corrections {
+		original := strings.TrimSpace(correction.Original)
+		if original == "" || strings.TrimSpace(correction.Corrected) == "" || !strings.Contains(essay.Text, original) {
+			continue
+		}
+		correction.Original = original
+		corrections = append(corrections, correction)
+	}
+	vocabulary := []models.AIVocabularyUpgrade{}
+	for _, upgrade := range task.Vocabulary {
+		if strings.TrimSpace(upgrade.Original) == "" || strings.TrimSpace(upgrade.Suggestion) == "" {
+			continue
+		}
+		vocabulary = append(vocabulary, upgrade)
+	}
+
+	task.PartID = essay.PartID
+	task.TaskType = essay.TaskType
+	task.Band = TaskBand(scores)
+	task.Corrections = corrections
+	task.Vocabulary = vocabulary
+	return nil
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Queen2333/ielts_test_backend/models"
+)
+
+func TestAIFeedbackInProgress(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local)
+	recent := now.Add(-time.Minute).Format(timeLayout)
+	stale := now.Add(-aiFeedbackStaleAfter - time.Minute).Format(timeLayout)
+
+	tests := []struct {
+		name      string
+		status    string
+		updatedAt string
+		want      bool
+	}{
+		{"pending recently updated", models.AIFeedbackPending, recent, true},
+		{"processing recently updated", models.AIFeedbackProcessing, recent, true},
+		{"pending stale", models.AIFeedbackPending, stale, false},
+		{"processing stale", models.AIFeedbackProcessing, stale, false},
+		{"missing updated_at", models.AIFeedbackProcessing, "", false},
+		{"done", models.AIFeedbackDone, recent, false},
+		{"failed", models.AIFeedbackFailed, recent, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := &models.AIWritingFeedbackItem{Status: tt.status, UpdatedAt: tt.updatedAt}
+			if got := aiFeedbackInProgress(item, now); got != tt.want {
+				t.Errorf("aiFeedbackInProgress() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
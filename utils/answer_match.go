@@ -0,0 +1,256 @@
+package utils
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// WordLimit 题组说明中的字数限制，如 "NO MORE THAN TWO WORDS AND/OR A NUMBER"
+type WordLimit struct {
+	MaxWords    int  // 最多单词数
+	AllowNumber bool // 是否额外允许一个数字
+}
+
+var (
+	htmlTagRegex     = regexp.MustCompile(`<[^>]*>`)
+	wordLimitRegex   = regexp.MustCompile(`NO MORE THAN (\w+) WORDS?( AND/OR A NUMBER)?`)
+	wordOnlyRegex    = regexp.MustCompile(`\b(ONE|TWO|THREE|FOUR|FIVE|\d+) WORDS?( AND/OR A NUMBER)?( ONLY)?`)
+	numberOnlyRegex  = regexp.MustCompile(`\bA NUMBER ONLY\b`)
+	optionalWordExpr = regexp.MustCompile(`\(([^()]*)\)`)
+	digitGroupRegex  = regexp.MustCompile(`(\d),(\d{3})`)
+)
+
+var limitWords = map[string]int{"ONE": 1, "TWO": 2, "THREE": 3, "FOUR": 4, "FIVE": 5}
+
+// ParseWordLimit 从题组说明中解析字数限制
+func ParseWordLimit(instructions string) (WordLimit, bool) {
+	text := strings.ToUpper(htmlTagRegex.ReplaceAllString(instructions, " "))
+	text = strings.Join(strings.Fields(text), " ")
+
+	parseCount := func(word string) (int, bool) {
+		if n, ok := limitWords[word]; ok {
+			return n, true
+		}
+		n, err := strconv.Atoi(word)
+		return n, err == nil && n > 0
+	}
+
+	if m := wordLimitRegex.FindStringSubmatch(text); m != nil {
+		if n, ok := parseCount(m[1]); ok {
+			return WordLimit{MaxWords: n, AllowNumber: m[2] != ""}, true
+		}
+	}
+	if m := wordOnlyRegex.FindStringSubmatch(text); m != nil && (m[2] != "" || m[3] != "") {
+		if n, ok := parseCount(m[1]); ok {
+			return WordLimit{MaxWords: n, AllowNumber: m[2] != ""}, true
+		}
+	}
+	if numberOnlyRegex.MatchString(text) {
+		return WordLimit{MaxWords: 0, AllowNumber: true}, true
+	}
+	return WordLimit{}, false
+}
+
+// ExceedsWordLimit 判断用户答案是否超过字数限制
+// 连字符连接的词算一个词；允许数字时最多额外计入一个数字
+func ExceedsWordLimit(answer string, limit WordLimit) bool {
+	words, numbers := 0, 0
+	for _, token := range strings.Fields(answer) {
+		token = strings.TrimFunc(token, isTrimmable)
+		if token == "" {
+			continue
+		}
+		if isNumeric(token) {
+			numbers++
+		} else {
+			words++
+		}
+	}
+	if limit.AllowNumber {
+		if numbers > 1 {
+			words += numbers - 1
+		}
+	} else {
+		words += numbers
+	}
+	return words > limit.MaxWords
+}
+
+// AcceptedAnswers 展开正确答案中的可选写法：
+// "/" 分隔的多个答案，以及括号中的可选单词，如 "(the) station/railway station"
+func AcceptedAnswers(correct string) []string {
+	var accepted []string
+	seen := make(map[string]bool)
+	for _, alternative := range splitAlternatives(correct) {
+		for _, variant := range expandOptionalWords(alternative) {
+			normalized := NormalizeAnswerText(variant)
+			if normalized != "" && !seen[normalized] {
+				seen[normalized] = true
+				accepted = append(accepted, normalized)
+			}
+		}
+	}
+	return accepted
+}
+
+// MatchGapFill 判断填空题答案是否正确
+func MatchGapFill(correct, user string) bool {
+	normalizedUser := NormalizeAnswerText(user)
+	if normalizedUser == "" {
+		return false
+	}
+	for _, accepted := range AcceptedAnswers(correct) {
+		if accepted == normalizedUser {
+			return true
+		}
+	}
+	return false
+}
+
+func splitAlternatives(correct string) []string {
+	parts := strings.Split(strings.ReplaceAll(correct, " OR ", "/"), "/")
+	var alternatives []string
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			alternatives = append(alternatives, part)
+		}
+	}
+	return alternatives
+}
+
+// expandOptionalWords 展开括号中的可选单词，最多处理 4 处括号
+func expandOptionalWords(answer string) []string {
+	loc := optionalWordExpr.FindStringSubmatchIndex(answer)
+	if loc == nil {
+		return []string{answer}
+	}
+	if len(optionalWordExpr.FindAllStringIndex(answer, -1)) > 4 {
+		return []string{optionalWordExpr.ReplaceAllString(answer, "$1")}
+	}
+
+	with := answer[:loc[0]] + answer[loc[2]:loc[3]] + answer[loc[1]:]
+	without := answer[:loc[0]] + answer[loc[1]:]
+	return append(expandOptionalWords(with), expandOptionalWords(without)...)
+}
+
+// NormalizeAnswerText 规范化答案文本：统一大小写、去掉首尾标点、合并空白、数字单词转为数字
+func NormalizeAnswerText(answer string) string {
+	answer = strings.NewReplacer("’", "'", "‘", "'", "“", "\"", "”", "\"", "–", "-", "—", "-").Replace(answer)
+	answer = strings.ToLower(answer)
+	answer = digitGroupRegex.ReplaceAllString(answer, "$1$2")
+
+	var tokens []string
+	for _, token := range strings.Fields(answer) {
+		token = strings.TrimFunc(token, isTrimmable)
+		if token == "" {
+			continue
+		}
+		// twenty-one 这类由数字单词组成的连字符词拆开处理
+		if pieces := strings.Split(token, "-"); len(pieces) > 1 && allNumberWords(pieces) {
+			tokens = append(tokens, pieces...)
+			continue
+		}
+		tokens = append(tokens, token)
+	}
+	return strings.Join(replaceNumberWords(tokens), " ")
+}
+
+func isTrimmable(r rune) bool {
+	return unicode.IsPunct(r) && r != '%' || unicode.IsSpace(r)
+}
+
+func isNumeric(token string) bool {
+	token = strings.TrimRight(digitGroupRegex.ReplaceAllString(token, "$1$2"), "%")
+	_, err := strconv.ParseFloat(token, 64)
+	return err == nil
+}
+
+var (
+	unitNumbers = map[string]int{
+		"zero": 0, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6, "seven": 7,
+		"eight": 8, "nine": 9, "ten": 10, "eleven": 11, "twelve": 12, "thirteen": 13,
+		"fourteen": 14, "fifteen": 15, "sixteen": 16, "seventeen": 17, "eighteen": 18, "nineteen": 19,
+	}
+	tensNumbers = map[string]int{
+		"twenty": 20, "thirty": 30, "forty": 40, "fifty": 50,
+		"sixty": 60, "seventy": 70, "eighty": 80, "ninety": 90,
+	}
+	scaleNumbers = map[string]int{"hundred": 100, "thousand": 1000, "million": 1000000}
+)
+
+func isNumberWord(word string) bool {
+	_, unit := unitNumbers[word]
+	_, tens := tensNumbers[word]
+	_, scale := scaleNumbers[word]
+	return unit || tens || scale
+}
+
+func allNumberWords(words []string) bool {
+	for _, word := range words {
+		if !isNumberWord(word) {
+			return false
+		}
+	}
+	return true
+}
+
+// replaceNumberWords 把连续的数字单词转换为阿拉伯数字，如 "two hundred and fifty" -> "250"
+func replaceNumberWords(tokens []string) []string {
+	var result []string
+	for i := 0; i < len(tokens); {
+		value, consumed := parseNumberWords(tokens[i:])
+		if consumed == 0 {
+			result = append(result, tokens[i])
+			i++
+			continue
+		}
+		result = append(result, strconv.Itoa(value))
+		i += consumed
+	}
+	return result
+}
+
+func parseNumberWords(tokens []string) (int, int) {
+	total, current, consumed := 0, 0, 0
+	last := "" // 上一个数字单词的类别：unit / tens / scale
+	for i, token := range tokens {
+		if token == "and" {
+			// "and" 只在 hundred/thousand 之后且后面还有数字单词时才算作数字的一部分
+			if last != "scale" || i+1 >= len(tokens) || !isNumberWord(tokens[i+1]) {
+				break
+			}
+			continue
+		}
+		if n, ok := unitNumbers[token]; ok {
+			// "one two" 是两个数字，"twenty one" 是一个数字
+			if last == "unit" || (last == "tens" && n >= 10) {
+				break
+			}
+			current += n
+			last = "unit"
+		} else if n, ok := tensNumbers[token]; ok {
+			if last == "unit" || last == "tens" {
+				break
+			}
+			current += n
+			last = "tens"
+		} else if n, ok := scaleNumbers[token]; ok {
+			if last == "" {
+				break
+			}
+			if n == 100 {
+				current *= n
+			} else {
+				total += current * n
+				current = 0
+			}
+			last = "scale"
+		} else {
+			break
+		}
+		consumed = i + 1
+	}
+	return total + current, consumed
+}
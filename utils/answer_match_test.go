@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseWordLimit(t *testing.T) {
+	tests := []struct {
+		name         string
+		instructions string
+		want         WordLimit
+		wantOK       bool
+	}{
+		{"words and number", "Write NO MORE THAN TWO WORDS AND/OR A NUMBER for each answer.", WordLimit{2, true}, true},
+		{"words only with html", "<p>Write <b>NO MORE THAN THREE WORDS</b></p>", WordLimit{3, false}, true},
+		{"digit count", "Write no more than 3 words", WordLimit{3, false}, true},
+		{"one word only", "Write ONE WORD ONLY for each answer.", WordLimit{1, false}, true},
+		{"one word and/or a number", "Write ONE WORD AND/OR A NUMBER for each answer.", WordLimit{1, true}, true},
+		{"number only", "Write A NUMBER ONLY.", WordLimit{0, true}, true},
+		{"no limit", "Choose the correct letter, A, B or C.", WordLimit{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseWordLimit(tt.instructions)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ParseWordLimit(%q) = %+v, %v, want %+v, %v", tt.instructions, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestExceedsWordLimit(t *testing.T) {
+	tests := []struct {
+		name   string
+		answer string
+		limit  WordLimit
+		want   bool
+	}{
+		{"within limit", "old mill", WordLimit{2, false}, false},
+		{"over limit", "the old mill", WordLimit{2, false}, true},
+		{"hyphenated word counts once", "well-known", WordLimit{1, false}, false},
+		{"number counts as word", "3 rooms", WordLimit{1, false}, true},
+		{"extra number allowed", "3 rooms", WordLimit{1, true}, false},
+		{"second number counts as word", "3 4 rooms", WordLimit{1, true}, true},
+		{"number only", "25%", WordLimit{0, true}, false},
+		{"punctuation ignored", "mill .", WordLimit{1, false}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExceedsWordLimit(tt.answer, tt.limit); got != tt.want {
+				t.Errorf("ExceedsWordLimit(%q, %+v) = %v, want %v", tt.answer, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAcceptedAnswers(t *testing.T) {
+	tests := []struct {
+		correct string
+		want    []string
+	}{
+		{"station", []string{"station"}},
+		{"(the) station/railway station", []string{"the station", "station", "railway station"}},
+		{"colour OR color", []string{"colour", "color"}},
+		{"(the) (old) mill", []string{"the old mill", "the mill", "old mill", "mill"}},
+		{"Twenty-five / 25", []string{"25"}},
+		{" / ", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.correct, func(t *testing.T) {
+			if got := AcceptedAnswers(tt.correct); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AcceptedAnswers(%q) = %q, want %q", tt.correct, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchGapFill(t *testing.T) {
+	tests := []struct {
+		name    string
+		correct string
+		user    string
+		want    bool
+	}{
+		{"exact", "station", "station", true},
+		{"case and punctuation", "station", " Station. ", true},
+		{"curly apostrophe", "children's", "children’s", true},
+		{"optional word present", "(the) station", "the station", true},
+		{"optional word omitted", "(the) station", "station", true},
+		{"alternative", "(the) station/railway station", "Railway Station", true},
+		{"synonym not listed", "(the) station/railway station", "train station", false},
+		{"or alternative", "colour OR color", "color", true},
+		{"number word", "25", "twenty-five", true},
+		{"number words with spaces", "25", "twenty five", true},
+		{"number with and", "250", "two hundred and fifty", true},
+		{"thousands separator", "1000", "1,000", true},
+		{"number word in phrase", "3 rooms", "three rooms", true},
+		{"separate numbers", "1 2", "one two", true},
+		{"wrong number", "25", "twenty-six", false},
+		{"empty answer", "station", "", false},
+		{"punctuation only", "station", "...", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchGapFill(tt.correct, tt.user); got != tt.want {
+				t.Errorf("MatchGapFill(%q, %q) = %v, want %v", tt.correct, tt.user, got, tt.want)
+			}
+		})
+	}
+}
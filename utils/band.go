@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/Queen2333/ielts_test_backend/database"
+	"github.com/Queen2333/ielts_test_backend/models"
+)
+
+// 官方分数换算表，数据库中没有配置时使用
+var defaultBandTables = map[string][]models.BandRow{
+	bandTableKey(models.SkillListening, ""): toBandRows([][2]float64{
+		{39, 9.0}, {37, 8.5}, {35, 8.0}, {32, 7.5}, {30, 7.0}, {26, 6.5}, {23, 6.0},
+		{18, 5.5}, {16, 5.0}, {13, 4.5}, {10, 4.0}, {8, 3.5}, {6, 3.0}, {4, 2.5},
+		{2, 2.0}, {1, 1.0}, {0, 0},
+	}),
+	bandTableKey(models.SkillReading, models.ModuleAcademic): toBandRows([][2]float64{
+		{39, 9.0}, {37, 8.5}, {35, 8.0}, {33, 7.5}, {30, 7.0}, {27, 6.5}, {23, 6.0},
+		{19, 5.5}, {15, 5.0}, {13, 4.5}, {10, 4.0}, {8, 3.5}, {6, 3.0}, {4, 2.5},
+		{2, 2.0}, {1, 1.0}, {0, 0},
+	}),
+	bandTableKey(models.SkillReading, models.ModuleGeneral): toBandRows([][2]float64{
+		{40, 9.0}, {39, 8.5}, {37, 8.0}, {36, 7.5}, {34, 7.0}, {32, 6.5}, {30, 6.0},
+		{27, 5.5}, {23, 5.0}, {19, 4.5}, {15, 4.0}, {12, 3.5}, {9, 3.0}, {6, 2.5},
+		{3, 2.0}, {1, 1.0}, {0, 0},
+	}),
+}
+
+// toBandRows 把 {答对题数, Band 分} 数组转换为换算表
+func toBandRows(pairs [][2]float64) []models.BandRow {
+	rows := make([]models.BandRow, 0, len(pairs))
+	for _, pair := range pairs {
+		rows = append(rows, models.BandRow{MinRaw: int(pair[0]), Band: pair[1]})
+	}
+	return rows
+}
+
+// NormalizeModule 规范化考试类型，未填写时默认学术类，其他取值返回错误
+func NormalizeModule(module string) (string, error) {
+	switch module {
+	case "", models.ModuleAcademic:
+		return models.ModuleAcademic, nil
+	case models.ModuleGeneral:
+		return models.ModuleGeneral, nil
+	}
+	return "", fmt.Errorf("invalid module %q", module)
+}
+
+// bandTableKey 听力只有一张换算表，不区分 module；module 须已经过 NormalizeModule
+func bandTableKey(skill, module string) string {
+	if skill == models.SkillListening {
+		return skill
+	}
+	return skill + ":" + module
+}
+
+// DefaultBandTables 返回内置的官方换算表
+func DefaultBandTables() []models.BandTableItem {
+	return []models.BandTableItem{
+		{Skill: models.SkillListening, Conversion: defaultBandTables[bandTableKey(models.SkillListening, "")]},
+		{Skill: models.SkillReading, Module: models.ModuleAcademic, Conversion: defaultBandTables[bandTableKey(models.SkillReading, models.ModuleAcademic)]},
+		{Skill: models.SkillReading, Module: models.ModuleGeneral, Conversion: defaultBandTables[bandTableKey(models.SkillReading, models.ModuleGeneral)]},
+	}
+}
+
+// GetBandTable 获取换算表，优先使用数据库中管理员配置的版本
+func GetBandTable(skill, module string) (models.BandTableItem, error) {
+	if skill == models.SkillListening {
+		module = ""
+	} else {
+		normalized, err := NormalizeModule(module)
+		if err != nil {
+			return models.BandTableItem{Skill: skill, Module: module}, err
+		}
+		module = normalized
+	}
+
+	table := models.BandTableItem{Skill: skill, Module: module}
+	db := database.GetDB()
+	var conversion string
+	err := db.QueryRow("SELECT id, conversion FROM band_tables WHERE skill = ? AND module = ?", skill, module).Scan(&table.ID, &conversion)
+	if err == nil {
+		if rows, ok := parseBandRows(conversion); ok {
+			table.Conversion = rows
+			return table, nil
+		}
+		fmt.Printf("Invalid band table %s/%s in database, using default\n", skill, module)
+	} else if !database.IsNoRowsError(err) {
+		return table, err
+	}
+
+	rows, ok := defaultBandTables[bandTableKey(skill, module)]
+	if !ok {
+		return table, fmt.Errorf("no band table for %s/%s", skill, module)
+	}
+	table.Conversion = rows
+	return table, nil
+}
+
+func parseBandRows(conversion string) ([]models.BandRow, bool) {
+	var rows []models.BandRow
+	if err := json.Unmarshal([]byte(conversion), &rows); err != nil {
+		return nil, false
+	}
+	if ValidateBandRows(rows) != nil {
+		return nil, false
+	}
+	return rows, true
+}
+
+// ValidateBandRows 校验换算表：答对题数 0-40、分数为 0-9 的半分、且必须覆盖 0 分
+func ValidateBandRows(rows []models.BandRow) error {
+	if len(rows) == 0 {
+		return fmt.Errorf("band table is empty")
+	}
+	hasZero := false
+	seen := make(map[int]bool)
+	for _, row := range rows {
+		if row.MinRaw < 0 || row.MinRaw > QuestionsPerSkill {
+			return fmt.Errorf("min_raw %d out of range", row.MinRaw)
+		}
+		if row.Band < 0 || row.Band > 9 || math.Mod(row.Band*2, 1) != 0 {
+			return fmt.Errorf("invalid band %v", row.Band)
+		}
+		if seen[row.MinRaw] {
+			return fmt.Errorf("duplicate min_raw %d", row.MinRaw)
+		}
+		seen[row.MinRaw] = true
+		hasZero = hasZero || row.MinRaw == 0
+	}
+	if !hasZero {
+		return fmt.Errorf("band table must contain a row for min_raw 0")
+	}
+
+	sorted := sortBandRows(rows)
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Band > sorted[i-1].Band {
+			return fmt.Errorf("band must not increase as min_raw decreases (min_raw %d)", sorted[i].MinRaw)
+		}
+	}
+	return nil
+}
+
+// sortBandRows 按 MinRaw 从高到低排序
+func sortBandRows(rows []models.BandRow) []models.BandRow {
+	sorted := append([]models.BandRow(nil), rows...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].MinRaw > sorted[j].MinRaw })
+	return sorted
+}
+
+// RawToBand 根据换算表把答对题数转换为 Band 分
+func RawToBand(rows []models.BandRow, raw int) float64 {
+	for _, row := range sortBandRows(rows) {
+		if raw >= row.MinRaw {
+			return row.Band
+		}
+	}
+	return 0
+}
+
+// CalculateBand 根据科目和考试类型把答对题数转换为 Band 分
+func CalculateBand(skill, module string, raw int) (float64, error) {
+	table, err := GetBandTable(skill, module)
+	if err != nil {
+		return 0, err
+	}
+	return RawToBand(table.Conversion, raw), nil
+}
@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/Queen2333/ielts_test_backend/models"
+)
+
+func TestRawToBand(t *testing.T) {
+	listening := defaultBandTables[bandTableKey(models.SkillListening, "")]
+	generalReading := defaultBandTables[bandTableKey(models.SkillReading, models.ModuleGeneral)]
+
+	tests := []struct {
+		name string
+		rows []models.BandRow
+		raw  int
+		want float64
+	}{
+		{"listening full marks", listening, 40, 9.0},
+		{"listening boundary", listening, 39, 9.0},
+		{"listening below boundary", listening, 38, 8.5},
+		{"listening 30", listening, 30, 7.0},
+		{"listening 29", listening, 29, 6.5},
+		{"listening 1", listening, 1, 1.0},
+		{"listening 0", listening, 0, 0},
+		{"general reading 30", generalReading, 30, 6.0},
+		{"general reading 29", generalReading, 29, 5.5},
+		{"unsorted rows", []models.BandRow{{MinRaw: 0, Band: 0}, {MinRaw: 20, Band: 6}, {MinRaw: 10, Band: 4}}, 15, 4},
+		{"negative raw", listening, -1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RawToBand(tt.rows, tt.raw); got != tt.want {
+				t.Errorf("RawToBand(%d) = %v, want %v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateBandRows(t *testing.T) {
+	tests := []struct {
+		name    string
+		rows    []models.BandRow
+		wantErr bool
+	}{
+		{"default listening", defaultBandTables[bandTableKey(models.SkillListening, "")], false},
+		{"empty", nil, true},
+		{"missing zero row", []models.BandRow{{MinRaw: 10, Band: 5}}, true},
+		{"min_raw out of range", []models.BandRow{{MinRaw: 0, Band: 0}, {MinRaw: 41, Band: 9}}, true},
+		{"negative min_raw", []models.BandRow{{MinRaw: -1, Band: 0}, {MinRaw: 0, Band: 0}}, true},
+		{"band not half step", []models.BandRow{{MinRaw: 0, Band: 0}, {MinRaw: 20, Band: 6.3}}, true},
+		{"band above 9", []models.BandRow{{MinRaw: 0, Band: 0}, {MinRaw: 40, Band: 9.5}}, true},
+		{"duplicate min_raw", []models.BandRow{{MinRaw: 0, Band: 0}, {MinRaw: 20, Band: 6}, {MinRaw: 20, Band: 6.5}}, true},
+		{"band decreases as raw increases", []models.BandRow{{MinRaw: 0, Band: 0}, {MinRaw: 10, Band: 5}, {MinRaw: 20, Band: 4}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateBandRows(tt.rows); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateBandRows() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNormalizeModule(t *testing.T) {
+	tests := []struct {
+		module  string
+		want    string
+		wantErr bool
+	}{
+		{models.ModuleGeneral, models.ModuleGeneral, false},
+		{models.ModuleAcademic, models.ModuleAcademic, false},
+		{"", models.ModuleAcademic, false},
+		{"generl", "", true},
+		{"General", "", true},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeModule(tt.module)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NormalizeModule(%q) error = %v, wantErr %v", tt.module, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NormalizeModule(%q) = %q, want %q", tt.module, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,392 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Queen2333/ielts_test_backend/database"
+	"github.com/Queen2333/ielts_test_backend/jobs"
+	"github.com/Queen2333/ielts_test_backend/models"
+)
+
+const (
+	// DoubleMarkingTolerance 双评分数相差超过 1 分时需要仲裁
+	DoubleMarkingTolerance = 1.0
+	// CalibrationAgreementTolerance 与参考评分相差不超过半分视为一致
+	CalibrationAgreementTolerance = 0.5
+
+	// DefaultCalibrationInterval 老师每完成多少次评分自动分配一篇校准作文
+	DefaultCalibrationInterval = 20
+	// maxCalibrationInterval 校准间隔的上限
+	maxCalibrationInterval = 1000
+
+	doubleMarkingRateKey   = "grading:double_marking_rate"
+	calibrationIntervalKey = "grading:calibration_interval"
+)
+
+// GetDoubleMarkingRate 获取写作双评比例（百分比），未设置时为 0
+func GetDoubleMarkingRate() int {
+	if err := InitRedis(); err != nil {
+		return 0
+	}
+	value, err := Get(doubleMarkingRateKey)
+	if err != nil {
+		return 0
+	}
+	rate, _ := strconv.Atoi(value)
+	return rate
+}
+
+// SetDoubleMarkingRate 设置写作双评比例（0-100）
+func SetDoubleMarkingRate(rate int) error {
+	if rate < 0 || rate > 100 {
+		return fmt.Errorf("double marking rate must be between 0 and 100")
+	}
+	if err := InitRedis(); err != nil {
+		return err
+	}
+	return Set(doubleMarkingRateKey, rate, 0)
+}
+
+// ShouldDoubleMark 按双评比例随机抽取
+func ShouldDoubleMark() bool {
+	rate := GetDoubleMarkingRate()
+	return rate > 0 && rand.Intn(100) < rate
+}
+
+// GetCalibrationInterval 获取校准间隔（每完成多少次评分分配一篇校准作文），未设置时为 DefaultCalibrationInterval，0 表示不自动分配
+func GetCalibrationInterval() int {
+	if err := InitRedis(); err != nil {
+		return DefaultCalibrationInterval
+	}
+	value, err := Get(calibrationIntervalKey)
+	if err != nil {
+		return DefaultCalibrationInterval
+	}
+	interval, err := strconv.Atoi(value)
+	if err != nil {
+		return DefaultCalibrationInterval
+	}
+	return interval
+}
+
+// SetCalibrationInterval 设置校准间隔（0-1000，0 表示不自动分配）
+func SetCalibrationInterval(interval int) error {
+	if interval < 0 || interval > maxCalibrationInterval {
+		return fmt.Errorf("calibration interval must be between 0 and %d", maxCalibrationInterval)
+	}
+	if err := InitRedis(); err != nil {
+		return err
+	}
+	return Set(calibrationIntervalKey, interval, 0)
+}
+
+// ScheduleCalibration 老师完成评分后调用：每完成 GetCalibrationInterval 次评分，在后台给老师分配一篇校准作文
+// 失败只记录日志，不影响评分
+func ScheduleCalibration(graderID string) {
+	interval := GetCalibrationInterval()
+	if interval <= 0 {
+		return
+	}
+	var completed int
+	if err := database.GetDB().QueryRow(
+		"SELECT COUNT(*) FROM grading_queue WHERE grader_id = ? AND status = ?",
+		graderID, models.QueueCompleted,
+	).Scan(&completed); err != nil {
+		fmt.Println("统计老师评分次数失败:", graderID, err)
+		return
+	}
+	if completed == 0 || completed%interval != 0 {
+		return
+	}
+	if _, err := jobs.Enqueue(jobs.TypeCalibrationAssign, jobs.CalibrationAssignPayload{GraderID: graderID}); err != nil {
+		fmt.Println("校准作文分配加入队列失败:", graderID, err)
+	}
+}
+
+// AssignNextCalibration 随机给老师分配一篇没有做过的校准作文（盲评，老师看不到参考评分）
+// 老师还有未完成的校准任务或没有可分配的作文时不分配
+func AssignNextCalibration(graderID string) error {
+	pending, err := listCalibrationAssignments(map[string]interface{}{
+		"grader_id": graderID,
+		"status":    models.QueuePending,
+	})
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return nil
+	}
+
+	var essayID int
+	err = database.GetDB().QueryRow(
+		"SELECT id FROM calibration_essays WHERE id NOT IN (SELECT essay_id FROM calibration_assignments WHERE grader_id = ?) ORDER BY RAND() LIMIT 1",
+		graderID,
+	).Scan(&essayID)
+	if database.IsNoRowsError(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = AssignCalibration(essayID, []string{graderID})
+	return err
+}
+
+// ResolveWritingBand 根据各评分人的最终评分确定写作分数
+// 双评时两人相差不超过 1 分取平均，否则加入仲裁，以仲裁评分为准
+// done 为 false 表示仍在等待其他评分人
+func ResolveWritingBand(recordType string, recordID int, current models.WritingGradeItem) (float64, bool, error) {
+	items, err := GradingItems(recordType, recordID, models.SkillWriting)
+	if err != nil {
+		return 0, false, err
+	}
+	if len(items) == 0 {
+		return current.Band, true, nil
+	}
+
+	bands := make(map[int]float64)
+	for _, item := range items {
+		if item.Status != models.QueueCompleted {
+			return 0, false, nil
+		}
+		if item.GraderID == nil {
+			continue
+		}
+		grades, err := FindWritingGrades(recordType, recordID, *item.GraderID)
+		if err != nil {
+			return 0, false, err
+		}
+		if len(grades) > 0 && grades[0].Status == models.GradeFinal {
+			bands[item.MarkerNo] = grades[0].Band
+		}
+	}
+
+	if band, ok := bands[models.ResolutionMarker]; ok {
+		return band, true, nil
+	}
+	first, hasFirst := bands[models.FirstMarker]
+	second, hasSecond := bands[models.SecondMarker]
+	switch {
+	case hasFirst && hasSecond:
+		if math.Abs(first-second) > DoubleMarkingTolerance {
+			return 0, false, EnqueueResolution(recordType, recordID, models.SkillWriting)
+		}
+		return RoundOverallBand((first + second) / 2), true, nil
+	case hasFirst:
+		return first, true, nil
+	case hasSecond:
+		return second, true, nil
+	}
+	return current.Band, true, nil
+}
+
+// PrepareCalibrationEssay 校验参考评分并补充题目类型和参考分数
+func PrepareCalibrationEssay(essay *models.CalibrationEssayItem) []string {
+	var problems []string
+	if strings.TrimSpace(essay.Text) == "" {
+		problems = append(problems, "text is required")
+	}
+	part, err := database.GetDataById("writing_part_list", essay.PartID)
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("writing part %d not found", essay.PartID))
+	} else {
+		essay.TaskType = strings.TrimSpace(fmt.Sprint(part["task_type"]))
+	}
+	problems = append(problems, validateCriteriaScores(essay.Reference)...)
+	essay.ReferenceBand = TaskBand(essay.Reference)
+	return problems
+}
+
+// AssignCalibration 把校准作文分配给老师，graderIDs 为空时分配给所有老师；已分配过的老师跳过
+func AssignCalibration(essayID int, graderIDs []string) (int, error) {
+	if _, err := database.GetDataById("calibration_essays", essayID); err != nil {
+		return 0, fmt.Errorf("calibration essay %d not found", essayID)
+	}
+	if len(graderIDs) == 0 {
+		var err error
+		if graderIDs, err = ListGraders(); err != nil {
+			return 0, err
+		}
+	}
+
+	count := 0
+	for _, graderID := range graderIDs {
+		existing, _, err := database.PaginationQuery("calibration_assignments", 1, -1, map[string]interface{}{
+			"essay_id":  essayID,
+			"grader_id": graderID,
+		})
+		if err != nil {
+			return count, err
+		}
+		if len(existing) > 0 {
+			continue
+		}
+		assignment := models.CalibrationAssignmentItem{
+			EssayID:    essayID,
+			GraderID:   graderID,
+			Status:     models.QueuePending,
+			AssignedAt: time.Now().Format(timeLayout),
+		}
+		if _, err := database.InsertData("calibration_assignments", &assignment, "create"); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// listCalibrationAssignments 查询校准任务
+func listCalibrationAssignments(conditions map[string]interface{}) ([]models.CalibrationAssignmentItem, error) {
+	results, _, err := database.PaginationQuery("calibration_assignments", 1, -1, conditions)
+	if err != nil {
+		return nil, err
+	}
+	var assignments []models.CalibrationAssignmentItem
+	if err := DecodeJSONValue(results, &assignments); err != nil {
+		return nil, err
+	}
+	return assignments, nil
+}
+
+// PendingCalibration 老师待完成的校准任务，只返回作文和题目，不包含参考评分
+func PendingCalibration(graderID string) ([]map[string]interface{}, error) {
+	assignments, err := listCalibrationAssignments(map[string]interface{}{
+		"grader_id": graderID,
+		"status":    models.QueuePending,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	items := []map[string]interface{}{}
+	for _, assignment := range assignments {
+		essay, err := database.GetDataById("calibration_essays", assignment.EssayID)
+		if err != nil {
+			continue
+		}
+		item := map[string]interface{}{
+			"id":        assignment.ID,
+			"essay_id":  assignment.EssayID,
+			"task_type": essay["task_type"],
+			"text":      essay["text"],
+		}
+		if partID, ok := essay["part_id"].(int); ok {
+			if part, err := database.GetDataById("writing_part_list", partID); err == nil {
+				item["part"] = part
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// SubmitCalibration 提交校准评分，只能提交分配给自己且未完成的任务
+func SubmitCalibration(assignmentID int, graderID string, scores models.CriteriaScores) (float64, []string, error) {
+	if problems := validateCriteriaScores(scores); len(problems) > 0 {
+		return 0, problems, nil
+	}
+	band := TaskBand(scores)
+	scoresJSON, err := json.Marshal(scores)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	result, err := database.GetDB().Exec(
+		"UPDATE calibration_assignments SET scores = ?, band = ?, status = ?, completed_at = ? WHERE id = ? AND grader_id = ? AND status = ?",
+		string(scoresJSON), band, models.QueueCompleted, time.Now().Format(timeLayout), assignmentID, graderID, models.QueuePending,
+	)
+	if err != nil {
+		return 0, nil, err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return 0, nil, ErrGradingNotFound
+	}
+	return band, nil, nil
+}
+
+// CalibrationReport 统计每位老师与参考评分的偏差和一致率，graderID 为空时统计所有老师
+func CalibrationReport(graderID string) ([]models.GraderCalibrationReport, error) {
+	conditions := map[string]interface{}{"status": models.QueueCompleted}
+	if graderID != "" {
+		conditions["grader_id"] = graderID
+	}
+	assignments, err := listCalibrationAssignments(conditions)
+	if err != nil {
+		return nil, err
+	}
+
+	essays := make(map[int]models.CalibrationEssayItem)
+	deviations := make(map[string]map[string][]float64) // 老师 -> 标准 -> 偏差
+	for _, assignment := range assignments {
+		if assignment.Scores == nil || assignment.Band == nil {
+			continue
+		}
+		essay, ok := essays[assignment.EssayID]
+		if !ok {
+			record, err := database.GetDataById("calibration_essays", assignment.EssayID)
+			if err != nil {
+				continue
+			}
+			if err := DecodeJSONValue(record, &essay); err != nil {
+				return nil, err
+			}
+			essays[assignment.EssayID] = essay
+		}
+
+		if deviations[assignment.GraderID] == nil {
+			deviations[assignment.GraderID] = make(map[string][]float64)
+		}
+		d := deviations[assignment.GraderID]
+		d[models.CriterionTaskAchievement] = append(d[models.CriterionTaskAchievement], assignment.Scores.TaskAchievement-essay.Reference.TaskAchievement)
+		d[models.CriterionCoherenceCohesion] = append(d[models.CriterionCoherenceCohesion], assignment.Scores.CoherenceCohesion-essay.Reference.CoherenceCohesion)
+		d[models.CriterionLexicalResource] = append(d[models.CriterionLexicalResource], assignment.Scores.LexicalResource-essay.Reference.LexicalResource)
+		d[models.CriterionGrammaticalRange] = append(d[models.CriterionGrammaticalRange], assignment.Scores.GrammaticalRange-essay.Reference.GrammaticalRange)
+		d["band"] = append(d["band"], *assignment.Band-essay.ReferenceBand)
+	}
+
+	reports := []models.GraderCalibrationReport{}
+	for grader, byCriterion := range deviations {
+		report := models.GraderCalibrationReport{
+			GraderID:  grader,
+			Completed: len(byCriterion["band"]),
+			Criteria:  make(map[string]models.CriterionStats),
+			Band:      deviationStats(byCriterion["band"]),
+		}
+		for criterion := range writingCriteria {
+			report.Criteria[criterion] = deviationStats(byCriterion[criterion])
+		}
+		reports = append(reports, report)
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].GraderID < reports[j].GraderID })
+	return reports, nil
+}
+
+// deviationStats 计算平均偏差、平均绝对偏差和一致率（保留两位小数）
+func deviationStats(deviations []float64) models.CriterionStats {
+	if len(deviations) == 0 {
+		return models.CriterionStats{}
+	}
+	var sum, absSum float64
+	agreed := 0
+	for _, d := range deviations {
+		sum += d
+		absSum += math.Abs(d)
+		if math.Abs(d) <= CalibrationAgreementTolerance {
+			agreed++
+		}
+	}
+	n := float64(len(deviations))
+	round := func(v float64) float64 { return math.Round(v*100) / 100 }
+	return models.CriterionStats{
+		MeanDeviation:    round(sum / n),
+		MeanAbsDeviation: round(absSum / n),
+		AgreementRate:    round(float64(agreed) / n),
+	}
+}
@@ -0,0 +1,327 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Queen2333/ielts_test_backend/database"
+)
+
+// 套题组成规则
+const (
+	ListeningPartCount = 4  // 听力 4 个 part
+	ReadingPartCount   = 3  // 阅读 3 篇文章
+	WritingTaskCount   = 2  // 写作 Task 1 + Task 2
+	QuestionsPerSkill  = 40 // 听力、阅读各 40 题，题号 1-40
+)
+
+var questionRangeRegex = regexp.MustCompile(`^\s*(\d+)\s*(?:[-–~]\s*(\d+))?\s*$`)
+
+// ParseQuestionNo 解析题号，支持 "5" 和 "21-22" 两种形式
+func ParseQuestionNo(no string) (int, int, bool) {
+	matches := questionRangeRegex.FindStringSubmatch(no)
+	if matches == nil {
+		return 0, 0, false
+	}
+	start, _ := strconv.Atoi(matches[1])
+	end := start
+	if matches[2] != "" {
+		end, _ = strconv.Atoi(matches[2])
+	}
+	if start <= 0 || end < start {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// FormatQuestionNo 根据起止题号生成题号字符串
+func FormatQuestionNo(start, end int) string {
+	if end > start {
+		return fmt.Sprintf("%d-%d", start, end)
+	}
+	return strconv.Itoa(start)
+}
+
+// questionSpan 计算一道题占用的题号数量
+// 题号为区间时按区间计算；多选题按正确答案个数计算（与计分规则一致）
+func questionSpan(questionType string, question map[string]interface{}) int {
+	span := 1
+	if no, ok := question["no"].(string); ok {
+		if start, end, ok := ParseQuestionNo(no); ok {
+			span = end - start + 1
+		}
+	}
+	if span == 1 && questionType == "multi_choice" {
+		if answers, ok := question["answer"].([]interface{}); ok && len(answers) > 1 {
+			span = len(answers)
+		}
+	}
+	if count, ok := question["answer_count"].(float64); ok && int(count) > span {
+		span = int(count)
+	}
+	return span
+}
+
+// walkQuestions 按 part -> type_list -> question_list 的顺序遍历题目
+func walkQuestions(parts []map[string]interface{}, fn func(partIndex int, questionType string, question map[string]interface{})) {
+	for i, part := range parts {
+		typeList, ok := part["type_list"].([]interface{})
+		if !ok {
+			continue
+		}
+		for _, typeItemInterface := range typeList {
+			typeItem, ok := typeItemInterface.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			questionType, _ := typeItem["type"].(string)
+			questionList, ok := typeItem["question_list"].([]interface{})
+			if !ok {
+				continue
+			}
+			for _, questionInterface := range questionList {
+				question, ok := questionInterface.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				fn(i, questionType, question)
+			}
+		}
+	}
+}
+
+// partTypeUser 用户自建 part 的 type，只有创建者可以引用和查看
+const partTypeUser = "3"
+
+// PartVisibleTo 判断 part 对 userID 是否可见：官方 part 所有人可见，用户自建 part 只有创建者可见
+// userID 为空表示官方套题，不能引用任何用户的 part
+func PartVisibleTo(part map[string]interface{}, userID string) bool {
+	if part["type"] == nil || strings.TrimSpace(fmt.Sprint(part["type"])) != partTypeUser {
+		return true
+	}
+	ownerID, _ := part["user_id"].(string)
+	return userID != "" && ownerID == userID
+}
+
+// LoadVisibleParts 同 LoadParts，但其他用户的 part 按不存在处理，避免通过套题读取
+func LoadVisibleParts(tableName string, ids []int, userID string) ([]map[string]interface{}, []int, error) {
+	var parts []map[string]interface{}
+	var missing []int
+	for _, id := range ids {
+		partDetail, err := database.GetPartsByIds(tableName, []int{id})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to query %s: %w", tableName, err)
+		}
+		if len(partDetail) == 0 || !PartVisibleTo(partDetail[0], userID) {
+			missing = append(missing, id)
+			continue
+		}
+		parts = append(parts, partDetail[0])
+	}
+	return parts, missing, nil
+}
+
+// LoadParts 按顺序查询 part 详情，并返回不存在的 part ID
+func LoadParts(tableName string, ids []int) ([]map[string]interface{}, []int, error) {
+	var parts []map[string]interface{}
+	var missing []int
+	for _, id := range ids {
+		partDetail, err := database.GetPartsByIds(tableName, []int{id})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to query %s: %w", tableName, err)
+		}
+		if len(partDetail) == 0 {
+			missing = append(missing, id)
+			continue
+		}
+		parts = append(parts, partDetail[0])
+	}
+	return parts, missing, nil
+}
+
+// ValidateQuestionNumbering 校验题号是否按顺序覆盖 1-40
+func ValidateQuestionNumbering(skill string, parts []map[string]interface{}) []string {
+	var problems []string
+	seen := make(map[int]bool)
+	expected := 1
+
+	walkQuestions(parts, func(partIndex int, questionType string, question map[string]interface{}) {
+		no, _ := question["no"].(string)
+		start, end, ok := ParseQuestionNo(no)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s part %d: invalid question number %q", skill, partIndex+1, no))
+			return
+		}
+		if span := questionSpan(questionType, question); end-start+1 != span {
+			end = start + span - 1
+		}
+		for n := start; n <= end; n++ {
+			if seen[n] {
+				problems = append(problems, fmt.Sprintf("%s part %d: duplicate question number %d", skill, partIndex+1, n))
+			}
+			seen[n] = true
+		}
+		if start != expected {
+			problems = append(problems, fmt.Sprintf("%s part %d: question %q is out of order, expected %d", skill, partIndex+1, no, expected))
+		}
+		expected = end + 1
+	})
+
+	for n := 1; n <= QuestionsPerSkill; n++ {
+		if !seen[n] {
+			problems = append(problems, fmt.Sprintf("%s: missing question number %d", skill, n))
+		}
+	}
+	// 按题号顺序报告，保证错误信息的顺序稳定
+	var extra []int
+	for n := range seen {
+		if n > QuestionsPerSkill {
+			extra = append(extra, n)
+		}
+	}
+	sort.Ints(extra)
+	for _, n := range extra {
+		problems = append(problems, fmt.Sprintf("%s: question number %d exceeds %d", skill, n, QuestionsPerSkill))
+	}
+	return problems
+}
+
+// validatePartCount 校验 part 数量以及是否存在
+func validatePartCount(skill string, ids []int, missing []int, expected int) []string {
+	var problems []string
+	if len(ids) != expected {
+		problems = append(problems, fmt.Sprintf("%s: expected %d parts, got %d", skill, expected, len(ids)))
+	}
+	for _, id := range missing {
+		problems = append(problems, fmt.Sprintf("%s: part %d not found", skill, id))
+	}
+	return problems
+}
+
+// ValidatePartReferences 只校验引用的 part 存在且可被 ownerID 引用，不校验套题组成
+func ValidatePartReferences(skill, tableName string, ids []int, ownerID string) ([]string, error) {
+	_, missing, err := LoadVisibleParts(tableName, ids, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	var problems []string
+	for _, id := range missing {
+		problems = append(problems, fmt.Sprintf("%s: part %d not found", skill, id))
+	}
+	return problems, nil
+}
+
+// ValidateListeningComposition 校验听力套题：4 个 part，题号 1-40
+func ValidateListeningComposition(ids []int, ownerID string) ([]string, error) {
+	parts, missing, err := LoadVisibleParts("listening_part_list", ids, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	problems := validatePartCount("listening", ids, missing, ListeningPartCount)
+	return append(problems, ValidateQuestionNumbering("listening", parts)...), nil
+}
+
+// ValidateReadingComposition 校验阅读套题：3 篇文章，题号 1-40
+func ValidateReadingComposition(ids []int, ownerID string) ([]string, error) {
+	parts, missing, err := LoadVisibleParts("reading_part_list", ids, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	problems := validatePartCount("reading", ids, missing, ReadingPartCount)
+	return append(problems, ValidateQuestionNumbering("reading", parts)...), nil
+}
+
+// ValidateWritingComposition 校验写作：Task 1 和 Task 2 各一篇
+func ValidateWritingComposition(ids []int, ownerID string) ([]string, error) {
+	parts, missing, err := LoadVisibleParts("writing_part_list", ids, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	problems := validatePartCount("writing", ids, missing, WritingTaskCount)
+
+	taskCount := make(map[string]int)
+	for _, part := range parts {
+		taskType := strings.TrimSpace(fmt.Sprint(part["task_type"]))
+		taskCount[taskType]++
+	}
+	if len(parts) == WritingTaskCount && (taskCount["1"] != 1 || taskCount["2"] != 1) {
+		problems = append(problems, "writing: expected one Task 1 and one Task 2")
+	}
+	return problems, nil
+}
+
+// ValidateTestingComposition 校验完整套题的组成，口语为可选科目
+// ownerID 为套题创建者，官方套题为空，此时不能引用任何用户自建的 part
+func ValidateTestingComposition(listeningIDs, readingIDs, writingIDs, speakingIDs []int, ownerID string) ([]string, error) {
+	var problems []string
+	validators := []struct {
+		ids      []int
+		validate func([]int, string) ([]string, error)
+	}{
+		{listeningIDs, ValidateListeningComposition},
+		{readingIDs, ValidateReadingComposition},
+		{writingIDs, ValidateWritingComposition},
+	}
+	for _, v := range validators {
+		result, err := v.validate(v.ids, ownerID)
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, result...)
+	}
+	if len(speakingIDs) > 0 {
+		result, err := ValidateSpeakingComposition(speakingIDs, ownerID)
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, result...)
+	}
+	return problems, nil
+}
+
+// RenumberQuestions 按 part 顺序重写题号，使其从 1 开始连续编号
+// 返回被修改过的 part 下标
+func RenumberQuestions(parts []map[string]interface{}) []int {
+	changed := make(map[int]bool)
+	next := 1
+	walkQuestions(parts, func(partIndex int, questionType string, question map[string]interface{}) {
+		span := questionSpan(questionType, question)
+		no := FormatQuestionNo(next, next+span-1)
+		if question["no"] != no {
+			question["no"] = no
+			changed[partIndex] = true
+		}
+		next += span
+	})
+
+	var indexes []int
+	for i := range parts {
+		if changed[i] {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// RenumberParts 重新编号并把修改后的 type_list 写回数据库
+func RenumberParts(tableName string, ids []int) (int, error) {
+	parts, missing, err := LoadParts(tableName, ids)
+	if err != nil {
+		return 0, err
+	}
+	if len(missing) > 0 {
+		return 0, fmt.Errorf("parts not found: %v", missing)
+	}
+
+	changed := RenumberQuestions(parts)
+	for _, i := range changed {
+		id, _ := parts[i]["id"].(int)
+		if err := database.UpdateColumns(tableName, id, map[string]interface{}{"type_list": parts[i]["type_list"]}); err != nil {
+			return 0, err
+		}
+	}
+	return len(changed), nil
+}
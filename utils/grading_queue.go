@@ -0,0 +1,494 @@
+package utils
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Queen2333/ielts_test_backend/auth"
+	"github.com/Queen2333/ielts_test_backend/database"
+	"github.com/Queen2333/ielts_test_backend/jobs"
+	"github.com/Queen2333/ielts_test_backend/mailer"
+	"github.com/Queen2333/ielts_test_backend/models"
+)
+
+// GradingSLA 提交后需在该时间内完成评分
+const GradingSLA = 48 * time.Hour
+
+// GradingReminderLead 截止前多久提醒老师
+const GradingReminderLead = 6 * time.Hour
+
+const timeLayout = "2006-01-02 15:04:05"
+
+var (
+	ErrGradingTaken     = errors.New("grading item is assigned to another grader")
+	ErrGradingNotFound  = errors.New("grading item not found")
+	ErrGradingDuplicate = errors.New("grader already marks this record")
+)
+
+// EnqueueGrading 把需要老师评分的记录加入队列，已有未完成的队列项时不重复加入
+// 写作按双评比例抽取部分记录，额外加入第二评分人
+func EnqueueGrading(recordType string, recordID int, skill, userID string) error {
+	open, err := OpenGradingItems(recordType, recordID, skill)
+	if err != nil || len(open) > 0 {
+		return err
+	}
+	markers := []int{models.FirstMarker}
+	if skill == models.SkillWriting && ShouldDoubleMark() {
+		markers = append(markers, models.SecondMarker)
+	}
+	now := time.Now()
+	for _, marker := range markers {
+		if err := insertGradingItem(recordType, recordID, skill, userID, marker, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insertGradingItem(recordType string, recordID int, skill, userID string, marker int, submittedAt time.Time) error {
+	item := models.GradingQueueItem{
+		RecordType:  recordType,
+		RecordID:    recordID,
+		Skill:       skill,
+		MarkerNo:    marker,
+		UserID:      userID,
+		Status:      models.QueuePending,
+		SubmittedAt: submittedAt.Format(timeLayout),
+		DueAt:       time.Now().Add(GradingSLA).Format(timeLayout),
+	}
+	_, err := database.InsertData("grading_queue", &item, "create")
+	return err
+}
+
+// gradingQueueColumns 评分队列查询的列，顺序与 queryGradingQueue 中的 Scan 一致
+const gradingQueueColumns = "id, record_type, record_id, skill, marker_no, user_id, grader_id, status, submitted_at, assigned_at, due_at, completed_at"
+
+// queryGradingQueue 按条件（列名 = 值）查询评分队列
+// 直接按类型扫描每一列：没有条件时 MySQL 使用文本协议，数字列会以字符串返回，不能通过 map 解析
+func queryGradingQueue(conditions map[string]interface{}) ([]models.GradingQueueItem, error) {
+	keys := make([]string, 0, len(conditions))
+	for key := range conditions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	query := "SELECT " + gradingQueueColumns + " FROM grading_queue WHERE 1 = 1"
+	var args []interface{}
+	for _, key := range keys {
+		query += fmt.Sprintf(" AND %s = ?", key)
+		args = append(args, conditions[key])
+	}
+
+	rows, err := database.GetDB().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.GradingQueueItem{}
+	for rows.Next() {
+		var item models.GradingQueueItem
+		var graderID, assignedAt, completedAt sql.NullString
+		if err := rows.Scan(&item.ID, &item.RecordType, &item.RecordID, &item.Skill, &item.MarkerNo, &item.UserID,
+			&graderID, &item.Status, &item.SubmittedAt, &assignedAt, &item.DueAt, &completedAt); err != nil {
+			return nil, err
+		}
+		item.GraderID = nullStringPtr(graderID)
+		item.AssignedAt = nullStringPtr(assignedAt)
+		item.CompletedAt = nullStringPtr(completedAt)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func nullStringPtr(value sql.NullString) *string {
+	if !value.Valid {
+		return nil
+	}
+	return &value.String
+}
+
+// GradingItems 查询记录某个科目最近一次提交的所有队列项
+func GradingItems(recordType string, recordID int, skill string) ([]models.GradingQueueItem, error) {
+	items, err := queryGradingQueue(map[string]interface{}{
+		"record_type": recordType,
+		"record_id":   recordID,
+		"skill":       skill,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 重新提交会产生新一轮队列项，只保留提交时间最晚的一轮
+	latest := ""
+	for _, item := range items {
+		if item.SubmittedAt > latest {
+			latest = item.SubmittedAt
+		}
+	}
+	var current []models.GradingQueueItem
+	for _, item := range items {
+		if item.SubmittedAt == latest {
+			current = append(current, item)
+		}
+	}
+	sort.Slice(current, func(i, j int) bool { return current[i].MarkerNo < current[j].MarkerNo })
+	return current, nil
+}
+
+// OpenGradingItems 查询记录某个科目未完成的队列项
+func OpenGradingItems(recordType string, recordID int, skill string) ([]models.GradingQueueItem, error) {
+	items, err := GradingItems(recordType, recordID, skill)
+	if err != nil {
+		return nil, err
+	}
+	var open []models.GradingQueueItem
+	for _, item := range items {
+		if item.Status != models.QueueCompleted {
+			open = append(open, item)
+		}
+	}
+	return open, nil
+}
+
+// ListGradingQueue 查询评分队列，按提交时间排序并计算等待时长
+func ListGradingQueue(conditions map[string]interface{}, overdueOnly bool) ([]models.GradingQueueView, error) {
+	items, err := queryGradingQueue(conditions)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	views := []models.GradingQueueView{}
+	for _, item := range items {
+		view := models.GradingQueueView{GradingQueueItem: item}
+		if submittedAt, err := time.ParseInLocation(timeLayout, item.SubmittedAt, time.Local); err == nil {
+			view.AgeHours = float64(int(now.Sub(submittedAt).Hours()*10)) / 10
+		}
+		if dueAt, err := time.ParseInLocation(timeLayout, item.DueAt, time.Local); err == nil {
+			view.Overdue = item.Status != models.QueueCompleted && now.After(dueAt)
+		}
+		if overdueOnly && !view.Overdue {
+			continue
+		}
+		views = append(views, view)
+	}
+	sort.Slice(views, func(i, j int) bool { return views[i].SubmittedAt < views[j].SubmittedAt })
+	return views, nil
+}
+
+// checkSecondMarker 双评时同一位老师不能担任同一记录的多个评分人
+func checkSecondMarker(id int, graderID string) error {
+	record, err := database.GetDataById("grading_queue", id)
+	if err != nil {
+		return ErrGradingNotFound
+	}
+	var item models.GradingQueueItem
+	if err := DecodeJSONValue(record, &item); err != nil {
+		return err
+	}
+	items, err := GradingItems(item.RecordType, item.RecordID, item.Skill)
+	if err != nil {
+		return err
+	}
+	for _, sibling := range items {
+		if sibling.ID != id && sibling.GraderID != nil && *sibling.GraderID == graderID {
+			return ErrGradingDuplicate
+		}
+	}
+	return nil
+}
+
+// ClaimGradingItem 老师领取待分配的队列项；通过条件更新保证同一项只能被一位老师领取
+func ClaimGradingItem(id int, graderID string) error {
+	if err := checkSecondMarker(id, graderID); err != nil {
+		return err
+	}
+	result, err := database.GetDB().Exec(
+		"UPDATE grading_queue SET grader_id = ?, status = ?, assigned_at = ? WHERE id = ? AND status = ?",
+		graderID, models.QueueAssigned, time.Now().Format(timeLayout), id, models.QueuePending,
+	)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrGradingTaken
+	}
+	scheduleGradingReminder(id, graderID)
+	return nil
+}
+
+// AssignGradingItem 管理员分配或重新分配队列项，已完成的项不能再分配
+func AssignGradingItem(id int, graderID string) error {
+	if err := checkSecondMarker(id, graderID); err != nil {
+		return err
+	}
+	result, err := database.GetDB().Exec(
+		"UPDATE grading_queue SET grader_id = ?, status = ?, assigned_at = ? WHERE id = ? AND status <> ?",
+		graderID, models.QueueAssigned, time.Now().Format(timeLayout), id, models.QueueCompleted,
+	)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrGradingNotFound
+	}
+	scheduleGradingReminder(id, graderID)
+	return nil
+}
+
+// ListGraders 获取所有老师的 ID
+func ListGraders() ([]string, error) {
+	rows, err := database.GetDB().Query("SELECT id FROM user_list WHERE role_id = ?", models.RoleTeacher)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var graders []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		graders = append(graders, id)
+	}
+	return graders, rows.Err()
+}
+
+// IsGrader 判断用户是否为老师
+func IsGrader(userID string) (bool, error) {
+	var roleID int
+	err := database.GetDB().QueryRow("SELECT role_id FROM user_list WHERE id = ?", userID).Scan(&roleID)
+	if database.IsNoRowsError(err) {
+		return false, nil
+	}
+	return roleID == models.RoleTeacher, err
+}
+
+// AutoAssignGrading 按提交时间把待分配的队列项分给当前待评数量最少的老师，返回分配数量
+func AutoAssignGrading() (int, error) {
+	graders, err := ListGraders()
+	if err != nil {
+		return 0, err
+	}
+	if len(graders) == 0 {
+		return 0, fmt.Errorf("no graders available")
+	}
+
+	assigned, err := ListGradingQueue(map[string]interface{}{"status": models.QueueAssigned}, false)
+	if err != nil {
+		return 0, err
+	}
+	load := make(map[string]int)
+	for _, item := range assigned {
+		if item.GraderID != nil {
+			load[*item.GraderID]++
+		}
+	}
+
+	pending, err := ListGradingQueue(map[string]interface{}{"status": models.QueuePending}, false)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, item := range pending {
+		// 按当前待评数量从少到多尝试
+		candidates := append([]string(nil), graders...)
+		sort.SliceStable(candidates, func(i, j int) bool { return load[candidates[i]] < load[candidates[j]] })
+		for _, grader := range candidates {
+			// 期间可能已被老师领取，或该老师已是同一记录的另一位评分人
+			err := ClaimGradingItem(item.ID, grader)
+			if errors.Is(err, ErrGradingDuplicate) {
+				continue
+			}
+			if errors.Is(err, ErrGradingTaken) {
+				break
+			}
+			if err != nil {
+				return count, err
+			}
+			load[grader]++
+			count++
+			break
+		}
+	}
+	return count, nil
+}
+
+// ClaimForGrading 评分前检查队列项：已分配给当前老师时直接评分，否则领取一个待分配的项
+// 双评时同一位老师不能同时担任两个评分人；管理员可以直接评分
+func ClaimForGrading(recordType string, recordID int, skill string, user models.UserQuery) error {
+	items, err := GradingItems(recordType, recordID, skill)
+	if err != nil {
+		return err
+	}
+
+	var pending *models.GradingQueueItem
+	for i, item := range items {
+		if item.Status == models.QueueCompleted {
+			if item.GraderID != nil && *item.GraderID == user.ID && item.MarkerNo != models.ResolutionMarker {
+				// 已完成的评分人重新提交评分
+				return nil
+			}
+			continue
+		}
+		if item.GraderID != nil && *item.GraderID == user.ID {
+			return nil
+		}
+		if item.Status == models.QueuePending && pending == nil {
+			pending = &items[i]
+		}
+	}
+	if pending != nil {
+		return ClaimGradingItem(pending.ID, user.ID)
+	}
+	if len(items) == 0 || auth.Can(user.RoleID, auth.PermGradingManage) {
+		return nil
+	}
+	return ErrGradingTaken
+}
+
+// CompleteGrading 标记当前老师的队列项评分完成（管理员直接评分时完成第一个未完成的项）
+// 返回该记录剩余未完成的队列项数量
+func CompleteGrading(recordType string, recordID int, skill string, user models.UserQuery) (int, error) {
+	open, err := OpenGradingItems(recordType, recordID, skill)
+	if err != nil || len(open) == 0 {
+		return 0, err
+	}
+
+	target := -1
+	for i, item := range open {
+		if item.GraderID != nil && *item.GraderID == user.ID {
+			target = i
+			break
+		}
+	}
+	if target == -1 {
+		if !auth.Can(user.RoleID, auth.PermGradingManage) {
+			return len(open), nil
+		}
+		target = 0
+	}
+
+	now := time.Now().Format(timeLayout)
+	fields := map[string]interface{}{"status": models.QueueCompleted, "completed_at": now}
+	if open[target].GraderID == nil {
+		fields["grader_id"] = user.ID
+		fields["assigned_at"] = now
+	}
+	if err := database.UpdateColumns("grading_queue", open[target].ID, fields); err != nil {
+		return 0, err
+	}
+	ScheduleCalibration(user.ID)
+	return len(open) - 1, nil
+}
+
+// EnqueueResolution 双评分歧过大时加入仲裁评分
+func EnqueueResolution(recordType string, recordID int, skill string) error {
+	items, err := GradingItems(recordType, recordID, skill)
+	if err != nil || len(items) == 0 {
+		return err
+	}
+	for _, item := range items {
+		if item.MarkerNo == models.ResolutionMarker {
+			return nil
+		}
+	}
+	submittedAt, err := time.ParseInLocation(timeLayout, items[0].SubmittedAt, time.Local)
+	if err != nil {
+		return err
+	}
+	return insertGradingItem(recordType, recordID, skill, items[0].UserID, models.ResolutionMarker, submittedAt)
+}
+
+// NotifyGradingFinished 发送评分完成邮件，失败只记录日志
+func NotifyGradingFinished(recordType string, recordID int, skill string, band float64) {
+	items, err := GradingItems(recordType, recordID, skill)
+	if err != nil || len(items) == 0 {
+		return
+	}
+
+	email, locale, err := userContact(items[0].UserID)
+	if err != nil {
+		fmt.Println("评分完成通知失败，找不到用户:", items[0].UserID, err)
+		return
+	}
+	if _, err := mailer.Send(email, mailer.TemplateGradingFinished, locale, map[string]interface{}{
+		"Skill": skill,
+		"Band":  band,
+	}); err != nil {
+		fmt.Println("评分完成通知发送失败:", err)
+	}
+}
+
+// scheduleGradingReminder 在截止时间前 GradingReminderLead 提醒老师，已临近截止时立即提醒
+func scheduleGradingReminder(id int, graderID string) {
+	row, err := database.GetDataById("grading_queue", id)
+	if err != nil {
+		return
+	}
+	dueAt, err := time.ParseInLocation(timeLayout, fmt.Sprint(row["due_at"]), time.Local)
+	if err != nil {
+		return
+	}
+	if _, err := jobs.Enqueue(jobs.TypeGradingReminder, jobs.GradingReminderPayload{QueueID: id, GraderID: graderID},
+		jobs.WithRunAt(dueAt.Add(-GradingReminderLead))); err != nil {
+		fmt.Println("评分到期提醒加入队列失败:", id, err)
+	}
+}
+
+// SendGradingReminder 队列项仍分配给该老师且未完成时发送到期提醒
+func SendGradingReminder(id int, graderID string) error {
+	row, err := database.GetDataById("grading_queue", id)
+	if err != nil {
+		return nil
+	}
+	var item models.GradingQueueItem
+	if err := DecodeJSONValue(row, &item); err != nil {
+		return err
+	}
+	if item.Status != models.QueueAssigned || item.GraderID == nil || *item.GraderID != graderID {
+		return nil
+	}
+
+	email, locale, err := userContact(graderID)
+	if err != nil {
+		return err
+	}
+	_, err = mailer.Send(email, mailer.TemplateAssignmentDue, locale, map[string]interface{}{
+		"Skill":   item.Skill,
+		"QueueID": item.ID,
+		"DueAt":   item.DueAt,
+	})
+	return err
+}
+
+// userContact 获取用户邮箱和邮件语言，没有记录语言时为空（使用默认语言）
+func userContact(userID string) (string, string, error) {
+	var email, locale string
+	err := database.GetDB().QueryRow("SELECT email, COALESCE(locale, '') FROM user_list WHERE id = ?", userID).Scan(&email, &locale)
+	return email, locale, err
+}
+
+// SaveUserLocale 根据 Accept-Language 记录用户的邮件语言，没有该请求头时不修改
+func SaveUserLocale(userID, acceptLanguage string) {
+	if strings.TrimSpace(acceptLanguage) == "" {
+		return
+	}
+	locale := mailer.ResolveLocale(acceptLanguage)
+	if _, err := database.GetDB().Exec("UPDATE user_list SET locale = ? WHERE id = ?", locale, userID); err != nil {
+		fmt.Println("保存用户语言失败:", userID, err)
+	}
+}
+
+var skillNames = map[string]string{
+	models.SkillListening: "听力",
+	models.SkillReading:   "阅读",
+	models.SkillWriting:   "写作",
+	models.SkillSpeaking:  "口语",
+}
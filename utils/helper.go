@@ -2,7 +2,6 @@ package utils
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"math/rand"
 	"net/http"
@@ -11,9 +10,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Queen2333/ielts_test_backend/auth"
 	"github.com/Queen2333/ielts_test_backend/database"
 	"github.com/Queen2333/ielts_test_backend/models"
-	"github.com/davecgh/go-spew/spew"
 	"github.com/gin-gonic/gin"
 )
 
@@ -68,6 +67,34 @@ func StringToList(str string) []int {
     return intValues
 }
 
+// InterfaceToIntList 将数据库中解析出的 []interface{} 形式的 ID 列表转换为整数数组
+func InterfaceToIntList(list interface{}) []int {
+	items, ok := list.([]interface{})
+	if !ok {
+		return nil
+	}
+	var strValues []string
+	for _, item := range items {
+		strValues = append(strValues, fmt.Sprint(item))
+	}
+	if len(strValues) == 0 {
+		return []int{}
+	}
+	return StringToList(strings.Join(strValues, ","))
+}
+
+// DecodeJSONValue 把数据库查询结果中已解析的 JSON 字段（map / slice）转换为结构体，nil 时不做处理
+func DecodeJSONValue(value interface{}, out interface{}) error {
+	if value == nil {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
 func ProcessRequest(c *gin.Context) (map[string]interface{}, error) {
 
 	var request struct {
@@ -88,17 +115,20 @@ func ProcessRequest(c *gin.Context) (map[string]interface{}, error) {
 	if request.Status != nil {
 		conditions["status"] = *request.Status
 	}
+	userID, err := GetUserIDFromToken(c)
+	if err != nil {
+		// 处理获取 user_id 失败的情况
+		HandleResponse(c, http.StatusUnauthorized, "", err.Error())
+		return nil, err
+	}
 	if request.Type != nil {
 		conditions["type"] = *request.Type
-		if *request.Type == 3 {
-			userID, err := GetUserIDFromToken(c)
-			if err != nil {
-				// 处理获取 user_id 失败的情况
-				HandleResponse(c, http.StatusUnauthorized, "", err.Error())
-				return nil, err
-			}
-			conditions["user_id"] = userID
-		}
+	}
+	if request.Type != nil && *request.Type == 3 {
+		conditions["user_id"] = userID
+	} else {
+		// 其他用户的题目（type 3）不可见
+		conditions[database.VisibleToCondition] = userID
 	}
 	if request.Name != "" {
 		conditions["name"] = "%" + request.Name + "%"
@@ -106,49 +136,30 @@ func ProcessRequest(c *gin.Context) (map[string]interface{}, error) {
 
 	return conditions, nil
 }
-func GetUserIDFromToken(c *gin.Context) (string, error) {
-	// 获取 Authorization 头中的 token
-	authHeader := c.GetHeader("Authorization")
-	token := strings.TrimPrefix(authHeader, "Bearer ")
-
-	// 检查 token 是否为空
-	if token == "" {
-		return "", errors.New("missing token in Authorization header")
-	}
-
-	// 初始化 Redis 并获取 user 信息
-	err := InitRedis()
-	if err != nil {
-		return "", fmt.Errorf("failed to initialize Redis: %w", err)
-	}
 
-	val, err := Get(token)
+// GetUserIDFromToken 获取当前登录用户的 ID（由 JWT 中间件写入 gin.Context）
+func GetUserIDFromToken(c *gin.Context) (string, error) {
+	userInfo, err := GetUserFromToken(c)
 	if err != nil {
-		return "", errors.New("failed to retrieve user from Redis")
-	}
-
-	// 检查 Redis 中是否有对应的用户数据
-	if val == "" {
-		return "", errors.New("no user data found for the provided token")
+		return "", err
 	}
+	return userInfo.ID, nil
+}
 
-	// 解析 user 信息
-	var userInfo models.UserQuery
-	err = json.Unmarshal([]byte(val), &userInfo)
+// GetUserFromToken 获取当前登录用户（由 JWT 中间件校验 token 后写入 gin.Context，不再读取 Redis）
+func GetUserFromToken(c *gin.Context) (models.UserQuery, error) {
+	principal, err := auth.PrincipalFrom(c)
 	if err != nil {
-		return "", errors.New("failed to parse user data from Redis")
+		return models.UserQuery{}, err
 	}
-
-	// 检查解析后的 user 信息
-	if userInfo.ID == "" {
-		return "", errors.New("invalid user data: missing user ID")
-	}
-
-	// 返回 user_id
-	return userInfo.ID, nil
+	return models.UserQuery{ID: principal.ID, Email: principal.Email, RoleID: principal.RoleID}, nil
 }
 
 func ProcessPartList(c *gin.Context, results []map[string]interface{}, name string) ([]map[string]interface{}, error) {
+	userID, err := GetUserIDFromToken(c)
+	if err != nil {
+		return nil, err
+	}
 	for i, result := range results {
 		// 处理 []interface{} 类型的 part_list
 		partListInterface, ok := result["part_list"].([]interface{})
@@ -157,7 +168,7 @@ func ProcessPartList(c *gin.Context, results []map[string]interface{}, name stri
 		}
 
 		// 调用 GetPartDetails 获取详细信息
-		details, err := GetPartDetails(partListInterface, name)
+		details, err := GetPartDetails(partListInterface, name, userID)
 		if err != nil {
 			return nil, err
 		}
@@ -168,7 +179,8 @@ func ProcessPartList(c *gin.Context, results []map[string]interface{}, name stri
 	return results, nil
 }
 
-func GetPartDetails(partListInterface []interface{}, name string) ([]map[string]interface{}, error) {
+// GetPartDetails 按 part_list 查询 part 详情，其他用户的 part（type 3）不返回
+func GetPartDetails(partListInterface []interface{}, name string, userID string) ([]map[string]interface{}, error) {
 	// 转换为字符串数组
 	var partListStrArray []string
 	for _, part := range partListInterface {
@@ -185,7 +197,7 @@ func GetPartDetails(partListInterface []interface{}, name string) ([]map[string]
 		if err != nil {
 			return nil, fmt.Errorf("failed to query listening parts: %w", err)
 		}
-		if len(partDetail) > 0 {
+		if len(partDetail) > 0 && PartVisibleTo(partDetail[0], userID) {
 			details = append(details, partDetail[0])
 		}
 	}
@@ -198,21 +210,27 @@ type Answer struct {
 	Answer interface{} `json:"answer"`
 }
 
-func CalculateScore(partList []map[string]interface{}, submittedAnswers []models.AnswerItem) float64 {
-	score := 0
+// CalculateRawScore 计算答对题数（原始分），Band 分通过 CalculateBand 换算
+func CalculateRawScore(partList []map[string]interface{}, submittedAnswers []models.AnswerItem) int {
+	return GradeParts(partList, submittedAnswers).RawScore
+}
+
+// GradeParts 逐题评分，返回原始分和每道题的作答详情（未作答的题也会列出）
+func GradeParts(partList []map[string]interface{}, submittedAnswers []models.AnswerItem) models.ScoreResult {
+	result := models.ScoreResult{Questions: []models.QuestionResult{}}
 	// 创建一个映射用于快速查找用户答案
-	answerMap := make(map[string]interface{})
+	answerMap := make(map[string]models.AnswerValue)
 	for _, ans := range submittedAnswers {
 		answerMap[ans.No] = ans.Answer
 	}
-	// spew.Dump(answerMap, partList, "answerMap")
-	for _, part := range partList {
-		// spew.Dump(part, "part2")
+	for partIndex, part := range partList {
 		typeList, ok := part["type_list"].([]interface{})
 		if !ok {
 			fmt.Printf("Skipping part due to type_list not being a valid type: %v (type: %T)\n", part["type_list"], part["type_list"])
 			continue
 		}
+		partID, _ := part["id"].(int)
+		partName, _ := part["name"].(string)
 
 		for _, typeItemInterface := range typeList {
 			typeItem, ok := typeItemInterface.(map[string]interface{}) // Type assertion
@@ -221,9 +239,14 @@ func CalculateScore(partList []map[string]interface{}, submittedAnswers []models
 				continue
 			}
 			questionType, _ := typeItem["type"].(string)
+			// 题组说明中的字数限制，如 "NO MORE THAN TWO WORDS"
+			instructions, _ := typeItem["title"].(string)
+			wordLimit, hasWordLimit := ParseWordLimit(instructions)
+			if !hasWordLimit {
+				wordLimit.MaxWords = -1
+			}
 			questionListInterface, ok := typeItem["question_list"].([]interface{})
 			if !ok {
-				spew.Dump(questionListInterface, "questionList skip")
 				continue
 			}
 			for _, questionInterface := range questionListInterface {
@@ -238,81 +261,93 @@ func CalculateScore(partList []map[string]interface{}, submittedAnswers []models
 					continue
 				}
 				correctAnswer := question["answer"]
-
-				// 检查用户是否提交了该题答案
-				if answer, exists := answerMap[questionNo]; exists && correctAnswer != nil {
-					score += calculateScoreByType(questionType, correctAnswer, answer)
-					// fmt.Printf("Question No: %s, User Answer: %v, Correct Answer: %v, Points: %d\n", questionNo, answer, correctAnswer, score)
+				if correctAnswer == nil {
+					continue
 				}
+
+				answer := answerMap[questionNo]
+				points := calculateScoreByType(questionType, wordLimit, correctAnswer, answer)
+				maxPoints := maxPointsByType(questionType, correctAnswer)
+				result.RawScore += points
+				result.Total += maxPoints
+				result.Questions = append(result.Questions, models.QuestionResult{
+					No:            questionNo,
+					PartID:        partID,
+					PartName:      partName,
+					PartIndex:     partIndex + 1,
+					QuestionType:  questionType,
+					UserAnswer:    answer,
+					CorrectAnswer: models.NewAnswerValue(correctAnswer),
+					Correct:       points == maxPoints,
+					Points:        points,
+					MaxPoints:     maxPoints,
+				})
 			}
 		}
 	}
-	spew.Dump(score, "total score")
-	// 定义分数映射
-	totalScore := score
-	// 根据 score 的值返回对应的分数
-	switch {
-	case totalScore >= 39:
-		return 9.0
-	case totalScore >= 37:
-		return 8.5
-	case totalScore >= 35:
-		return 8.0
-	case totalScore >= 33:
-		return 7.5
-	case totalScore >= 30:
-		return 7.0
-	case totalScore >= 27:
-		return 6.5
-	case totalScore >= 23:
-		return 6.0
-	case totalScore >= 20:
-		return 5.5
-	case totalScore >= 16:
-		return 5.0
-	case totalScore >= 13:
-		return 4.5
-	case totalScore >= 10:
-		return 4.0
-	case totalScore >= 6:
-		return 3.5
-	case totalScore >= 4:
-		return 3.0
-	case totalScore == 3:
-		return 2.5
-	case totalScore == 2:
-		return 2.0
-	case totalScore == 1:
-		return 1.0
-	default:
-		return 0
+	return result
+}
+
+// maxPointsByType 每道题的满分：多选题按正确选项个数计分，其余题型 1 分
+func maxPointsByType(questionType string, correctAnswer interface{}) int {
+	if qType, ok := models.ParseQuestionType(questionType); ok && qType == models.QuestionMultiChoice {
+		if correct, err := models.DecodeAnswer(qType, models.NewAnswerValue(correctAnswer)); err == nil && len(correct.Values()) > 0 {
+			return len(correct.Values())
+		}
 	}
+	return 1
 }
 
 // 根据题型计算得分
-func calculateScoreByType(questionType string, correctAnswer, userAnswer interface{}) int {
-	spew.Dump(questionType, correctAnswer, userAnswer, "questionType, correctAnswer, userAnswer")
-	switch questionType {
-	case "multi_choice":
+// 正确答案和用户答案都先按题型转换为对应的答案结构，再进行比较
+// wordLimit.MaxWords < 0 表示没有字数限制
+func calculateScoreByType(questionType string, wordLimit WordLimit, correctAnswer interface{}, userAnswer models.AnswerValue) int {
+	qType, ok := models.ParseQuestionType(questionType)
+	if !ok {
+		// 未知题型按填空题处理
+		qType = models.QuestionGapFill
+	}
+
+	correct, err := models.DecodeAnswer(qType, models.NewAnswerValue(correctAnswer))
+	if err != nil {
+		fmt.Printf("Invalid correct answer for %s: %v\n", questionType, err)
+		return 0
+	}
+	user, err := models.DecodeAnswer(qType, userAnswer)
+	if err != nil {
+		return 0
+	}
+
+	switch qType {
+	case models.QuestionMultiChoice:
 		// 多选题处理
-		if correctAns, ok := correctAnswer.([]interface{}); ok {
-			if userAns, ok := userAnswer.([]interface{}); ok {
-				return calculateMultiChoiceScore(correctAns, userAns)
-			}
+		return calculateMultiChoiceScore(correct.Values(), user.Values())
+	case models.QuestionGapFill:
+		// 填空题：超出字数限制判错，其余按规范化后的可接受答案比较
+		userText := user.Values()[0]
+		if wordLimit.MaxWords >= 0 && ExceedsWordLimit(userText, wordLimit) {
+			return 0
+		}
+		if MatchGapFill(correct.Values()[0], userText) {
+			return 1
 		}
 	default:
 		// 其他题型（单选、填空、匹配、地图等）
-		if correctAnswer == userAnswer {
+		if correct.Values()[0] != "" && correct.Values()[0] == user.Values()[0] {
 			return 1
 		}
 	}
 	return 0
 }
 
-// 计算多选题得分
-func calculateMultiChoiceScore(correctAnswers, userAnswers []interface{}) int {
+// 计算多选题得分：每个正确选项计 1 分；选择的个数超过要求（如 choose TWO 选了三项）时整题不得分
+func calculateMultiChoiceScore(correctAnswers, userAnswers []string) int {
+	if len(userAnswers) > len(correctAnswers) {
+		return 0
+	}
+
 	score := 0
-	correctSet := make(map[interface{}]bool)
+	correctSet := make(map[string]bool)
 
 	// 将正确答案放入集合
 	for _, correct := range correctAnswers {
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/Queen2333/ielts_test_backend/models"
+)
+
+func TestCalculateMultiChoiceScore(t *testing.T) {
+	tests := []struct {
+		name    string
+		correct []string
+		user    []string
+		want    int
+	}{
+		{"both correct", []string{"A", "C"}, []string{"C", "A"}, 2},
+		{"one correct", []string{"A", "C"}, []string{"A", "B"}, 1},
+		{"one correct, one left blank", []string{"A", "C"}, []string{"C"}, 1},
+		{"none correct", []string{"A", "C"}, []string{"B", "D"}, 0},
+		{"no answer", []string{"A", "C"}, nil, 0},
+		{"too many options chosen", []string{"A", "C"}, []string{"A", "B", "C"}, 0},
+		{"every option chosen", []string{"A", "C"}, []string{"A", "B", "C", "D", "E"}, 0},
+		{"choose three", []string{"B", "D", "E"}, []string{"B", "D", "E"}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := calculateMultiChoiceScore(tt.correct, tt.user); got != tt.want {
+				t.Errorf("calculateMultiChoiceScore(%q, %q) = %d, want %d", tt.correct, tt.user, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateScoreByTypeMultiChoice(t *testing.T) {
+	noLimit := WordLimit{MaxWords: -1}
+	tests := []struct {
+		name string
+		user interface{}
+		want int
+	}{
+		{"list answer", []interface{}{"a", "c"}, 2},
+		{"comma separated answer", "C,A", 2},
+		{"duplicates count once", []interface{}{"A", "A"}, 1},
+		{"all options ticked", []interface{}{"A", "B", "C", "D", "E"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := calculateScoreByType("multi_choice", noLimit, []interface{}{"A", "C"}, models.NewAnswerValue(tt.user))
+			if got != tt.want {
+				t.Errorf("calculateScoreByType(%v) = %d, want %d", tt.user, got, tt.want)
+			}
+		})
+	}
+}
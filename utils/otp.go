@@ -0,0 +1,219 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Queen2333/ielts_test_backend/database"
+	"github.com/Queen2333/ielts_test_backend/models"
+)
+
+// 邮箱验证码配置
+const (
+	OTPLength        = 6                // 验证码位数
+	OTPTTL           = 15 * time.Minute // 验证码有效期
+	OTPMaxAttempts   = 5                // 同一邮箱连续输错次数上限
+	OTPLockout       = 15 * time.Minute // 输错次数用完后的锁定时间
+	OTPEmailCooldown = 60 * time.Second // 同一邮箱两次发送的最小间隔
+	OTPIPCooldown    = 10 * time.Second // 同一 IP 两次发送的最小间隔
+
+	// otpSecretMinLength OTP_SECRET 最短长度，密钥泄露或过短时可以离线穷举 6 位验证码
+	otpSecretMinLength = 32
+)
+
+var (
+	// ErrOTPInvalid 验证码错误、已使用或已过期
+	ErrOTPInvalid = errors.New("invalid or expired verification code")
+	// ErrOTPLocked 输错次数过多，暂时锁定
+	ErrOTPLocked = errors.New("too many failed attempts, please try again later")
+	// ErrOTPSecretMissing 没有配置 OTP_SECRET
+	ErrOTPSecretMissing = fmt.Errorf("OTP_SECRET must be set to at least %d characters", otpSecretMinLength)
+)
+
+// OTPCooldownError 发送过于频繁，RetryAfter 后才能再次发送
+type OTPCooldownError struct {
+	RetryAfter time.Duration
+}
+
+func (e *OTPCooldownError) Error() string {
+	return fmt.Sprintf("please wait %d seconds before requesting another code", int(e.RetryAfter.Seconds()))
+}
+
+func otpCodeKey(email string) string     { return "otp:code:" + email }
+func otpAttemptsKey(email string) string { return "otp:attempts:" + email }
+func otpLockKey(email string) string     { return "otp:lock:" + email }
+func otpEmailCooldownKey(email string) string {
+	return "otp:cooldown:email:" + email
+}
+func otpIPCooldownKey(ip string) string { return "otp:cooldown:ip:" + ip }
+
+// NormalizeEmail 去掉首尾空格并转为小写，验证码和用户都按规范化后的邮箱处理
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// CheckOTPConfig 检查 OTP_SECRET，启动时调用，没有配置时不能启动
+func CheckOTPConfig() error {
+	if len(os.Getenv("OTP_SECRET")) < otpSecretMinLength {
+		return ErrOTPSecretMissing
+	}
+	return nil
+}
+
+// hashOTP 验证码只以 HMAC 形式存储，Redis 中看不到明文
+func hashOTP(email, code string) (string, error) {
+	if err := CheckOTPConfig(); err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, []byte(os.Getenv("OTP_SECRET")))
+	mac.Write([]byte(email + ":" + code))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// generateOTP 使用 crypto/rand 生成数字验证码
+func generateOTP(length int) (string, error) {
+	b := make([]byte, length)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		b[i] = byte('0' + n.Int64())
+	}
+	return string(b), nil
+}
+
+// IssueOTP 为邮箱生成新的验证码并返回明文（只用于发邮件，不能返回给客户端）
+// 邮箱被锁定时返回 ErrOTPLocked，发送过于频繁时返回 *OTPCooldownError
+func IssueOTP(email, ip string) (string, error) {
+	if err := InitRedis(); err != nil {
+		return "", err
+	}
+
+	if ttl, err := TTL(otpLockKey(email)); err == nil && ttl > 0 {
+		AuditAuth(models.AuditOTPThrottled, email, ip, "", "locked")
+		return "", ErrOTPLocked
+	}
+
+	ok, err := SetNX(otpEmailCooldownKey(email), 1, OTPEmailCooldown)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		AuditAuth(models.AuditOTPThrottled, email, ip, "", "email cooldown")
+		return "", &OTPCooldownError{RetryAfter: remaining(otpEmailCooldownKey(email), OTPEmailCooldown)}
+	}
+	if ip != "" {
+		ok, err = SetNX(otpIPCooldownKey(ip), 1, OTPIPCooldown)
+		if err != nil {
+			Del(otpEmailCooldownKey(email))
+			return "", err
+		}
+		if !ok {
+			Del(otpEmailCooldownKey(email))
+			AuditAuth(models.AuditOTPThrottled, email, ip, "", "ip cooldown")
+			return "", &OTPCooldownError{RetryAfter: remaining(otpIPCooldownKey(ip), OTPIPCooldown)}
+		}
+	}
+
+	code, err := generateOTP(OTPLength)
+	if err != nil {
+		return "", err
+	}
+	hash, err := hashOTP(email, code)
+	if err != nil {
+		return "", err
+	}
+	// 新验证码替换旧验证码，错误次数重新计算
+	if err := Set(otpCodeKey(email), hash, OTPTTL); err != nil {
+		return "", err
+	}
+	Del(otpAttemptsKey(email))
+	return code, nil
+}
+
+// RevokeOTP 验证码发送失败时撤销，并允许立即重新发送
+func RevokeOTP(email, ip string) {
+	keys := []string{otpCodeKey(email), otpEmailCooldownKey(email)}
+	if ip != "" {
+		keys = append(keys, otpIPCooldownKey(ip))
+	}
+	Del(keys...)
+}
+
+// VerifyOTP 校验验证码，成功后立即删除（只能使用一次）
+// 连续输错 OTPMaxAttempts 次后删除验证码并锁定邮箱 OTPLockout
+func VerifyOTP(email, code, ip string) error {
+	if err := InitRedis(); err != nil {
+		return err
+	}
+
+	if ttl, err := TTL(otpLockKey(email)); err == nil && ttl > 0 {
+		AuditAuth(models.AuditOTPFailed, email, ip, "", "locked")
+		return ErrOTPLocked
+	}
+
+	hash, err := hashOTP(email, code)
+	if err != nil {
+		return err
+	}
+	stored, err := Get(otpCodeKey(email))
+	if err != nil || !hmac.Equal([]byte(stored), []byte(hash)) {
+		attempts, incrErr := Incr(otpAttemptsKey(email), OTPLockout)
+		if incrErr != nil {
+			return incrErr
+		}
+		if attempts >= OTPMaxAttempts {
+			Set(otpLockKey(email), 1, OTPLockout)
+			Del(otpCodeKey(email), otpAttemptsKey(email))
+			AuditAuth(models.AuditOTPLocked, email, ip, "", fmt.Sprintf("%d failed attempts", attempts))
+			return ErrOTPLocked
+		}
+		AuditAuth(models.AuditOTPFailed, email, ip, "", fmt.Sprintf("attempt %d", attempts))
+		return ErrOTPInvalid
+	}
+
+	// 并发请求同一验证码时只有成功删除的一方通过
+	deleted, err := Del(otpCodeKey(email))
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		AuditAuth(models.AuditOTPFailed, email, ip, "", "already used")
+		return ErrOTPInvalid
+	}
+	Del(otpAttemptsKey(email))
+	return nil
+}
+
+// remaining 获取冷却键的剩余时间，获取失败时使用完整冷却时间
+func remaining(key string, fallback time.Duration) time.Duration {
+	ttl, err := TTL(key)
+	if err != nil || ttl <= 0 {
+		return fallback
+	}
+	return ttl
+}
+
+// AuditAuth 写入认证审计日志，失败只打印不影响请求
+func AuditAuth(event, email, ip, userID, detail string) {
+	item := models.AuthAuditItem{
+		Event:     event,
+		Email:     email,
+		IP:        ip,
+		UserID:    userID,
+		Detail:    detail,
+		CreatedAt: time.Now().Format(timeLayout),
+	}
+	if _, err := database.InsertData("auth_audit_log", &item, "create"); err != nil {
+		fmt.Println("认证审计日志写入失败:", event, email, err)
+	}
+}
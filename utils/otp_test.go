@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const testOTPSecret = "0123456789abcdef0123456789abcdef"
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		email string
+		want  string
+	}{
+		{"user@example.com", "user@example.com"},
+		{"  User@Example.COM \n", "user@example.com"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeEmail(tt.email); got != tt.want {
+			t.Errorf("NormalizeEmail(%q) = %q, want %q", tt.email, got, tt.want)
+		}
+	}
+}
+
+func TestCheckOTPConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  string
+		wantErr bool
+	}{
+		{"missing", "", true},
+		{"too short", testOTPSecret[:otpSecretMinLength-1], true},
+		{"minimum length", testOTPSecret, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OTP_SECRET", tt.secret)
+			if err := CheckOTPConfig(); (err != nil) != tt.wantErr {
+				t.Errorf("CheckOTPConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHashOTP(t *testing.T) {
+	t.Setenv("OTP_SECRET", testOTPSecret)
+
+	hash, err := hashOTP("user@example.com", "123456")
+	if err != nil {
+		t.Fatalf("hashOTP() error = %v", err)
+	}
+	if strings.Contains(hash, "123456") || len(hash) != 64 {
+		t.Errorf("hashOTP() = %q, want 64 hex characters without the code", hash)
+	}
+
+	tests := []struct {
+		name  string
+		email string
+		code  string
+		same  bool
+	}{
+		{"same input", "user@example.com", "123456", true},
+		{"different code", "user@example.com", "123457", false},
+		{"different email", "other@example.com", "123456", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			other, err := hashOTP(tt.email, tt.code)
+			if err != nil {
+				t.Fatalf("hashOTP() error = %v", err)
+			}
+			if (other == hash) != tt.same {
+				t.Errorf("hashOTP(%q, %q) == hashOTP(user@example.com, 123456) is %v, want %v", tt.email, tt.code, other == hash, tt.same)
+			}
+		})
+	}
+
+	t.Setenv("OTP_SECRET", "")
+	if _, err := hashOTP("user@example.com", "123456"); err != ErrOTPSecretMissing {
+		t.Errorf("hashOTP() without secret error = %v, want %v", err, ErrOTPSecretMissing)
+	}
+}
+
+func TestGenerateOTP(t *testing.T) {
+	for _, length := range []int{0, 1, OTPLength, 12} {
+		code, err := generateOTP(length)
+		if err != nil {
+			t.Fatalf("generateOTP(%d) error = %v", length, err)
+		}
+		if len(code) != length {
+			t.Errorf("generateOTP(%d) = %q, want %d digits", length, code, length)
+		}
+		if strings.Trim(code, "0123456789") != "" {
+			t.Errorf("generateOTP(%d) = %q, want digits only", length, code)
+		}
+	}
+}
+
+func TestOTPCooldownError(t *testing.T) {
+	tests := []struct {
+		retryAfter time.Duration
+		want       string
+	}{
+		{OTPEmailCooldown, "please wait 60 seconds before requesting another code"},
+		{1500 * time.Millisecond, "please wait 1 seconds before requesting another code"},
+	}
+	for _, tt := range tests {
+		err := &OTPCooldownError{RetryAfter: tt.retryAfter}
+		if got := err.Error(); got != tt.want {
+			t.Errorf("OTPCooldownError{%v}.Error() = %q, want %q", tt.retryAfter, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/Queen2333/ielts_test_backend/database"
+	"github.com/Queen2333/ielts_test_backend/models"
+)
+
+// RoundOverallBand 按官方规则取整：平均分以 .25 结尾进到 .5，以 .75 结尾进到下一个整数
+func RoundOverallBand(average float64) float64 {
+	// 加上极小值避免 6.25 这类值因浮点误差被舍掉
+	return math.Floor(average*2+0.5+1e-9) / 2
+}
+
+// CalculateOverallBand 计算综合分
+// 听力、阅读、写作必须评分，口语在 requireSpeaking 为 true 或已有分数时计入
+// 有科目未评分时按已评分的科目计算暂定综合分
+func CalculateOverallBand(listening, reading, writing, speaking *float64, requireSpeaking bool) models.OverallBandResult {
+	result := models.OverallBandResult{
+		Listening: listening,
+		Reading:   reading,
+		Writing:   writing,
+		Speaking:  speaking,
+		Status:    models.OverallFinal,
+	}
+
+	components := []struct {
+		skill    string
+		band     *float64
+		required bool
+	}{
+		{models.SkillListening, listening, true},
+		{models.SkillReading, reading, true},
+		{models.SkillWriting, writing, true},
+		{models.SkillSpeaking, speaking, requireSpeaking},
+	}
+
+	sum, count := 0.0, 0
+	for _, component := range components {
+		if component.band == nil {
+			if component.required {
+				result.Pending = append(result.Pending, component.skill)
+			}
+			continue
+		}
+		sum += *component.band
+		count++
+	}
+
+	if len(result.Pending) > 0 {
+		result.Status = models.OverallProvisional
+	}
+	if count > 0 {
+		band := RoundOverallBand(sum / float64(count))
+		result.Band = &band
+	}
+	return result
+}
+
+// OverallFromSections 根据套题各科目成绩计算综合分
+func OverallFromSections(sections []models.SectionScore, requireSpeaking bool) models.OverallBandResult {
+	bands := make(map[string]*float64)
+	for _, section := range sections {
+		bands[section.Skill] = section.Band
+	}
+	return CalculateOverallBand(bands[models.SkillListening], bands[models.SkillReading], bands[models.SkillWriting], bands[models.SkillSpeaking], requireSpeaking)
+}
+
+// ParseSections 把数据库查询结果中的 sections 字段转换为结构体
+func ParseSections(value interface{}) ([]models.SectionScore, error) {
+	var sections []models.SectionScore
+	if err := DecodeJSONValue(value, &sections); err != nil {
+		return nil, err
+	}
+	return sections, nil
+}
+
+// SetTestingComponentBand 写入套题记录中某个科目的 Band 分（如写作评分、口语评分完成后），并重新计算综合分
+// 写作和口语评分可能同时完成，读取和写入 sections 在同一个事务中进行，并锁定该记录
+func SetTestingComponentBand(recordID int, skill string, band float64) (models.OverallBandResult, error) {
+	if band < 0 || band > 9 || math.Mod(band*2, 1) != 0 {
+		return models.OverallBandResult{}, fmt.Errorf("invalid band %v", band)
+	}
+
+	tx, err := database.GetDB().Begin()
+	if err != nil {
+		return models.OverallBandResult{}, err
+	}
+	defer tx.Rollback()
+
+	var sectionsJSON sql.NullString
+	var testID sql.NullInt64
+	err = tx.QueryRow("SELECT sections, test_id FROM testing_records WHERE id = ? FOR UPDATE", recordID).Scan(&sectionsJSON, &testID)
+	if database.IsNoRowsError(err) {
+		return models.OverallBandResult{}, fmt.Errorf("testing record %d not found", recordID)
+	}
+	if err != nil {
+		return models.OverallBandResult{}, err
+	}
+	var sections []models.SectionScore
+	if sectionsJSON.Valid && sectionsJSON.String != "" {
+		if err := json.Unmarshal([]byte(sectionsJSON.String), &sections); err != nil {
+			return models.OverallBandResult{}, fmt.Errorf("invalid sections in testing record %d: %w", recordID, err)
+		}
+	}
+
+	found := false
+	for i := range sections {
+		if sections[i].Skill == skill {
+			sections[i].Band = &band
+			found = true
+		}
+	}
+	if !found {
+		sections = append(sections, models.SectionScore{Skill: skill, Band: &band})
+	}
+
+	overall := OverallFromSections(sections, testingRequiresSpeaking(int(testID.Int64)))
+	sectionsValue, err := json.Marshal(sections)
+	if err != nil {
+		return models.OverallBandResult{}, err
+	}
+	overallValue, err := json.Marshal(overall)
+	if err != nil {
+		return models.OverallBandResult{}, err
+	}
+	_, err = tx.Exec("UPDATE testing_records SET sections = ?, overall = ?, overall_band = ? WHERE id = ?",
+		string(sectionsValue), string(overallValue), overall.Band, recordID)
+	if err != nil {
+		return models.OverallBandResult{}, err
+	}
+	return overall, tx.Commit()
+}
+
+// testingRequiresSpeaking 套题是否包含口语
+func testingRequiresSpeaking(testID int) bool {
+	test, err := database.GetDataById("testing_list", testID)
+	if err != nil || test == nil {
+		return false
+	}
+	return len(InterfaceToIntList(test["speaking_ids"])) > 0
+}
@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Queen2333/ielts_test_backend/models"
+)
+
+func TestRoundOverallBand(t *testing.T) {
+	tests := []struct {
+		average float64
+		want    float64
+	}{
+		{6.0, 6.0},
+		{6.1, 6.0},
+		{6.125, 6.0},
+		{6.25, 6.5},
+		{6.375, 6.5},
+		{6.5, 6.5},
+		{6.625, 6.5},
+		{6.75, 7.0},
+		{6.875, 7.0},
+		{(6.5 + 6.5 + 5.0 + 7.0) / 4, 6.5}, // 6.25
+		{(4.0 + 3.5 + 4.0 + 4.0) / 4, 4.0}, // 3.875
+		{(6.5 + 6.5 + 5.5 + 7.5) / 4, 6.5}, // 6.5
+		{(7.0 + 7.0 + 6.5 + 7.0) / 4, 7.0}, // 6.875
+		{0, 0},
+		{9, 9},
+	}
+	for _, tt := range tests {
+		if got := RoundOverallBand(tt.average); got != tt.want {
+			t.Errorf("RoundOverallBand(%v) = %v, want %v", tt.average, got, tt.want)
+		}
+	}
+}
+
+func TestCalculateOverallBand(t *testing.T) {
+	band := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name            string
+		listening       *float64
+		reading         *float64
+		writing         *float64
+		speaking        *float64
+		requireSpeaking bool
+		wantBand        *float64
+		wantStatus      string
+		wantPending     []string
+	}{
+		{"all four", band(6.5), band(6.5), band(5.0), band(7.0), true, band(6.5), models.OverallFinal, nil},
+		{"speaking optional and missing", band(7.0), band(6.5), band(6.0), nil, false, band(6.5), models.OverallFinal, nil},
+		{"speaking optional but graded", band(7.0), band(7.0), band(6.0), band(8.0), false, band(7.0), models.OverallFinal, nil},
+		{"speaking required and missing", band(7.0), band(6.5), band(6.0), nil, true, band(6.5), models.OverallProvisional, []string{models.SkillSpeaking}},
+		{"writing not graded", band(8.0), band(7.0), nil, nil, false, band(7.5), models.OverallProvisional, []string{models.SkillWriting}},
+		{"nothing graded", nil, nil, nil, nil, true, nil, models.OverallProvisional, []string{models.SkillListening, models.SkillReading, models.SkillWriting, models.SkillSpeaking}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CalculateOverallBand(tt.listening, tt.reading, tt.writing, tt.speaking, tt.requireSpeaking)
+			if !reflect.DeepEqual(got.Band, tt.wantBand) {
+				t.Errorf("Band = %v, want %v", deref(got.Band), deref(tt.wantBand))
+			}
+			if got.Status != tt.wantStatus {
+				t.Errorf("Status = %q, want %q", got.Status, tt.wantStatus)
+			}
+			if !reflect.DeepEqual(got.Pending, tt.wantPending) {
+				t.Errorf("Pending = %q, want %q", got.Pending, tt.wantPending)
+			}
+		})
+	}
+}
+
+func TestOverallFromSections(t *testing.T) {
+	listening, reading, writing := 8.0, 7.5, 6.5
+	sections := []models.SectionScore{
+		{Skill: models.SkillListening, Band: &listening},
+		{Skill: models.SkillReading, Band: &reading},
+		{Skill: models.SkillWriting, Band: &writing},
+		{Skill: models.SkillSpeaking},
+	}
+
+	got := OverallFromSections(sections, false)
+	if got.Band == nil || *got.Band != 7.5 || got.Status != models.OverallFinal {
+		t.Errorf("OverallFromSections(optional speaking) = %v %q, want 7.5 %q", deref(got.Band), got.Status, models.OverallFinal)
+	}
+
+	got = OverallFromSections(sections, true)
+	if got.Status != models.OverallProvisional || !reflect.DeepEqual(got.Pending, []string{models.SkillSpeaking}) {
+		t.Errorf("OverallFromSections(required speaking) = %q %q, want %q [speaking]", got.Status, got.Pending, models.OverallProvisional)
+	}
+}
+
+func deref(v *float64) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
@@ -0,0 +1,224 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/Queen2333/ielts_test_backend/database"
+	"github.com/Queen2333/ielts_test_backend/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 密码配置
+const (
+	PasswordMinLength      = 8                // 最短长度
+	PasswordMaxBytes       = 72               // bcrypt 只使用前 72 字节
+	PasswordBcryptCost     = 12               // bcrypt 计算强度
+	PasswordMaxAttempts    = 5                // 同一邮箱连续输错次数上限
+	PasswordLockout        = 15 * time.Minute // 输错次数用完后的锁定时间
+	PasswordResetTokenTTL  = 30 * time.Minute // 重置链接有效期
+	passwordResetTokenSize = 32
+)
+
+var (
+	// ErrInvalidCredentials 邮箱或密码错误（不区分用户是否存在）
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	// ErrPasswordLocked 密码输错次数过多，暂时锁定
+	ErrPasswordLocked = errors.New("too many failed attempts, please try again later")
+	// ErrPasswordResetInvalid 重置链接无效、已使用或已过期
+	ErrPasswordResetInvalid = errors.New("invalid or expired reset token")
+)
+
+// dummyPasswordHash 用户不存在时也做一次比对，避免通过响应时间判断邮箱是否注册
+var (
+	dummyPasswordOnce sync.Once
+	dummyPasswordHash []byte
+)
+
+func passwordAttemptsKey(email string) string { return "password:attempts:" + email }
+func passwordLockKey(email string) string     { return "password:lock:" + email }
+func passwordResetKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "password:reset:" + hex.EncodeToString(sum[:])
+}
+
+// ValidatePassword 检查密码强度：8-72 字节，同时包含字母和数字
+func ValidatePassword(password string) error {
+	if len([]rune(password)) < PasswordMinLength {
+		return fmt.Errorf("password must be at least %d characters", PasswordMinLength)
+	}
+	if len(password) > PasswordMaxBytes {
+		return fmt.Errorf("password must be at most %d bytes", PasswordMaxBytes)
+	}
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return errors.New("password must contain both letters and digits")
+	}
+	return nil
+}
+
+// HashPassword 使用 bcrypt 计算密码哈希
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), PasswordBcryptCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword 比对密码和哈希，哈希为空时使用假哈希保证耗时一致
+func CheckPassword(hash, password string) bool {
+	if hash == "" {
+		dummyPasswordOnce.Do(func() {
+			dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password-0"), PasswordBcryptCost)
+		})
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// UserPasswordHash 获取用户的密码哈希，没有设置密码时返回空字符串
+func UserPasswordHash(userID string) (string, error) {
+	var hash *string
+	err := database.GetDB().QueryRow("SELECT password_hash FROM user_list WHERE id = ?", userID).Scan(&hash)
+	if err != nil {
+		return "", err
+	}
+	if hash == nil {
+		return "", nil
+	}
+	return *hash, nil
+}
+
+// SetUserPassword 设置用户密码
+func SetUserPassword(userID, password string) error {
+	hash, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+	_, err = database.GetDB().Exec("UPDATE user_list SET password_hash = ?, password_updated_at = ? WHERE id = ?",
+		hash, time.Now().Format(timeLayout), userID)
+	return err
+}
+
+// FindUserByEmail 按邮箱获取用户和密码哈希
+func FindUserByEmail(email string) (models.UserQuery, string, error) {
+	var user models.UserQuery
+	var hash *string
+	err := database.GetDB().QueryRow("SELECT id, email, role_id, password_hash FROM user_list WHERE email = ?", email).
+		Scan(&user.ID, &user.Email, &user.RoleID, &hash)
+	if err != nil {
+		return models.UserQuery{}, "", err
+	}
+	if hash == nil {
+		return user, "", nil
+	}
+	return user, *hash, nil
+}
+
+// AuthenticatePassword 邮箱密码登录校验，连续输错 PasswordMaxAttempts 次后锁定 PasswordLockout
+// 用户不存在、没有设置密码和密码错误都返回 ErrInvalidCredentials
+func AuthenticatePassword(email, password, ip string) (models.UserQuery, error) {
+	if err := InitRedis(); err != nil {
+		return models.UserQuery{}, err
+	}
+	if ttl, err := TTL(passwordLockKey(email)); err == nil && ttl > 0 {
+		AuditAuth(models.AuditPasswordFailed, email, ip, "", "locked")
+		return models.UserQuery{}, ErrPasswordLocked
+	}
+
+	user, hash, err := FindUserByEmail(email)
+	if err != nil && !database.IsNoRowsError(err) {
+		return models.UserQuery{}, err
+	}
+	if !CheckPassword(hash, password) {
+		attempts, incrErr := Incr(passwordAttemptsKey(email), PasswordLockout)
+		if incrErr != nil {
+			return models.UserQuery{}, incrErr
+		}
+		if attempts >= PasswordMaxAttempts {
+			Set(passwordLockKey(email), 1, PasswordLockout)
+			Del(passwordAttemptsKey(email))
+			AuditAuth(models.AuditPasswordLocked, email, ip, user.ID, fmt.Sprintf("%d failed attempts", attempts))
+			return models.UserQuery{}, ErrPasswordLocked
+		}
+		AuditAuth(models.AuditPasswordFailed, email, ip, user.ID, fmt.Sprintf("attempt %d", attempts))
+		return models.UserQuery{}, ErrInvalidCredentials
+	}
+
+	Del(passwordAttemptsKey(email))
+	return user, nil
+}
+
+// IssuePasswordReset 生成重置令牌（Redis 中只保存哈希），返回明文令牌和重置链接
+// 设置了 PASSWORD_RESET_URL 时链接为该地址加上 token 参数，否则链接为空，邮件中直接给出令牌
+func IssuePasswordReset(userID string) (string, string, error) {
+	if err := InitRedis(); err != nil {
+		return "", "", err
+	}
+	b := make([]byte, passwordResetTokenSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := hex.EncodeToString(b)
+	if err := Set(passwordResetKey(token), userID, PasswordResetTokenTTL); err != nil {
+		return "", "", err
+	}
+
+	link := ""
+	if base := os.Getenv("PASSWORD_RESET_URL"); base != "" {
+		u, err := url.Parse(base)
+		if err == nil {
+			query := u.Query()
+			query.Set("token", token)
+			u.RawQuery = query.Encode()
+			link = u.String()
+		}
+	}
+	return token, link, nil
+}
+
+// ConsumePasswordReset 校验重置令牌并立即删除（只能使用一次），返回用户 ID
+func ConsumePasswordReset(token string) (string, error) {
+	if err := InitRedis(); err != nil {
+		return "", err
+	}
+	if token == "" {
+		return "", ErrPasswordResetInvalid
+	}
+	key := passwordResetKey(token)
+	userID, err := Get(key)
+	if err != nil || userID == "" {
+		return "", ErrPasswordResetInvalid
+	}
+	deleted, err := Del(key)
+	if err != nil {
+		return "", err
+	}
+	if deleted == 0 {
+		return "", ErrPasswordResetInvalid
+	}
+	return userID, nil
+}
+
+// ClearPasswordLockout 重置密码后清除输错次数和锁定
+func ClearPasswordLockout(email string) {
+	Del(passwordAttemptsKey(email), passwordLockKey(email))
+}
@@ -0,0 +1,317 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Queen2333/ielts_test_backend/database"
+	"github.com/Queen2333/ielts_test_backend/models"
+	"github.com/Queen2333/ielts_test_backend/services"
+)
+
+// MaxDraftQuestions 一次最多生成的题目数量
+const MaxDraftQuestions = 20
+
+// questionDraftAttempts 模型输出无法解析时最多请求的次数
+const questionDraftAttempts = 2
+
+var questionDraftTemperature float32 = 0.4
+
+var (
+	ErrDraftOutput = errors.New("llm returned an invalid question draft") // 模型输出不是合法的题组结构
+	ErrLLMRequest  = errors.New("llm request failed")                     // 模型服务请求失败
+)
+
+// 各题型的出题要求
+var questionTypeRules = map[models.QuestionType]string{
+	models.QuestionGapFill:      `"gap_fill": the title states the word limit (e.g. "Write NO MORE THAN TWO WORDS from the passage for each answer."); each question contains "____" for the gap; the answer is copied exactly from the text; alternatives are separated by "/".`,
+	models.QuestionSingleChoice: `"single_choice": each question has options labelled A-D; the answer is one label.`,
+	models.QuestionMultiChoice:  `"multi_choice": one question per set with options labelled A-E; the answer is an array of labels (e.g. ["B","D"]) and the question's "no" covers one number per answer (e.g. "21-22").`,
+	models.QuestionMatching:     `"matching": put the choices in the group's "matching_options" labelled A, B, C...; each answer is one label.`,
+	models.QuestionMapLabelling: `"map_labelling": put the labels in the group's "matching_options" labelled A, B, C...; each answer is one label.`,
+	models.QuestionTFNG:         `"tfng": statements about facts in the passage; the answer is TRUE, FALSE or NOT GIVEN.`,
+	models.QuestionYNNG:         `"ynng": statements about the writer's views; the answer is YES, NO or NOT GIVEN.`,
+	models.QuestionHeadings:     `"headings": put the list of headings in "matching_options" labelled with lower-case roman numerals (i, ii, iii...), including at least two extra headings; each question names a paragraph and the answer is one numeral.`,
+}
+
+// GenerateQuestionDraft 根据文章和题型要求生成题组草稿
+// 生成后统一编号，并用与录题相同的规则校验答案，发现的问题放在 Problems 中供审核
+func GenerateQuestionDraft(ctx context.Context, userID string, req models.QuestionDraftRequest) (*models.QuestionDraft, error) {
+	if problems := validateDraftRequest(&req); len(problems) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	if req.PartID != 0 {
+		article, err := draftArticle(userID, req)
+		if err != nil {
+			return nil, err
+		}
+		req.Article = article
+	}
+	if strings.TrimSpace(req.Article) == "" {
+		return nil, fmt.Errorf("article is required")
+	}
+
+	provider := services.DefaultProvider()
+	chatReq := services.ChatRequest{
+		Messages: []services.Message{
+			{Role: "system", Content: questionDraftSystemPrompt(req.Skill)},
+			{Role: "user", Content: questionDraftPrompt(req)},
+		},
+		Temperature: &questionDraftTemperature,
+	}
+
+	draft := &models.QuestionDraft{
+		Skill:    req.Skill,
+		Status:   models.QuestionDraftStatus,
+		Source:   models.FeedbackSourceAI,
+		Problems: []string{},
+	}
+	var output struct {
+		TypeList []map[string]interface{} `json:"type_list"`
+	}
+	var err error
+	for attempt := 0; attempt < questionDraftAttempts; attempt++ {
+		var resp *services.ChatResponse
+		output.TypeList = nil
+		resp, err = services.ChatJSON(ctx, provider, chatReq, &output)
+		if resp != nil {
+			draft.Model = resp.Model
+			draft.TotalTokens += resp.Usage.TotalTokens
+		}
+		if resp == nil {
+			return nil, fmt.Errorf("%w: %v", ErrLLMRequest, err)
+		}
+		if err == nil && len(output.TypeList) == 0 {
+			err = ErrDraftOutput
+		}
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDraftOutput, err)
+	}
+
+	draft.Problems = append(draft.Problems, prepareDraftTypeList(output.TypeList, req)...)
+	typeList := make([]interface{}, len(output.TypeList))
+	for i, group := range output.TypeList {
+		typeList[i] = group
+	}
+	_, problems := models.NormalizeTypeList(typeList)
+	draft.Problems = append(draft.Problems, problems...)
+
+	// 转换为 part 使用的结构，丢弃模型输出的多余字段
+	if req.Skill == models.SkillListening {
+		var groups []models.ListeningTypeItem
+		if err := DecodeJSONValue(output.TypeList, &groups); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDraftOutput, err)
+		}
+		draft.TypeList = groups
+	} else {
+		var groups []models.ReadingTypeItem
+		if err := DecodeJSONValue(output.TypeList, &groups); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDraftOutput, err)
+		}
+		draft.TypeList = groups
+	}
+	return draft, nil
+}
+
+// validateDraftRequest 校验科目和题型，并把题型名称转换为标准名称
+func validateDraftRequest(req *models.QuestionDraftRequest) []string {
+	var problems []string
+	if req.Skill != models.SkillReading && req.Skill != models.SkillListening {
+		problems = append(problems, fmt.Sprintf("skill must be reading or listening, got %q", req.Skill))
+	}
+	if req.StartNo <= 0 {
+		req.StartNo = 1
+	}
+	if len(req.Types) == 0 {
+		problems = append(problems, "at least one question type is required")
+	}
+	total := 0
+	for i := range req.Types {
+		questionType, ok := models.ParseQuestionType(req.Types[i].Type)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("unknown question type %q", req.Types[i].Type))
+			continue
+		}
+		req.Types[i].Type = string(questionType)
+		if req.Types[i].Count <= 0 {
+			problems = append(problems, fmt.Sprintf("%s count must be positive", questionType))
+		}
+		total += req.Types[i].Count
+	}
+	if total > MaxDraftQuestions {
+		problems = append(problems, fmt.Sprintf("at most %d questions can be generated at once, got %d", MaxDraftQuestions, total))
+	}
+	return problems
+}
+
+// draftArticle 读取阅读 part 的文章；用户自建的 part 只有创建者可以使用
+func draftArticle(userID string, req models.QuestionDraftRequest) (string, error) {
+	if req.Skill != models.SkillReading {
+		return "", fmt.Errorf("part_id is only supported for reading, provide the listening transcript as article")
+	}
+	part, err := database.GetDataById("reading_part_list", req.PartID)
+	if err != nil || (fmt.Sprint(part["type"]) == "3" && part["user_id"] != userID) {
+		return "", fmt.Errorf("reading part %d not found", req.PartID)
+	}
+	article, _ := part["article"].(string)
+	return stripHTML(article), nil
+}
+
+func questionDraftSystemPrompt(skill string) string {
+	optionShape := `{"label": "A", "text": "..."}`
+	source := "reading passage"
+	if skill == models.SkillListening {
+		optionShape = `{"label": "A", "value": "..."}`
+		source = "listening transcript"
+	}
+	return fmt.Sprintf(`You are an experienced IELTS %s item writer. Write questions that can be answered only from the %s provided.
+Reply with a single JSON object and nothing else, using exactly this shape:
+{
+  "type_list": [
+    {
+      "title": "<instructions shown to candidates, e.g. Questions 1-5 ...>",
+      "type": "<question type>",
+      "matching_options": [{"label": "A", "content": "..."}],
+      "question_list": [
+        {"no": "1", "question": "...", "options": [%s], "answer": "..."}
+      ]
+    }
+  ]
+}
+Omit "options" and "matching_options" when the question type does not use them.`, skill, source, optionShape)
+}
+
+func questionDraftPrompt(req models.QuestionDraftRequest) string {
+	var b strings.Builder
+	b.WriteString("Create the following question groups in this order:\n")
+	no := req.StartNo
+	for _, item := range req.Types {
+		fmt.Fprintf(&b, "- %d %s questions, numbered from %d\n", item.Count, item.Type, no)
+		no += item.Count
+	}
+	b.WriteString("\nRules:\n")
+	seen := make(map[string]bool)
+	for _, item := range req.Types {
+		if !seen[item.Type] {
+			seen[item.Type] = true
+			fmt.Fprintf(&b, "- %s\n", questionTypeRules[models.QuestionType(item.Type)])
+		}
+	}
+	fmt.Fprintf(&b, "\nText:\n%s\n", truncateRunes(req.Article, tutorContextLimit))
+	return b.String()
+}
+
+// prepareDraftTypeList 校验题组与请求是否一致，检查题目内容并从 StartNo 开始统一编号
+func prepareDraftTypeList(typeList []map[string]interface{}, req models.QuestionDraftRequest) []string {
+	var problems []string
+	if len(typeList) != len(req.Types) {
+		problems = append(problems, fmt.Sprintf("expected %d question groups, got %d", len(req.Types), len(typeList)))
+	}
+
+	article := strings.ToLower(strings.Join(strings.Fields(req.Article), " "))
+	next := req.StartNo
+	for i, group := range typeList {
+		typeName, _ := group["type"].(string)
+		questionType, known := models.ParseQuestionType(typeName)
+		if known {
+			group["type"] = string(questionType)
+		}
+		if i < len(req.Types) && string(questionType) != req.Types[i].Type {
+			problems = append(problems, fmt.Sprintf("group %d: expected %s questions, got %q", i+1, req.Types[i].Type, typeName))
+		}
+
+		questions := interfaceMaps(group["question_list"])
+		groupLabels := optionLabels(group["matching_options"])
+		wordLimit, hasLimit := ParseWordLimit(fmt.Sprint(group["title"]))
+
+		groupStart := next
+		for _, question := range questions {
+			span := questionSpan(string(questionType), question)
+			question["no"] = FormatQuestionNo(next, next+span-1)
+			if req.Skill == models.SkillReading {
+				question["id"] = next
+			}
+			next += span
+			problems = append(problems, checkDraftQuestion(question, questionType, groupLabels, article, wordLimit, hasLimit)...)
+			// 不符合题型的答案无法转换为 part 的结构，记录问题后清空，由老师在草稿中补充
+			if known && question["answer"] != nil {
+				if _, err := models.DecodeAnswer(questionType, models.NewAnswerValue(question["answer"])); err != nil {
+					problems = append(problems, fmt.Sprintf("question %v: %v", question["no"], err))
+					question["answer"] = nil
+				}
+			}
+		}
+		// 多选题一道题占多个题号，按题号数量比较
+		if i < len(req.Types) && next-groupStart != req.Types[i].Count {
+			problems = append(problems, fmt.Sprintf("group %d: expected %d questions, got %d", i+1, req.Types[i].Count, next-groupStart))
+		}
+	}
+	return problems
+}
+
+// checkDraftQuestion 检查题干、答案是否存在，选择/匹配题答案是否在选项中，填空题答案是否来自原文且不超过字数限制
+func checkDraftQuestion(question map[string]interface{}, questionType models.QuestionType, groupLabels map[string]bool, article string, wordLimit WordLimit, hasLimit bool) []string {
+	var problems []string
+	no := question["no"]
+	if strings.TrimSpace(fmt.Sprint(question["question"])) == "" || question["question"] == nil {
+		problems = append(problems, fmt.Sprintf("question %v: question text is required", no))
+	}
+	answer := models.NewAnswerValue(question["answer"])
+	if answer.IsEmpty() {
+		return append(problems, fmt.Sprintf("question %v: answer is required", no))
+	}
+
+	switch questionType {
+	case models.QuestionSingleChoice, models.QuestionMultiChoice:
+		labels := optionLabels(question["options"])
+		for _, value := range answer.Trimmed() {
+			for _, label := range strings.Split(value, ",") {
+				if label = strings.ToUpper(strings.TrimSpace(label)); !labels[label] {
+					problems = append(problems, fmt.Sprintf("question %v: answer %q is not one of the options", no, label))
+				}
+			}
+		}
+	case models.QuestionMatching, models.QuestionMapLabelling, models.QuestionHeadings:
+		for _, value := range answer.Trimmed() {
+			if !groupLabels[strings.ToUpper(value)] {
+				problems = append(problems, fmt.Sprintf("question %v: answer %q is not one of the matching options", no, value))
+			}
+		}
+	case models.QuestionGapFill:
+		for _, value := range answer.Trimmed() {
+			for _, alternative := range splitAlternatives(value) {
+				found := false
+				for _, variant := range expandOptionalWords(alternative) {
+					variant = strings.ToLower(strings.Join(strings.Fields(variant), " "))
+					if strings.Contains(article, variant) {
+						found = true
+					}
+					if hasLimit && ExceedsWordLimit(variant, wordLimit) {
+						problems = append(problems, fmt.Sprintf("question %v: answer %q exceeds the word limit", no, variant))
+					}
+				}
+				if !found {
+					problems = append(problems, fmt.Sprintf("question %v: answer %q does not appear in the text", no, alternative))
+				}
+			}
+		}
+	}
+	return problems
+}
+
+// optionLabels 选项标签（统一大写）
+func optionLabels(options interface{}) map[string]bool {
+	labels := make(map[string]bool)
+	for _, option := range interfaceMaps(options) {
+		if label, ok := option["label"].(string); ok {
+			labels[strings.ToUpper(strings.TrimSpace(label))] = true
+		}
+	}
+	return labels
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/Queen2333/ielts_test_backend/jobs"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -35,6 +36,19 @@ func InitRedis() error {
 	return nil
 }
 
+func init() {
+	// 后台任务使用同一个 Redis 连接
+	jobs.UseClient(RedisClient)
+}
+
+// RedisClient 返回 Redis 客户端（未初始化时先初始化）
+func RedisClient() (*redis.Client, error) {
+	if err := InitRedis(); err != nil {
+		return nil, err
+	}
+	return rdb, nil
+}
+
 // Set 设置一个键值对 (如果过期时间小于等于0，则表示不设置过期时间)
 func Set(key string, value interface{}, expiration time.Duration) error {
 	ctx := context.Background()
@@ -63,6 +77,44 @@ func Get(key string) (string, error) {
 	return val, nil
 }
 
+// Incr 计数加一，第一次计数时设置过期时间，返回加一后的值
+// SET NX EX 和 INCR 在同一个 MULTI 中执行，进程中途退出也不会留下没有过期时间的计数
+func Incr(key string, expiration time.Duration) (int64, error) {
+	ctx := context.Background()
+	if expiration <= 0 {
+		return rdb.Incr(ctx, key).Result()
+	}
+	var incr *redis.IntCmd
+	if _, err := rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SetNX(ctx, key, 0, expiration)
+		incr = pipe.Incr(ctx, key)
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+// Decr 计数减一
+func Decr(key string) error {
+	return rdb.Decr(context.Background(), key).Err()
+}
+
+// SetNX 键不存在时设置，返回是否设置成功
+func SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
+	return rdb.SetNX(context.Background(), key, value, expiration).Result()
+}
+
+// Del 删除键，返回实际删除的数量
+func Del(keys ...string) (int64, error) {
+	return rdb.Del(context.Background(), keys...).Result()
+}
+
+// TTL 获取键的剩余过期时间
+func TTL(key string) (time.Duration, error) {
+	return rdb.TTL(context.Background(), key).Result()
+}
+
 // 测试 Redis 写的一段代码
 
 /****** 这一段写在main函数里 *****/
@@ -0,0 +1,291 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Queen2333/ielts_test_backend/models"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// 会话配置
+const (
+	SessionTTL            = 30 * 24 * time.Hour // 会话（refresh token）有效期，每次刷新后重新计算
+	sessionTouchInterval  = time.Minute         // last_seen 最多每分钟更新一次
+	sessionDeviceMaxLen   = 255
+	refreshTokenSize      = 32
+	refreshTokenUsedValue = "used:"
+)
+
+var (
+	// ErrSessionNotFound 会话不存在、已过期或已注销
+	ErrSessionNotFound = errors.New("session not found or revoked")
+	// ErrRefreshTokenInvalid refresh token 无效或已过期
+	ErrRefreshTokenInvalid = errors.New("invalid or expired refresh token")
+	// ErrRefreshTokenReused refresh token 已经使用过（可能被盗用），会话已被注销
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected, session revoked")
+)
+
+// sessionRecord Redis 中保存的会话，RefreshHash 为当前有效的 refresh token 哈希
+type sessionRecord struct {
+	models.SessionItem
+	RefreshHash string `json:"refresh_hash"`
+}
+
+func sessionKey(id string) string          { return "session:" + id }
+func userSessionsKey(userID string) string { return "sessions:" + userID }
+func refreshKey(hash string) string        { return "refresh:" + hash }
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func newRefreshToken() (string, error) {
+	b := make([]byte, refreshTokenSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// CreateSession 登录成功后创建会话，返回会话和 refresh token（明文只返回这一次）
+func CreateSession(userID, device, ip string) (*models.SessionItem, string, error) {
+	if err := InitRedis(); err != nil {
+		return nil, "", err
+	}
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	if len(device) > sessionDeviceMaxLen {
+		device = device[:sessionDeviceMaxLen]
+	}
+	record := &sessionRecord{
+		SessionItem: models.SessionItem{
+			ID:        uuid.NewString(),
+			UserID:    userID,
+			Device:    device,
+			IP:        ip,
+			CreatedAt: now.Format(timeLayout),
+			LastSeen:  now.Format(timeLayout),
+			ExpiresAt: now.Add(SessionTTL).Format(timeLayout),
+		},
+		RefreshHash: hashRefreshToken(refreshToken),
+	}
+
+	ctx := context.Background()
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, "", err
+	}
+	_, err = rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, sessionKey(record.ID), data, SessionTTL)
+		pipe.Set(ctx, refreshKey(record.RefreshHash), record.ID, SessionTTL)
+		pipe.SAdd(ctx, userSessionsKey(userID), record.ID)
+		pipe.Expire(ctx, userSessionsKey(userID), SessionTTL)
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return &record.SessionItem, refreshToken, nil
+}
+
+func loadSession(id string) (*sessionRecord, error) {
+	val, err := Get(sessionKey(id))
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	var record sessionRecord
+	if err := json.Unmarshal([]byte(val), &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func saveSession(record *sessionRecord, ttl time.Duration) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return Set(sessionKey(record.ID), data, ttl)
+}
+
+// TouchSession 确认会话有效（JWT 中间件每个请求调用），并按间隔更新最后访问时间和 IP
+func TouchSession(id, userID, ip string) error {
+	if err := InitRedis(); err != nil {
+		return err
+	}
+	record, err := loadSession(id)
+	if err != nil {
+		return err
+	}
+	if record.UserID != userID {
+		return ErrSessionNotFound
+	}
+
+	lastSeen, err := time.ParseInLocation(timeLayout, record.LastSeen, time.Local)
+	if err == nil && time.Since(lastSeen) < sessionTouchInterval && record.IP == ip {
+		return nil
+	}
+	ttl, err := TTL(sessionKey(id))
+	if err != nil || ttl <= 0 {
+		return nil
+	}
+	record.LastSeen = time.Now().Format(timeLayout)
+	record.IP = ip
+	return saveSession(record, ttl)
+}
+
+// RotateRefreshToken 使用 refresh token 换取新的 refresh token（旧的立即失效），返回会话
+// 已经使用过的 refresh token 再次出现说明可能被盗用，注销整个会话并返回 ErrRefreshTokenReused
+func RotateRefreshToken(refreshToken, ip string) (*models.SessionItem, string, error) {
+	if err := InitRedis(); err != nil {
+		return nil, "", err
+	}
+	if refreshToken == "" {
+		return nil, "", ErrRefreshTokenInvalid
+	}
+	ctx := context.Background()
+	oldHash := hashRefreshToken(refreshToken)
+
+	// 原子地把旧 token 标记为已使用，并发的两次刷新只有一次成功
+	previous, err := rdb.GetSet(ctx, refreshKey(oldHash), refreshTokenUsedValue).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			rdb.Del(ctx, refreshKey(oldHash))
+			return nil, "", ErrRefreshTokenInvalid
+		}
+		return nil, "", err
+	}
+	if strings.HasPrefix(previous, refreshTokenUsedValue) {
+		sessionID := strings.TrimPrefix(previous, refreshTokenUsedValue)
+		rdb.Set(ctx, refreshKey(oldHash), previous, SessionTTL)
+		if record, err := loadSession(sessionID); err == nil {
+			RevokeSession(record.UserID, sessionID)
+			AuditAuth(models.AuditRefreshReused, "", ip, record.UserID, "session "+sessionID)
+		}
+		return nil, "", ErrRefreshTokenReused
+	}
+	sessionID := previous
+	// 记住旧 token 属于哪个会话，用于检测重复使用
+	rdb.Set(ctx, refreshKey(oldHash), refreshTokenUsedValue+sessionID, SessionTTL)
+
+	record, err := loadSession(sessionID)
+	if err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			return nil, "", ErrRefreshTokenInvalid
+		}
+		return nil, "", err
+	}
+	if record.RefreshHash != oldHash {
+		// 会话已经轮换到新的 token，旧 token 不应该仍然有效
+		RevokeSession(record.UserID, sessionID)
+		AuditAuth(models.AuditRefreshReused, "", ip, record.UserID, "session "+sessionID)
+		return nil, "", ErrRefreshTokenReused
+	}
+
+	newToken, err := newRefreshToken()
+	if err != nil {
+		return nil, "", err
+	}
+	now := time.Now()
+	record.RefreshHash = hashRefreshToken(newToken)
+	record.LastSeen = now.Format(timeLayout)
+	record.ExpiresAt = now.Add(SessionTTL).Format(timeLayout)
+	record.IP = ip
+	if err := saveSession(record, SessionTTL); err != nil {
+		return nil, "", err
+	}
+	if err := Set(refreshKey(record.RefreshHash), sessionID, SessionTTL); err != nil {
+		return nil, "", err
+	}
+	rdb.Expire(ctx, userSessionsKey(record.UserID), SessionTTL)
+	return &record.SessionItem, newToken, nil
+}
+
+// ListSessions 获取用户的所有有效会话，最近访问的在前；currentID 对应的会话标记为 Current
+func ListSessions(userID, currentID string) ([]models.SessionItem, error) {
+	if err := InitRedis(); err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	ids, err := rdb.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := []models.SessionItem{}
+	for _, id := range ids {
+		record, err := loadSession(id)
+		if err != nil {
+			if errors.Is(err, ErrSessionNotFound) {
+				// 已过期的会话从索引中移除
+				rdb.SRem(ctx, userSessionsKey(userID), id)
+				continue
+			}
+			return nil, err
+		}
+		item := record.SessionItem
+		item.Current = item.ID == currentID
+		sessions = append(sessions, item)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeen > sessions[j].LastSeen
+	})
+	return sessions, nil
+}
+
+// RevokeSession 注销用户的一个会话，会话不存在或不属于该用户时返回 ErrSessionNotFound
+func RevokeSession(userID, id string) error {
+	if err := InitRedis(); err != nil {
+		return err
+	}
+	record, err := loadSession(id)
+	if err != nil {
+		return err
+	}
+	if record.UserID != userID {
+		return ErrSessionNotFound
+	}
+	ctx := context.Background()
+	_, err = rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, sessionKey(id), refreshKey(record.RefreshHash))
+		pipe.SRem(ctx, userSessionsKey(userID), id)
+		return nil
+	})
+	return err
+}
+
+// RevokeUserSessions 注销用户除 exceptID 以外的所有会话（修改、重置密码后调用），返回注销的数量
+func RevokeUserSessions(userID, exceptID string) (int, error) {
+	sessions, err := ListSessions(userID, "")
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, session := range sessions {
+		if session.ID == exceptID {
+			continue
+		}
+		if err := RevokeSession(userID, session.ID); err != nil && !errors.Is(err, ErrSessionNotFound) {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
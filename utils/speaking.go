@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Queen2333/ielts_test_backend/database"
+	"github.com/Queen2333/ielts_test_backend/models"
+)
+
+// 口语组成规则
+const (
+	SpeakingPartCount          = 3   // Part 1、Part 2、Part 3 各一个
+	DefaultCueCardPrepSeconds  = 60  // Part 2 准备时间
+	DefaultCueCardSpeakSeconds = 120 // Part 2 陈述时间
+	CueCardQuestionNo          = "1" // Part 2 只有一道题（话题卡）
+)
+
+// 录音文件上传后的访问路径，对应 UploadFileNative 的保存目录
+const (
+	audioURLPrefix = "/files/audios/"
+	audioUploadDir = "uploads/audio"
+)
+
+// ValidateSpeakingPart 校验口语 part 内容，并为话题卡补充默认时间
+func ValidateSpeakingPart(part *models.SpeakingPartItem) []string {
+	var problems []string
+	switch part.PartType {
+	case models.SpeakingPart1, models.SpeakingPart3:
+		if len(part.QuestionList) == 0 {
+			problems = append(problems, fmt.Sprintf("speaking part %s: question_list is required", part.PartType))
+		}
+		seen := make(map[string]bool)
+		for i, question := range part.QuestionList {
+			if strings.TrimSpace(question.Question) == "" {
+				problems = append(problems, fmt.Sprintf("speaking part %s: question %d is empty", part.PartType, i+1))
+			}
+			// 未填写题号时按顺序编号
+			if strings.TrimSpace(question.No) == "" {
+				part.QuestionList[i].No = strconv.Itoa(i + 1)
+			}
+			if seen[part.QuestionList[i].No] {
+				problems = append(problems, fmt.Sprintf("speaking part %s: duplicate question number %q", part.PartType, part.QuestionList[i].No))
+			}
+			seen[part.QuestionList[i].No] = true
+		}
+		part.CueCard = nil
+	case models.SpeakingPart2:
+		if part.CueCard == nil || strings.TrimSpace(part.CueCard.Topic) == "" {
+			problems = append(problems, "speaking part 2: cue_card topic is required")
+			break
+		}
+		if len(part.CueCard.BulletPoints) == 0 {
+			problems = append(problems, "speaking part 2: cue_card bullet_points are required")
+		}
+		if part.CueCard.PrepSeconds <= 0 {
+			part.CueCard.PrepSeconds = DefaultCueCardPrepSeconds
+		}
+		if part.CueCard.SpeakSeconds <= 0 {
+			part.CueCard.SpeakSeconds = DefaultCueCardSpeakSeconds
+		}
+		if part.Topic == "" {
+			part.Topic = part.CueCard.Topic
+		}
+		part.QuestionList = []models.SpeakingQuestionItem{}
+	default:
+		problems = append(problems, fmt.Sprintf("invalid speaking part_type %q, expected 1, 2 or 3", part.PartType))
+	}
+	return problems
+}
+
+// ValidateSpeakingComposition 校验口语套题：Part 1、Part 2、Part 3 按顺序各一个
+func ValidateSpeakingComposition(ids []int, ownerID string) ([]string, error) {
+	parts, missing, err := LoadVisibleParts("speaking_part_list", ids, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	problems := validatePartCount("speaking", ids, missing, SpeakingPartCount)
+	if len(parts) == SpeakingPartCount {
+		for i, part := range parts {
+			if partType := strings.TrimSpace(fmt.Sprint(part["part_type"])); partType != strconv.Itoa(i+1) {
+				problems = append(problems, fmt.Sprintf("speaking: part %d should be Part %d, got Part %s", i+1, i+1, partType))
+			}
+		}
+	}
+	return problems, nil
+}
+
+// SpeakingQuestionNos 返回口语 part 中需要录音的题号
+func SpeakingQuestionNos(part map[string]interface{}) []string {
+	if strings.TrimSpace(fmt.Sprint(part["part_type"])) == models.SpeakingPart2 {
+		return []string{CueCardQuestionNo}
+	}
+	var nos []string
+	questionList, _ := part["question_list"].([]interface{})
+	for _, questionInterface := range questionList {
+		if question, ok := questionInterface.(map[string]interface{}); ok {
+			if no, ok := question["no"].(string); ok {
+				nos = append(nos, no)
+			}
+		}
+	}
+	return nos
+}
+
+// ValidateAudioURL 校验录音是否为通过 /upload/file 上传的音频文件
+func ValidateAudioURL(audioURL string) error {
+	parsed, err := url.Parse(strings.TrimSpace(audioURL))
+	if err != nil || !strings.HasPrefix(parsed.Path, audioURLPrefix) {
+		return fmt.Errorf("audio_url %q is not an uploaded audio file", audioURL)
+	}
+	filename := filepath.Base(parsed.Path)
+	if _, err := os.Stat(filepath.Join(audioUploadDir, filename)); err != nil {
+		return fmt.Errorf("audio file %q not found", filename)
+	}
+	return nil
+}
+
+// ValidateSpeakingAnswers 校验口语录音：返回多余或无效的作答，以及未录音的题目（part ID:题号）
+func ValidateSpeakingAnswers(parts []map[string]interface{}, answers []models.SpeakingAnswerItem) ([]string, []string) {
+	var problems, missing []string
+
+	questionNos := make(map[int]map[string]bool)
+	for _, part := range parts {
+		id, _ := part["id"].(int)
+		questionNos[id] = make(map[string]bool)
+		for _, no := range SpeakingQuestionNos(part) {
+			questionNos[id][no] = true
+		}
+	}
+
+	answered := make(map[string]bool)
+	for _, answer := range answers {
+		key := fmt.Sprintf("%d:%s", answer.PartID, answer.No)
+		allowed, ok := questionNos[answer.PartID]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("speaking part %d is not part of this test", answer.PartID))
+		case !allowed[answer.No]:
+			problems = append(problems, fmt.Sprintf("speaking part %d has no question %q", answer.PartID, answer.No))
+		case answered[key]:
+			problems = append(problems, fmt.Sprintf("speaking part %d question %q answered more than once", answer.PartID, answer.No))
+		default:
+			if err := ValidateAudioURL(answer.AudioURL); err != nil {
+				problems = append(problems, err.Error())
+				continue
+			}
+			answered[key] = true
+		}
+	}
+
+	for _, part := range parts {
+		id, _ := part["id"].(int)
+		for _, no := range SpeakingQuestionNos(part) {
+			if key := fmt.Sprintf("%d:%s", id, no); !answered[key] {
+				missing = append(missing, key)
+			}
+		}
+	}
+	return problems, missing
+}
+
+// ApplySpeakingBand 把口语分数写回记录；套题记录同时重新计算综合分
+func ApplySpeakingBand(recordType string, recordID int, band float64) error {
+	if band < 0 || band > 9 || math.Mod(band*2, 1) != 0 {
+		return fmt.Errorf("invalid band %v", band)
+	}
+	switch recordType {
+	case models.GradingRecordTesting:
+		_, err := SetTestingComponentBand(recordID, models.SkillSpeaking, band)
+		return err
+	case models.GradingRecordSpeaking:
+		return database.UpdateColumns("speaking_records", recordID, map[string]interface{}{"band": band})
+	}
+	return fmt.Errorf("invalid record type %q", recordType)
+}
@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/Queen2333/ielts_test_backend/models"
+)
+
+// TestingParts 套题各科目的 part 详情（按套题中的顺序）
+type TestingParts map[string][]map[string]interface{}
+
+// MappedTestingAnswers 按 part ID 分组后的套题作答
+type MappedTestingAnswers struct {
+	Answers  map[string]map[int][]models.AnswerItem // 科目 -> part ID -> 答案
+	Writing  map[int]string                         // 写作 part ID -> 作答内容
+	Seconds  map[int]int                            // 写作 part ID -> 用时（秒）
+	Speaking []models.SpeakingAnswerItem            // 口语录音
+	Problems []string                               // 多余或无法识别的作答
+}
+
+// partQuestionNos 返回 part 中所有题号
+func partQuestionNos(part map[string]interface{}) []string {
+	var nos []string
+	walkQuestions([]map[string]interface{}{part}, func(partIndex int, questionType string, question map[string]interface{}) {
+		if no, ok := question["no"].(string); ok {
+			nos = append(nos, no)
+		}
+	})
+	return nos
+}
+
+// MapTestingAnswers 把提交的作答对应到套题的 part 上，并检查多余的作答
+func MapTestingAnswers(answers []models.TestingAnswerItem, parts TestingParts) MappedTestingAnswers {
+	mapped := MappedTestingAnswers{
+		Answers: map[string]map[int][]models.AnswerItem{
+			models.SkillListening: {},
+			models.SkillReading:   {},
+		},
+		Writing: map[int]string{},
+		Seconds: map[int]int{},
+	}
+
+	// 每个 part 允许的题号（口语 part 只用于判断是否属于套题）
+	questionNos := make(map[string]map[int]map[string]bool)
+	for skill, skillParts := range parts {
+		questionNos[skill] = make(map[int]map[string]bool)
+		for _, part := range skillParts {
+			id, _ := part["id"].(int)
+			questionNos[skill][id] = make(map[string]bool)
+			for _, no := range partQuestionNos(part) {
+				questionNos[skill][id][no] = true
+			}
+		}
+	}
+
+	seenParts := make(map[string]bool)
+	for _, item := range answers {
+		partKey := item.Skill + ":" + strconv.Itoa(item.PartID)
+		allowed, ok := questionNos[item.Skill][item.PartID]
+		switch {
+		case item.Skill != models.SkillListening && item.Skill != models.SkillReading && item.Skill != models.SkillWriting && item.Skill != models.SkillSpeaking:
+			mapped.Problems = append(mapped.Problems, fmt.Sprintf("unknown skill %q", item.Skill))
+			continue
+		case !ok:
+			mapped.Problems = append(mapped.Problems, fmt.Sprintf("%s part %d is not part of this test", item.Skill, item.PartID))
+			continue
+		case seenParts[partKey]:
+			mapped.Problems = append(mapped.Problems, fmt.Sprintf("%s part %d answered more than once", item.Skill, item.PartID))
+			continue
+		}
+		seenParts[partKey] = true
+
+		if item.Skill == models.SkillWriting {
+			mapped.Writing[item.PartID] = item.Text
+			mapped.Seconds[item.PartID] = item.SecondsSpent
+			continue
+		}
+		// 口语录音的题号和音频文件由 ValidateSpeakingAnswers 校验
+		if item.Skill == models.SkillSpeaking {
+			for _, recording := range item.Recordings {
+				recording.PartID = item.PartID
+				mapped.Speaking = append(mapped.Speaking, recording)
+			}
+			continue
+		}
+
+		seenNos := make(map[string]bool)
+		for _, answer := range item.AnswerList {
+			if !allowed[answer.No] {
+				mapped.Problems = append(mapped.Problems, fmt.Sprintf("%s part %d has no question %q", item.Skill, item.PartID, answer.No))
+				continue
+			}
+			if seenNos[answer.No] {
+				mapped.Problems = append(mapped.Problems, fmt.Sprintf("%s part %d question %q answered more than once", item.Skill, item.PartID, answer.No))
+				continue
+			}
+			seenNos[answer.No] = true
+			mapped.Answers[item.Skill][item.PartID] = append(mapped.Answers[item.Skill][item.PartID], answer)
+		}
+	}
+	return mapped
+}
+
+// GradeSection 逐个 part 评分，题号只在所属 part 内匹配
+func GradeSection(skill string, parts []map[string]interface{}, answers map[int][]models.AnswerItem) models.SectionScore {
+	result := models.ScoreResult{Questions: []models.QuestionResult{}}
+	section := models.SectionScore{Skill: skill}
+	for i, part := range parts {
+		id, _ := part["id"].(int)
+		partResult := GradeParts([]map[string]interface{}{part}, answers[id])
+		for _, question := range partResult.Questions {
+			question.PartIndex = i + 1
+			result.Questions = append(result.Questions, question)
+			if question.UserAnswer.IsEmpty() {
+				section.Missing = append(section.Missing, question.No)
+			}
+		}
+		result.RawScore += partResult.RawScore
+		result.Total += partResult.Total
+	}
+	section.RawScore = result.RawScore
+	section.Total = result.Total
+	section.Result = &result
+	return section
+}
+
+// WritingSection 写作部分只记录未作答的 part，Band 分由评分后填入
+func WritingSection(parts []map[string]interface{}, writing map[int]string) models.SectionScore {
+	section := models.SectionScore{Skill: models.SkillWriting, Total: len(parts)}
+	for _, part := range parts {
+		id, _ := part["id"].(int)
+		if text, ok := writing[id]; ok && text != "" {
+			section.RawScore++
+		} else {
+			section.Missing = append(section.Missing, strconv.Itoa(id))
+		}
+	}
+	return section
+}
+
+// SpeakingSection 口语部分记录未录音的题目，Band 分由评分后填入
+func SpeakingSection(parts []map[string]interface{}, recordings []models.SpeakingAnswerItem) (models.SectionScore, []string) {
+	problems, missing := ValidateSpeakingAnswers(parts, recordings)
+	section := models.SectionScore{Skill: models.SkillSpeaking, Missing: missing}
+	for _, part := range parts {
+		section.Total += len(SpeakingQuestionNos(part))
+	}
+	section.RawScore = section.Total - len(missing)
+	return section, problems
+}
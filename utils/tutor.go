@@ -0,0 +1,432 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Queen2333/ielts_test_backend/database"
+	"github.com/Queen2333/ielts_test_backend/models"
+	"github.com/Queen2333/ielts_test_backend/services"
+)
+
+// TutorHistoryLimit 每次提问带上的历史消息条数
+const TutorHistoryLimit = 20
+
+// tutorContextLimit 注入对话的题目内容最大字符数
+const tutorContextLimit = 12000
+
+// defaultTutorDailyLimit 每个用户每天的提问次数，可通过 TUTOR_DAILY_LIMIT 修改
+const defaultTutorDailyLimit = 30
+
+var (
+	ErrTutorNotFound      = errors.New("conversation not found")
+	ErrTutorQuotaExceeded = errors.New("daily tutor quota exceeded")
+)
+
+const tutorSystemPrompt = `You are a friendly IELTS tutor. Answer the student's questions about the material below.
+Explain answers by pointing to the exact words in the passage or transcript, keep explanations concise, and answer in the language the student uses.
+If the material does not contain enough information, say so instead of guessing.`
+
+// 做题记录对应的表
+var tutorRecordTables = map[string]string{
+	models.SkillListening: "listening_records",
+	models.SkillReading:   "reading_records",
+	models.SkillWriting:   "writing_records",
+	models.SkillSpeaking:  "speaking_records",
+	"testing":             "testing_records",
+}
+
+// TutorDailyLimit 每天的提问次数
+func TutorDailyLimit() int {
+	if limit, err := strconv.Atoi(os.Getenv("TUTOR_DAILY_LIMIT")); err == nil && limit >= 0 {
+		return limit
+	}
+	return defaultTutorDailyLimit
+}
+
+func tutorQuotaKey(userID string) string {
+	return fmt.Sprintf("tutor:quota:%s:%s", userID, time.Now().Format("2006-01-02"))
+}
+
+// GetTutorQuota 获取用户当天的提问次数
+func GetTutorQuota(userID string) (models.TutorQuota, error) {
+	quota := models.TutorQuota{Limit: TutorDailyLimit()}
+	if err := InitRedis(); err != nil {
+		return quota, err
+	}
+	value, err := Get(tutorQuotaKey(userID))
+	if err == nil {
+		quota.Used, _ = strconv.Atoi(value)
+	}
+	quota.Remaining = quota.Limit - quota.Used
+	if quota.Remaining < 0 {
+		quota.Remaining = 0
+	}
+	return quota, nil
+}
+
+// ReserveTutorQuota 占用一次提问次数，超出限制时返回 ErrTutorQuotaExceeded
+func ReserveTutorQuota(userID string) error {
+	if err := InitRedis(); err != nil {
+		return err
+	}
+	key := tutorQuotaKey(userID)
+	count, err := Incr(key, 48*time.Hour)
+	if err != nil {
+		return err
+	}
+	if int(count) > TutorDailyLimit() {
+		Decr(key)
+		return ErrTutorQuotaExceeded
+	}
+	return nil
+}
+
+// ReleaseTutorQuota 模型调用失败时退回提问次数
+func ReleaseTutorQuota(userID string) {
+	if err := InitRedis(); err == nil {
+		Decr(tutorQuotaKey(userID))
+	}
+}
+
+// PrepareTutorConversation 校验关联的 part 和做题记录，并生成注入对话的题目内容
+func PrepareTutorConversation(userID string, conversation *models.TutorConversationItem) error {
+	var studentContext string
+	if conversation.RecordType != "" {
+		var err error
+		studentContext, err = tutorRecordContext(userID, conversation)
+		if err != nil {
+			return err
+		}
+	}
+
+	switch conversation.Skill {
+	case models.SkillListening, models.SkillReading, models.SkillWriting, models.SkillSpeaking:
+	default:
+		return fmt.Errorf("invalid skill %q", conversation.Skill)
+	}
+	if conversation.PartID == 0 {
+		return fmt.Errorf("part_id is required")
+	}
+	part, err := database.GetDataById(conversation.Skill+"_part_list", conversation.PartID)
+	if err != nil {
+		return ErrTutorNotFound
+	}
+	// 用户自建的 part 只有创建者可以使用
+	if fmt.Sprint(part["type"]) == "3" && part["user_id"] != userID {
+		return ErrTutorNotFound
+	}
+
+	var b strings.Builder
+	writeTutorPart(&b, conversation.Skill, part, conversation.QuestionNo)
+	if studentContext != "" {
+		b.WriteString("\n")
+		b.WriteString(studentContext)
+	}
+	conversation.Context = truncateRunes(b.String(), tutorContextLimit)
+
+	if conversation.Title == "" {
+		name, _ := part["name"].(string)
+		conversation.Title = strings.TrimSpace(fmt.Sprintf("%s %s", skillNames[conversation.Skill], name))
+		if conversation.QuestionNo != "" {
+			conversation.Title += " Q" + conversation.QuestionNo
+		}
+	}
+	return nil
+}
+
+// tutorRecordContext 读取做题记录中学生的作答，并补全科目和 part
+func tutorRecordContext(userID string, conversation *models.TutorConversationItem) (string, error) {
+	tableName, ok := tutorRecordTables[conversation.RecordType]
+	if !ok {
+		return "", fmt.Errorf("invalid record type %q", conversation.RecordType)
+	}
+	record, err := database.GetDataById(tableName, conversation.RecordID)
+	if err != nil || record["user_id"] != userID {
+		return "", ErrTutorNotFound
+	}
+	if conversation.RecordType != "testing" {
+		conversation.Skill = conversation.RecordType
+	}
+
+	switch conversation.Skill {
+	case models.SkillListening, models.SkillReading:
+		var result *models.ScoreResult
+		if conversation.RecordType == "testing" {
+			sections, _ := ParseSections(record["sections"])
+			for _, section := range sections {
+				if section.Skill == conversation.Skill {
+					result = section.Result
+				}
+			}
+		} else if record["result"] != nil {
+			result = &models.ScoreResult{}
+			if err := DecodeJSONValue(record["result"], result); err != nil {
+				return "", err
+			}
+		}
+		if result == nil || conversation.QuestionNo == "" {
+			return "", nil
+		}
+		for _, question := range result.Questions {
+			if question.No != conversation.QuestionNo {
+				continue
+			}
+			if conversation.PartID == 0 {
+				conversation.PartID = question.PartID
+			}
+			verdict := "incorrect"
+			if question.Correct {
+				verdict = "correct"
+			}
+			return fmt.Sprintf("Student's answer to question %s: %s (%s). Correct answer: %s.\n",
+				question.No, formatAnswer(question.UserAnswer), verdict, formatAnswer(question.CorrectAnswer)), nil
+		}
+		return "", nil
+	case models.SkillWriting:
+		essays, _, err := LoadWritingEssays(conversation.RecordType, conversation.RecordID)
+		if err != nil {
+			return "", err
+		}
+		for _, essay := range essays {
+			if conversation.PartID == 0 {
+				conversation.PartID = essay.PartID
+			}
+			if essay.PartID == conversation.PartID {
+				return fmt.Sprintf("Student's essay (%d words):\n%s\n", essay.WordCount, essay.Text), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// writeTutorPart 写入 part 的原文和题目；指定题号时只写入该题所在的题组
+func writeTutorPart(b *strings.Builder, skill string, part map[string]interface{}, questionNo string) {
+	switch skill {
+	case models.SkillWriting:
+		fmt.Fprintf(b, "IELTS Writing Task %v\nPrompt: %v\n", part["task_type"], part["title"])
+		if subTitle, _ := part["sub_title"].(string); subTitle != "" {
+			fmt.Fprintf(b, "%s\n", subTitle)
+		}
+		if description, _ := part["img_description"].(string); description != "" {
+			fmt.Fprintf(b, "Description of the visual: %s\n", description)
+		}
+		return
+	case models.SkillSpeaking:
+		var speaking models.SpeakingPartItem
+		DecodeJSONValue(part, &speaking)
+		fmt.Fprintf(b, "IELTS Speaking Part %s\nTopic: %s\n", speaking.PartType, speaking.Topic)
+		for _, question := range speaking.QuestionList {
+			fmt.Fprintf(b, "%s. %s\n", question.No, question.Question)
+		}
+		if speaking.CueCard != nil {
+			fmt.Fprintf(b, "Cue card: %s\n", speaking.CueCard.Topic)
+			for _, point := range speaking.CueCard.BulletPoints {
+				fmt.Fprintf(b, "- %s\n", point)
+			}
+		}
+		return
+	}
+
+	fmt.Fprintf(b, "IELTS %s: %v\n", skill, part["name"])
+	if article, _ := part["article"].(string); article != "" {
+		fmt.Fprintf(b, "\nPassage:\n%s\n", stripHTML(article))
+	}
+	typeList, _ := part["type_list"].([]interface{})
+	for _, typeItemInterface := range typeList {
+		typeItem, ok := typeItemInterface.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		questions, _ := typeItem["question_list"].([]interface{})
+		if questionNo != "" && !containsQuestion(questions, questionNo) {
+			continue
+		}
+		fmt.Fprintf(b, "\nQuestion group (%v): %v\n", typeItem["type"], stripHTML(fmt.Sprint(typeItem["title"])))
+		if content, _ := typeItem["article_content"].(string); content != "" {
+			fmt.Fprintf(b, "%s\n", stripHTML(content))
+		}
+		for _, matching := range interfaceMaps(typeItem["matching_options"]) {
+			fmt.Fprintf(b, "%v. %v\n", matching["label"], matching["content"])
+		}
+		for _, question := range interfaceMaps(questions) {
+			fmt.Fprintf(b, "Q%v. %s\n", question["no"], stripHTML(fmt.Sprint(question["question"])))
+			for _, option := range interfaceMaps(question["options"]) {
+				text := option["text"]
+				if text == nil {
+					text = option["value"]
+				}
+				fmt.Fprintf(b, "   %v. %v\n", option["label"], text)
+			}
+			fmt.Fprintf(b, "   Correct answer: %s\n", formatAnswer(models.NewAnswerValue(question["answer"])))
+		}
+	}
+}
+
+// containsQuestion 题组中是否包含题号（支持 "21-22" 这类区间题号）
+func containsQuestion(questions []interface{}, questionNo string) bool {
+	target, _, ok := ParseQuestionNo(questionNo)
+	for _, question := range interfaceMaps(questions) {
+		no, _ := question["no"].(string)
+		if no == questionNo {
+			return true
+		}
+		if start, end, valid := ParseQuestionNo(no); ok && valid && target >= start && target <= end {
+			return true
+		}
+	}
+	return false
+}
+
+func interfaceMaps(value interface{}) []map[string]interface{} {
+	list, _ := value.([]interface{})
+	var maps []map[string]interface{}
+	for _, item := range list {
+		if m, ok := item.(map[string]interface{}); ok {
+			maps = append(maps, m)
+		}
+	}
+	return maps
+}
+
+func formatAnswer(value models.AnswerValue) string {
+	if len(value.Values) == 0 {
+		return "(blank)"
+	}
+	return strings.Join(value.Values, " / ")
+}
+
+func stripHTML(text string) string {
+	return strings.TrimSpace(htmlTagRegex.ReplaceAllString(text, " "))
+}
+
+func truncateRunes(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit]) + "\n..."
+}
+
+// FindTutorConversation 获取用户的对话，不属于该用户时返回 ErrTutorNotFound
+func FindTutorConversation(userID string, id int) (*models.TutorConversationItem, error) {
+	result, err := database.GetDataById("tutor_conversations", id)
+	if err != nil {
+		return nil, ErrTutorNotFound
+	}
+	var conversation models.TutorConversationItem
+	if err := DecodeJSONValue(result, &conversation); err != nil {
+		return nil, err
+	}
+	if conversation.UserID != userID {
+		return nil, ErrTutorNotFound
+	}
+	return &conversation, nil
+}
+
+// ListTutorConversations 用户的所有对话，最近更新的在前
+func ListTutorConversations(userID string) ([]models.TutorConversationItem, error) {
+	results, _, err := database.PaginationQuery("tutor_conversations", 1, -1, map[string]interface{}{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	conversations := []models.TutorConversationItem{}
+	if err := DecodeJSONValue(results, &conversations); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(conversations, func(i, j int) bool {
+		return conversations[i].UpdatedAt > conversations[j].UpdatedAt
+	})
+	for i := range conversations {
+		conversations[i].Context = ""
+	}
+	return conversations, nil
+}
+
+// TutorMessages 对话中的消息，按时间顺序
+func TutorMessages(conversationID int) ([]models.TutorMessageItem, error) {
+	results, _, err := database.PaginationQuery("tutor_messages", 1, -1, map[string]interface{}{"conversation_id": conversationID})
+	if err != nil {
+		return nil, err
+	}
+	messages := []models.TutorMessageItem{}
+	if err := DecodeJSONValue(results, &messages); err != nil {
+		return nil, err
+	}
+	// 同一秒内提问在回答之前
+	sort.SliceStable(messages, func(i, j int) bool {
+		if messages[i].CreatedAt != messages[j].CreatedAt {
+			return messages[i].CreatedAt < messages[j].CreatedAt
+		}
+		return messages[i].Role == "user" && messages[j].Role != "user"
+	})
+	return messages, nil
+}
+
+// DeleteTutorConversation 删除对话及其消息
+func DeleteTutorConversation(id int) error {
+	if _, err := database.GetDB().Exec("DELETE FROM tutor_messages WHERE conversation_id = ?", id); err != nil {
+		return err
+	}
+	_, err := database.DeleteData("tutor_conversations", id)
+	return err
+}
+
+func saveTutorMessage(message *models.TutorMessageItem) error {
+	message.CreatedAt = time.Now().Format(timeLayout)
+	id, err := database.InsertData("tutor_messages", message, "create")
+	if err != nil {
+		return err
+	}
+	message.ID = id
+	return nil
+}
+
+// StreamTutorReply 保存提问，带上题目内容和历史消息请求模型，流式返回回答并保存
+// 客户端断开时保存已生成的部分
+func StreamTutorReply(ctx context.Context, conversation *models.TutorConversationItem, content string, onDelta services.DeltaFunc) (*models.TutorMessageItem, error) {
+	history, err := TutorMessages(conversation.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(history) > TutorHistoryLimit {
+		history = history[len(history)-TutorHistoryLimit:]
+	}
+
+	question := &models.TutorMessageItem{ConversationID: conversation.ID, Role: "user", Content: content}
+	if err := saveTutorMessage(question); err != nil {
+		return nil, err
+	}
+
+	messages := []services.Message{{Role: "system", Content: tutorSystemPrompt + "\n\nMaterial:\n" + conversation.Context}}
+	for _, message := range history {
+		messages = append(messages, services.Message{Role: message.Role, Content: message.Content})
+	}
+	messages = append(messages, services.Message{Role: "user", Content: content})
+
+	resp, streamErr := services.DefaultProvider().ChatStream(ctx, services.ChatRequest{Messages: messages}, onDelta)
+	if resp == nil || resp.Content == "" {
+		if streamErr == nil {
+			streamErr = services.ErrEmptyResponse
+		}
+		return nil, streamErr
+	}
+
+	reply := &models.TutorMessageItem{
+		ConversationID: conversation.ID,
+		Role:           "assistant",
+		Content:        resp.Content,
+		TotalTokens:    resp.Usage.TotalTokens,
+	}
+	if err := saveTutorMessage(reply); err != nil {
+		return nil, err
+	}
+	database.UpdateColumns("tutor_conversations", conversation.ID, map[string]interface{}{"updated_at": reply.CreatedAt})
+	return reply, streamErr
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/Queen2333/ielts_test_backend/models"
+)
+
+// 写作最低字数要求
+const (
+	Task1MinWords = 150
+	Task2MinWords = 250
+)
+
+// MinWordsForTask 根据任务类型返回最低字数
+func MinWordsForTask(taskType string) int {
+	if taskType == "1" {
+		return Task1MinWords
+	}
+	return Task2MinWords
+}
+
+// CountWords 按雅思规则统计字数：
+// 连字符连接的词（well-known）、缩写（don't）、数字（1990、25%）都算一个词，单独的标点符号不计
+func CountWords(text string) int {
+	text = htmlTagRegex.ReplaceAllString(text, " ")
+	// 破折号（—、–）和斜杠两侧视为两个词
+	text = strings.NewReplacer("—", " ", "–", " ", "/", " ").Replace(text)
+
+	count := 0
+	for _, token := range strings.Fields(text) {
+		if strings.IndexFunc(token, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// BuildWritingMetrics 按套题中 part 的顺序计算每篇作文的字数、是否字数不足以及用时
+func BuildWritingMetrics(parts []map[string]interface{}, texts map[int]string, seconds map[int]int) []models.WritingTaskMetrics {
+	metrics := []models.WritingTaskMetrics{}
+	for _, part := range parts {
+		id, _ := part["id"].(int)
+		taskType := strings.TrimSpace(fmt.Sprint(part["task_type"]))
+		wordCount := CountWords(texts[id])
+		minWords := MinWordsForTask(taskType)
+		spent := seconds[id]
+		if spent < 0 {
+			spent = 0
+		}
+		metrics = append(metrics, models.WritingTaskMetrics{
+			PartID:       id,
+			TaskType:     taskType,
+			WordCount:    wordCount,
+			MinWords:     minWords,
+			UnderLength:  wordCount < minWords,
+			SecondsSpent: spent,
+		})
+	}
+	return metrics
+}
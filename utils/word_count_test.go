@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/Queen2333/ielts_test_backend/models"
+)
+
+func TestCountWords(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{"empty", "", 0},
+		{"whitespace", " \n\t ", 0},
+		{"simple sentence", "The graph shows a rise.", 5},
+		{"hyphenated word", "a well-known fact", 3},
+		{"contraction", "I don't agree", 3},
+		{"numbers and percentages", "In 1990, 25% of people", 5},
+		{"standalone punctuation", "first , second - third .", 3},
+		{"em dash separates words", "rise—fall", 2},
+		{"spaced en dash", "rise – fall", 2},
+		{"slash separates words", "and/or", 2},
+		{"html tags", "<p>Hello</p><p>world</p>", 2},
+		{"non-latin letters", "café naïve", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CountWords(tt.text); got != tt.want {
+				t.Errorf("CountWords(%q) = %d, want %d", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMinWordsForTask(t *testing.T) {
+	tests := []struct {
+		taskType string
+		want     int
+	}{
+		{"1", Task1MinWords},
+		{"2", Task2MinWords},
+		{"", Task2MinWords},
+	}
+	for _, tt := range tests {
+		if got := MinWordsForTask(tt.taskType); got != tt.want {
+			t.Errorf("MinWordsForTask(%q) = %d, want %d", tt.taskType, got, tt.want)
+		}
+	}
+}
+
+func TestBuildWritingMetrics(t *testing.T) {
+	parts := []map[string]interface{}{
+		{"id": 1, "task_type": "1"},
+		{"id": 2, "task_type": " 2 "},
+	}
+	texts := map[int]string{1: "one two three", 2: ""}
+	seconds := map[int]int{1: 600, 2: -5}
+
+	got := BuildWritingMetrics(parts, texts, seconds)
+	want := []models.WritingTaskMetrics{
+		{PartID: 1, TaskType: "1", WordCount: 3, MinWords: Task1MinWords, UnderLength: true, SecondsSpent: 600},
+		{PartID: 2, TaskType: "2", WordCount: 0, MinWords: Task2MinWords, UnderLength: true, SecondsSpent: 0},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("BuildWritingMetrics() returned %d items, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("BuildWritingMetrics()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
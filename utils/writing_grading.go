@@ -0,0 +1,245 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Queen2333/ielts_test_backend/database"
+	"github.com/Queen2333/ielts_test_backend/models"
+)
+
+var writingCriteria = map[string]bool{
+	models.CriterionTaskAchievement:   true,
+	models.CriterionCoherenceCohesion: true,
+	models.CriterionLexicalResource:   true,
+	models.CriterionGrammaticalRange:  true,
+}
+
+// TaskBand 单篇作文分数：四项标准的平均分，向下取到半分（如 6.75 -> 6.5）
+func TaskBand(scores models.CriteriaScores) float64 {
+	average := (scores.TaskAchievement + scores.CoherenceCohesion + scores.LexicalResource + scores.GrammaticalRange) / 4
+	return math.Floor(average*2+1e-9) / 2
+}
+
+// WritingBand 写作总分：Task 2 权重为 Task 1 的两倍，取最接近的半分
+// 只有一篇作文时直接使用该篇分数
+func WritingBand(tasks []models.TaskGrade) (float64, error) {
+	var task1, task2 *float64
+	for i := range tasks {
+		switch tasks[i].TaskType {
+		case "1":
+			task1 = &tasks[i].Band
+		case "2":
+			task2 = &tasks[i].Band
+		}
+	}
+	switch {
+	case task1 != nil && task2 != nil:
+		return RoundOverallBand((*task1 + 2**task2) / 3), nil
+	case task2 != nil:
+		return *task2, nil
+	case task1 != nil:
+		return *task1, nil
+	}
+	return 0, fmt.Errorf("no graded task")
+}
+
+// validateCriteriaScores 每项标准为 0-9 的整数分
+func validateCriteriaScores(scores models.CriteriaScores) []string {
+	var problems []string
+	for criterion, score := range map[string]float64{
+		models.CriterionTaskAchievement:   scores.TaskAchievement,
+		models.CriterionCoherenceCohesion: scores.CoherenceCohesion,
+		models.CriterionLexicalResource:   scores.LexicalResource,
+		models.CriterionGrammaticalRange:  scores.GrammaticalRange,
+	} {
+		if score < 0 || score > 9 || score != math.Trunc(score) {
+			problems = append(problems, fmt.Sprintf("%s must be a whole band between 0 and 9, got %v", criterion, score))
+		}
+	}
+	return problems
+}
+
+// validateComments 批注范围必须落在作文内
+func validateComments(partID int, comments []models.InlineComment, text string) []string {
+	var problems []string
+	length := utf8.RuneCountInString(text)
+	for i, comment := range comments {
+		if comment.Start < 0 || comment.End <= comment.Start || comment.End > length {
+			problems = append(problems, fmt.Sprintf("part %d comment %d: range [%d, %d) is outside the essay (%d characters)", partID, i+1, comment.Start, comment.End, length))
+		}
+		if comment.Criterion != "" && !writingCriteria[comment.Criterion] {
+			problems = append(problems, fmt.Sprintf("part %d comment %d: unknown criterion %q", partID, i+1, comment.Criterion))
+		}
+		if strings.TrimSpace(comment.Text) == "" {
+			problems = append(problems, fmt.Sprintf("part %d comment %d: text is required", partID, i+1))
+		}
+	}
+	return problems
+}
+
+// PrepareWritingGrade 校验评分并计算每篇作文和写作总分
+// 提交最终评分时每篇作文都必须评分
+func PrepareWritingGrade(grade *models.WritingGradeItem, essays []models.WritingEssay) []string {
+	var problems []string
+	essayByPart := make(map[int]models.WritingEssay)
+	for _, essay := range essays {
+		essayByPart[essay.PartID] = essay
+	}
+
+	graded := make(map[int]bool)
+	for i := range grade.Tasks {
+		task := &grade.Tasks[i]
+		essay, ok := essayByPart[task.PartID]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("part %d is not part of this record", task.PartID))
+			continue
+		}
+		if graded[task.PartID] {
+			problems = append(problems, fmt.Sprintf("part %d graded more than once", task.PartID))
+			continue
+		}
+		graded[task.PartID] = true
+
+		task.TaskType = essay.TaskType
+		problems = append(problems, validateCriteriaScores(task.Scores)...)
+		problems = append(problems, validateComments(task.PartID, task.Comments, essay.Text)...)
+		task.Band = TaskBand(task.Scores)
+	}
+
+	if grade.Status == models.GradeFinal {
+		for _, essay := range essays {
+			if !graded[essay.PartID] {
+				problems = append(problems, fmt.Sprintf("part %d has not been graded", essay.PartID))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return problems
+	}
+
+	if len(grade.Tasks) > 0 {
+		band, err := WritingBand(grade.Tasks)
+		if err != nil {
+			return []string{err.Error()}
+		}
+		grade.Band = band
+	}
+	return nil
+}
+
+// LoadWritingEssays 获取记录中的作文以及记录所属用户
+func LoadWritingEssays(recordType string, recordID int) ([]models.WritingEssay, string, error) {
+	var tableName string
+	switch recordType {
+	case models.GradingRecordWriting:
+		tableName = "writing_records"
+	case models.GradingRecordTesting:
+		tableName = "testing_records"
+	default:
+		return nil, "", fmt.Errorf("invalid record type %q", recordType)
+	}
+
+	record, err := database.GetDataById(tableName, recordID)
+	if err != nil {
+		return nil, "", err
+	}
+	userID, _ := record["user_id"].(string)
+
+	texts := make(map[int]string)
+	var partIDs []int
+	if recordType == models.GradingRecordWriting {
+		var answers []models.WritingAnswerItem
+		if err := DecodeJSONValue(record["answers"], &answers); err != nil {
+			return nil, "", fmt.Errorf("invalid answers in writing record %d: %w", recordID, err)
+		}
+		for _, answer := range answers {
+			texts[answer.PartID] = answer.Text
+			partIDs = append(partIDs, answer.PartID)
+		}
+	} else {
+		var answers []models.TestingAnswerItem
+		if err := DecodeJSONValue(record["answers"], &answers); err != nil {
+			return nil, "", fmt.Errorf("invalid answers in testing record %d: %w", recordID, err)
+		}
+		for _, answer := range answers {
+			if answer.Skill == models.SkillWriting {
+				texts[answer.PartID] = answer.Text
+				partIDs = append(partIDs, answer.PartID)
+			}
+		}
+	}
+
+	parts, _, err := LoadParts("writing_part_list", partIDs)
+	if err != nil {
+		return nil, "", err
+	}
+	var essays []models.WritingEssay
+	for _, part := range parts {
+		id, _ := part["id"].(int)
+		title, _ := part["title"].(string)
+		subTitle, _ := part["sub_title"].(string)
+		imgDesc, _ := part["img_description"].(string)
+		essays = append(essays, models.WritingEssay{
+			PartID:    id,
+			TaskType:  strings.TrimSpace(fmt.Sprint(part["task_type"])),
+			Title:     title,
+			SubTitle:  subTitle,
+			ImgDesc:   imgDesc,
+			Text:      texts[id],
+			WordCount: CountWords(texts[id]),
+		})
+	}
+	return essays, userID, nil
+}
+
+// FindWritingGrades 获取记录的所有评分，graderID 为空时返回所有老师的评分
+func FindWritingGrades(recordType string, recordID int, graderID string) ([]models.WritingGradeItem, error) {
+	conditions := map[string]interface{}{"record_type": recordType, "record_id": recordID}
+	if graderID != "" {
+		conditions["grader_id"] = graderID
+	}
+	results, _, err := database.PaginationQuery("writing_grades", 1, -1, conditions)
+	if err != nil {
+		return nil, err
+	}
+	var grades []models.WritingGradeItem
+	if err := DecodeJSONValue(results, &grades); err != nil {
+		return nil, err
+	}
+	return grades, nil
+}
+
+// SaveWritingGrade 保存老师的评分（同一老师对同一记录只保留一份）
+func SaveWritingGrade(grade *models.WritingGradeItem) error {
+	existing, err := FindWritingGrades(grade.RecordType, grade.RecordID, grade.GraderID)
+	if err != nil {
+		return err
+	}
+	handleType := "create"
+	if len(existing) > 0 {
+		grade.ID = existing[0].ID
+		handleType = "update"
+	}
+	grade.UpdatedAt = time.Now().Format("2006-01-02 15:04:05")
+	id, err := database.InsertData("writing_grades", grade, handleType)
+	if err != nil {
+		return err
+	}
+	if handleType == "create" {
+		grade.ID = id
+	}
+	return nil
+}
+
+// ApplyWritingBand 把最终写作分数写回记录；套题记录同时重新计算综合分
+func ApplyWritingBand(recordType string, recordID int, band float64) error {
+	if recordType == models.GradingRecordTesting {
+		_, err := SetTestingComponentBand(recordID, models.SkillWriting, band)
+		return err
+	}
+	return database.UpdateColumns("writing_records", recordID, map[string]interface{}{"band": band})
+}
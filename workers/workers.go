@@ -0,0 +1,69 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Queen2333/ielts_test_backend/jobs"
+	"github.com/Queen2333/ielts_test_backend/mailer"
+	"github.com/Queen2333/ielts_test_backend/utils"
+)
+
+// DefaultConcurrency 默认 worker 数量，可通过 JOBS_WORKERS 修改
+const DefaultConcurrency = 4
+
+// Concurrency worker 数量；HTTP 服务中为 0 时不启动 worker，由单独的 worker 进程执行任务
+func Concurrency() int {
+	if value, err := strconv.Atoi(os.Getenv("JOBS_WORKERS")); err == nil && value >= 0 {
+		return value
+	}
+	return DefaultConcurrency
+}
+
+// Register 注册所有任务的处理函数
+func Register() {
+	jobs.Handle(jobs.TypeSendEmail, func(ctx context.Context, payload jobs.SendEmailPayload) error {
+		return mailer.Deliver(ctx, payload.OutboxID)
+	})
+
+	jobs.Handle(jobs.TypeGradingReminder, func(ctx context.Context, payload jobs.GradingReminderPayload) error {
+		return utils.SendGradingReminder(payload.QueueID, payload.GraderID)
+	})
+
+	jobs.Handle(jobs.TypeCalibrationAssign, func(ctx context.Context, payload jobs.CalibrationAssignPayload) error {
+		return utils.AssignNextCalibration(payload.GraderID)
+	})
+
+	jobs.Register(jobs.TypeAIWritingFeedback, func(ctx context.Context, job *jobs.Job) error {
+		var payload jobs.AIWritingFeedbackPayload
+		if err := job.Decode(&payload); err != nil {
+			return jobs.Permanent(err)
+		}
+		return utils.GenerateAIWritingFeedback(payload.FeedbackID, payload.RecordType, payload.RecordID, job.Attempts >= job.MaxAttempts)
+	})
+}
+
+// outboxSweepInterval 检查未加入队列的邮件的间隔
+const outboxSweepInterval = 5 * time.Minute
+
+// Run 运行后台任务，并定期把未能加入队列的邮件重新加入队列
+func Run(ctx context.Context, concurrency int) error {
+	go func() {
+		ticker := time.NewTicker(outboxSweepInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := mailer.RequeuePending(); err != nil {
+					fmt.Println("发件箱重新加入队列失败:", err)
+				}
+			}
+		}
+	}()
+	return jobs.Run(ctx, concurrency)
+}